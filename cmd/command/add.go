@@ -8,27 +8,70 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var addDescription string
+var (
+	addDescription string
+	addFrom        string
+	addEnv         map[string]string
+)
+
+const (
+	addMinArgs = 1
+	addMaxArgs = 2
+)
 
-const addRequiredArgs = 2
+var ErrMissingCommand = errors.New("a command string is required unless --from is set")
 
 // AddCmd represents the add command.
 var AddCmd = &cobra.Command{
-	Use:   "add <COMMAND_NAME> <COMMAND_COMMAND>",
+	Use:   "add <COMMAND_NAME> [COMMAND_COMMAND]",
 	Short: "Add a new command to shed",
 	Long: `Add a new command to shed with a name, description, and command string.
 
 The command string can contain parameters using the {{name|description}} syntax.
 
+With --from, the new command is seeded with the body and description of an
+existing command. Any command string or description given is used instead.
+
 Example:
   shed add list_files "ls -la {{path|directory path}}" --description "List files in a directory"
-  shed add greet "echo Hello {{name|person's name}}" -d "Greet someone by name"`,
-	Args: cobra.ExactArgs(addRequiredArgs),
+  shed add greet "echo Hello {{name|person's name}}" -d "Greet someone by name"
+
+  # Clone an existing command under a new name
+  shed add list_home_files --from list_files
+
+  # Clone an existing command and change its body
+  shed add list_all_files --from list_files "ls -lah {{path|directory path}}"
+
+  # Set environment variables for every run of the command
+  shed add list_buckets "aws s3 ls" --env AWS_PROFILE=dev`,
+	Args: cobra.RangeArgs(addMinArgs, addMaxArgs),
 	RunE: func(_ *cobra.Command, args []string) error {
 		commandName := args[0]
-		commandCommand := args[1]
 
-		logger.Debug("Adding command", "name", commandName, "command", commandCommand, "description", addDescription)
+		commandCommand := ""
+		if len(args) == addMaxArgs {
+			commandCommand = args[1]
+		}
+
+		if commandCommand == "" && addFrom == "" {
+			logger.Error("Missing command string", "name", commandName)
+
+			return ErrMissingCommand
+		}
+
+		logger.Debug("Adding command",
+			"name", commandName,
+			"command", commandCommand,
+			"description", addDescription,
+			"from", addFrom,
+		)
+
+		// Checked up front so a bad --env does not leave the command added
+		if err := store.ValidateEnv(addEnv); err != nil {
+			logger.Error("Invalid environment variable", "error", err)
+
+			return err
+		}
 
 		s, err := store.NewStoreFromConfig()
 		if err != nil {
@@ -37,8 +80,22 @@ Example:
 			return err
 		}
 
-		cmd, err := s.AddCommand(commandName, commandCommand, addDescription)
+		defer s.CloseLogged()
+
+		var cmd *store.Command
+		if addFrom != "" {
+			cmd, err = s.AddCommandFrom(addFrom, commandName, commandCommand, addDescription)
+		} else {
+			cmd, err = s.AddCommand(commandName, commandCommand, addDescription)
+		}
+
 		if err != nil {
+			if errors.Is(err, store.ErrCommandNotFound) {
+				logger.Error("Source command not found", "name", addFrom)
+
+				return err
+			}
+
 			if errors.Is(err, store.ErrAlreadyExists) {
 				logger.Error("Command already exists", "name", commandName)
 
@@ -56,12 +113,22 @@ Example:
 			return err
 		}
 
+		if len(addEnv) > 0 {
+			cmd, err = s.SetCommandEnv(cmd.Name, addEnv)
+			if err != nil {
+				logger.Error("Failed to set command env", "error", err)
+
+				return err
+			}
+		}
+
 		logger.Info("Command added successfully",
 			"id", cmd.ID,
 			"name", cmd.Name,
 			"command", cmd.Command,
 			"description", cmd.Description,
 			"parameters", len(cmd.Parameters),
+			"env", len(cmd.Env),
 		)
 
 		return nil
@@ -70,4 +137,6 @@ Example:
 
 func init() {
 	AddCmd.Flags().StringVarP(&addDescription, "description", "d", "", "Description of the command")
+	AddCmd.Flags().StringVar(&addFrom, "from", "", "Existing command to seed the new command from")
+	AddCmd.Flags().StringToStringVar(&addEnv, "env", nil, "Environment variables to set when the command runs (KEY=VALUE)")
 }
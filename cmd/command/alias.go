@@ -0,0 +1,95 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+const (
+	aliasMinArgs = 1
+	aliasMaxArgs = 2
+)
+
+var aliasRemove bool
+
+var ErrAliasArgs = errors.New("give an alias and a command name, or --rm and an alias")
+
+// AliasCmd represents the alias command.
+var AliasCmd = &cobra.Command{
+	Use:   "alias <ALIAS> <COMMAND_NAME>",
+	Short: "Reach a command by another name",
+	Long: `Add an alias so a stored command can also be reached by another name, as
+in shed run k for kubectl_get. Aliases follow the same rules as command names
+and cannot reuse the name of a command or another alias.
+
+Removing a command removes its aliases too, and shed undo brings them back.
+
+Examples:
+  # Run kubectl_get as shed run k
+  shed alias k kubectl_get
+
+  # Remove the alias, leaving kubectl_get in place
+  shed alias --rm k`,
+	Args: cobra.RangeArgs(aliasMinArgs, aliasMaxArgs),
+	RunE: func(_ *cobra.Command, args []string) error {
+		if aliasRemove != (len(args) == aliasMinArgs) {
+			logger.Error("Wrong number of arguments", "error", ErrAliasArgs)
+
+			return ErrAliasArgs
+		}
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		alias := args[0]
+
+		if aliasRemove {
+			if err := s.RemoveAlias(alias); err != nil {
+				logger.Error("Failed to remove alias", "alias", alias, "error", err)
+
+				return err
+			}
+
+			logger.Info("Alias removed", "alias", alias)
+
+			return nil
+		}
+
+		commandName := args[1]
+
+		if _, err := s.AddAlias(alias, commandName); err != nil {
+			if errors.Is(err, store.ErrAlreadyExists) {
+				logger.Error("Name is already in use", "alias", alias)
+
+				return err
+			}
+
+			if errors.Is(err, store.ErrCommandNotFound) {
+				logger.Error("Command not found", "name", commandName)
+
+				return err
+			}
+
+			logger.Error("Failed to add alias", "alias", alias, "error", err)
+
+			return err
+		}
+
+		logger.Info("Alias added", "alias", alias, "name", commandName)
+
+		return nil
+	},
+}
+
+func init() {
+	AliasCmd.Flags().BoolVar(&aliasRemove, "rm", false, "Remove the alias instead of adding it")
+}
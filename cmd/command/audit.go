@@ -0,0 +1,81 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+const defaultAuditLimit = 50
+
+var auditLimit int
+
+// AuditCmd represents the audit command.
+var AuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the log of changes made to commands and secrets",
+	Long: `Show the audit log: every command and secret that was added, updated,
+removed, restored, moved or rotated, and every alias added or removed and trash
+purge, oldest first, with when it happened.
+
+Secrets appear by key only; their values are never recorded.
+
+Example:
+  # Show the latest 50 changes
+  shed audit
+
+  # Show the latest 10 changes
+  shed audit --limit 10
+
+  # Show every change
+  shed audit --limit 0`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		logger.Debug("Reading audit log", "limit", auditLimit)
+
+		s, err := store.NewReadOnlyStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		entries, err := s.AuditLog(auditLimit)
+		if err != nil {
+			logger.Error("Failed to read audit log", "error", err)
+
+			return err
+		}
+
+		if len(entries) == 0 {
+			logger.Info("No changes recorded")
+
+			return nil
+		}
+
+		return writeAuditLog(c.OutOrStdout(), entries)
+	},
+}
+
+func init() {
+	AuditCmd.Flags().IntVarP(&auditLimit, "limit", "n", defaultAuditLimit, "Show at most this many of the latest entries (0 for all)")
+}
+
+// writeAuditLog prints audit entries to w, one aligned line each.
+func writeAuditLog(w io.Writer, entries []store.AuditEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.At, e.Op, e.Target, e.Detail)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
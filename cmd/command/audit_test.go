@@ -0,0 +1,50 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestWriteAuditLog(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	if _, err := s.AddSecret("api_token", "s3cr3t", ""); err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	if _, err := s.AddCommand("deploy", "deploy {{!api_token}}", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	entries, err := s.AuditLog(0)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeAuditLog(&buf, entries); err != nil {
+		t.Fatalf("unexpected error writing audit log: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+
+	// Each line is the date, time, op and target, with no detail
+	first, second := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(first) != 4 || first[2] != store.AuditOpAddSecret || first[3] != "api_token" ||
+		len(second) != 4 || second[2] != store.AuditOpAddCommand || second[3] != "deploy" {
+		t.Fatalf("expected the secret then the command, got %q", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Fatalf("expected no secret value in the output, got %q", buf.String())
+	}
+}
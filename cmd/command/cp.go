@@ -1,9 +1,13 @@
 package command
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
 
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/h3jfc/shed/internal/store"
@@ -15,6 +19,8 @@ const (
 	cpMaxArgs = 3
 )
 
+var cpInteractive bool
+
 // CpCmd represents the cp command.
 var CpCmd = &cobra.Command{
 	Use:   "cp <COMMAND_SRC_NAME> <COMMAND_DEST_NAME> [jsonValueParams]",
@@ -25,6 +31,10 @@ Parameters should be provided as a JSON object in the form {"param":"value"}.
 The command will substitute these values in the copied command and remove those
 parameters from the new command.
 
+With --interactive, shed prompts for a value for each parameter of the source
+command not given in the JSON, showing its description. Leave an answer empty
+to keep that parameter in the copy.
+
 Example:
   # Copy without parameter substitution
   shed cp list_files list_home_files
@@ -33,9 +43,12 @@ Example:
   shed cp list_files list_home_files '{"path":"/home/user"}'
 
   # Copy with multiple parameter substitutions
-  shed cp greet greet_john '{"name":"John","title":"Mr."}'`,
+  shed cp greet greet_john '{"name":"John","title":"Mr."}'
+
+  # Prompt for the values to bake in
+  shed cp greet greet_john --interactive`,
 	Args: cobra.RangeArgs(cpMinArgs, cpMaxArgs),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(c *cobra.Command, args []string) error {
 		srcName := args[0]
 		destName := args[1]
 
@@ -64,6 +77,17 @@ Example:
 			return err
 		}
 
+		defer s.CloseLogged()
+
+		if cpInteractive {
+			jsonValueParams, err = promptCopyParams(s, bufio.NewReader(c.InOrStdin()), os.Stderr, srcName, jsonValueParams)
+			if err != nil {
+				logger.Error("Failed to read parameters", "error", err)
+
+				return err
+			}
+		}
+
 		cmd, err := s.CopyCommand(srcName, destName, jsonValueParams)
 		if err != nil {
 			if errors.Is(err, store.ErrCommandNotFound) {
@@ -107,6 +131,40 @@ Example:
 	},
 }
 
+func init() {
+	CpCmd.Flags().BoolVarP(&cpInteractive, "interactive", "i", false,
+		"Prompt for the value of each source parameter not given in the JSON")
+}
+
+// promptCopyParams prompts for the parameters of the source command missing
+// from jsonValueParams and returns the values to bake into the copy as JSON.
+// Empty answers are dropped, so those parameters stay in the copy.
+func promptCopyParams(s *store.Store, r *bufio.Reader, w io.Writer, srcName, jsonValueParams string) (string, error) {
+	src, err := s.GetCommandByName(srcName)
+	if err != nil {
+		return "", err
+	}
+
+	var given map[string]string
+	if err := json.Unmarshal([]byte(jsonValueParams), &given); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	values, err := promptMissingParams(r, w, src.Parameters, given)
+	if err != nil {
+		return "", err
+	}
+
+	maps.DeleteFunc(values, func(_, value string) bool { return value == "" })
+
+	bb, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal parameter values: %w", err)
+	}
+
+	return string(bb), nil
+}
+
 // validateJSON checks if a string is valid JSON object format.
 func validateJSON(jsonStr string) error {
 	var m map[string]string
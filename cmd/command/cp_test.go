@@ -1,7 +1,11 @@
 package command
 
 import (
+	"bufio"
+	"strings"
 	"testing"
+
+	"github.com/h3jfc/shed/internal/store/storetest"
 )
 
 func TestValidateJSON(t *testing.T) { // nolint:funlen
@@ -85,3 +89,40 @@ func TestValidateJSON(t *testing.T) { // nolint:funlen
 		})
 	}
 }
+
+func TestPromptCopyParams(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	if _, err := s.AddCommand("greet", "echo {{greeting|what to say}} {{title}} {{name}}", "greets someone"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	var out strings.Builder
+
+	// title comes from the JSON; greeting is answered and name left empty
+	in := bufio.NewReader(strings.NewReader("hello\n\n"))
+
+	values, err := promptCopyParams(s, in, &out, "greet", `{"title":"Dr."}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.String() != "greeting (what to say): name: " {
+		t.Fatalf("expected prompts for missing parameters only, got %q", out.String())
+	}
+
+	cmd, err := s.CopyCommand("greet", "greet_doc", values)
+	if err != nil {
+		t.Fatalf("unexpected error copying command: %v", err)
+	}
+
+	if cmd.Command != "echo hello Dr. {{name}}" {
+		t.Fatalf("expected %q, got %q", "echo hello Dr. {{name}}", cmd.Command)
+	}
+
+	if len(cmd.Parameters) != 1 || cmd.Parameters[0].Name != "name" {
+		t.Fatalf("expected only %q left as a parameter, got %v", "name", cmd.Parameters)
+	}
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var dedupeMerge bool
+
+// DedupeCmd represents the dedupe command.
+var DedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find commands with identical bodies",
+	Long: `Report groups of commands whose bodies are the same once normalized, such
+as two names saved for one command over time.
+
+With --merge, each group is folded into its oldest command. The others are
+moved to the trash and their names become aliases of the command kept, so
+shed run still finds them under the old names.
+
+Examples:
+  # List duplicate commands
+  shed dedupe
+
+  # Keep the oldest of each group and alias the rest to it
+  shed dedupe --merge`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		if dedupeMerge {
+			merged, err := s.DeduplicateCommands()
+			if err != nil {
+				logger.Error("Failed to merge duplicate commands", "error", err)
+
+				return err
+			}
+
+			for _, keep := range slices.Sorted(maps.Keys(merged)) {
+				logger.Info("Merged duplicates", "kept", keep, "aliased", strings.Join(merged[keep], ", "))
+			}
+
+			logger.Info("Duplicate commands merged", "groups", len(merged))
+
+			return nil
+		}
+
+		dups, err := s.FindDuplicateBodies()
+		if err != nil {
+			logger.Error("Failed to find duplicate commands", "error", err)
+
+			return err
+		}
+
+		if len(dups) == 0 {
+			logger.Info("No duplicate commands found")
+
+			return nil
+		}
+
+		for _, body := range slices.Sorted(maps.Keys(dups)) {
+			logger.Info("Duplicate commands", "names", strings.Join(dups[body], ", "), "command", body)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	DedupeCmd.Flags().BoolVar(&dedupeMerge, "merge", false,
+		"Keep the oldest command of each group and turn the others into aliases of it")
+}
@@ -1,8 +1,12 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"slices"
 	"strings"
 
@@ -13,6 +17,34 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	describeFormatText = "text"
+	describeFormatJSON = "json"
+)
+
+var (
+	describeFormat   string
+	describeTemplate bool
+	describeUsage    bool
+)
+
+var ErrUnknownFormat = errors.New("unknown output format, expected text or json")
+
+// describeOutput is the JSON shape printed by describe --format json.
+type describeOutput struct {
+	Version        int               `json:"version"`
+	ID             int64             `json:"id"`
+	Name           string            `json:"name"`
+	Command        string            `json:"command"`
+	Description    string            `json:"description"`
+	Parameters     json.RawMessage   `json:"parameters"`
+	Env            map[string]string `json:"env"`
+	Secrets        []string          `json:"secrets"`
+	MissingSecrets []string          `json:"missing_secrets"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+}
+
 // DescribeCmd represents the describe command.
 var DescribeCmd = &cobra.Command{
 	Use:   "describe <COMMAND_NAME>",
@@ -25,14 +57,29 @@ Example:
   shed describe list_files
 
   # Describe a command with verbose output
-  shed describe greet -v`,
+  shed describe greet -v
+
+  # Describe a command as indented JSON
+  shed describe greet --format json
+
+  # Print a params object to fill in and pass to shed run, with default values filled in
+  shed describe greet --template
+
+  # Print an example shed run invocation, with default values filled in
+  shed describe greet --usage`,
 	Args: cobra.ExactArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(c *cobra.Command, args []string) error { // nolint:funlen
 		commandName := args[0]
 
-		logger.Debug("Describing command", "name", commandName)
+		logger.Debug("Describing command", "name", commandName, "format", describeFormat)
 
-		s, err := store.NewStoreFromConfig()
+		if describeFormat != describeFormatText && describeFormat != describeFormatJSON {
+			logger.Error("Unknown output format", "format", describeFormat)
+
+			return fmt.Errorf("%w: %q", ErrUnknownFormat, describeFormat)
+		}
+
+		s, err := store.NewReadOnlyStoreFromConfig()
 		if err != nil {
 			logger.Error("Failed to initialize store", "error", err)
 
@@ -52,6 +99,21 @@ Example:
 			return err
 		}
 
+		if describeTemplate || describeUsage {
+			defaults, err := loadDefaultParams(cmd.Name)
+			if err != nil {
+				logger.Error("Failed to load default parameters", "error", err)
+
+				return err
+			}
+
+			if describeTemplate {
+				return writeParamsTemplate(c.OutOrStdout(), cmd.Parameters, defaults)
+			}
+
+			return writeUsage(c.OutOrStdout(), cmd.Name, cmd.Parameters, defaults)
+		}
+
 		ss, err := brackets.ParseSecrets(cmd.Command)
 		if err != nil {
 			logger.Error("Failed to parse command for secrets", "error", err)
@@ -76,6 +138,10 @@ Example:
 			})
 		})
 
+		if describeFormat == describeFormatJSON {
+			return writeDescribeJSON(c.OutOrStdout(), cmd, *secrets, missingSecrets)
+		}
+
 		var sb strings.Builder
 
 		fmt.Fprintf(&sb, "\nID:          %d\n", cmd.ID)
@@ -87,14 +153,12 @@ Example:
 		if len(cmd.Parameters) > 0 {
 			sb.WriteString("\n  Details:")
 			for _, param := range cmd.Parameters {
-				if param.Description != "" {
-					fmt.Fprintf(&sb, "\n    - %s: %s", param.Name, param.Description)
-				} else {
-					fmt.Fprintf(&sb, "\n    - %s", param.Name)
-				}
+				fmt.Fprintf(&sb, "\n    - %s", param)
 			}
 		}
 
+		writeEnv(&sb, cmd.Env)
+
 		fmt.Fprintf(&sb, "\nCreated:     %s\n", cmd.CreatedAt)
 		fmt.Fprintf(&sb, "Updated:     %s\n", cmd.UpdatedAt)
 
@@ -107,6 +171,136 @@ Example:
 	},
 }
 
+func init() {
+	DescribeCmd.Flags().BoolVarP(&describeTemplate, "template", "t", false,
+		"Print a JSON params object for shed run, with default values filled in, instead")
+	DescribeCmd.Flags().BoolVar(&describeUsage, "usage", false,
+		"Print an example shed run invocation instead")
+	DescribeCmd.Flags().StringVarP(&describeFormat, "format", "f", describeFormatText, "Output format: text or json")
+}
+
+// writeDescribeJSON writes the command as indented JSON, with parameters
+// sorted by name.
+func writeDescribeJSON(w io.Writer, cmd *store.Command, secrets []store.Secret, missing []string) error {
+	params, err := cmd.Parameters.MarshalIndent()
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, secret.Key)
+	}
+
+	out := describeOutput{
+		Version:        store.ExportVersion,
+		ID:             cmd.ID,
+		Name:           cmd.Name,
+		Command:        cmd.Command,
+		Description:    cmd.Description,
+		Parameters:     params,
+		Env:            cmd.Env,
+		Secrets:        keys,
+		MissingSecrets: missing,
+		CreatedAt:      cmd.CreatedAt,
+		UpdatedAt:      cmd.UpdatedAt,
+	}
+
+	bb, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(w, string(bb)); err != nil {
+		return fmt.Errorf("failed to write command: %w", err)
+	}
+
+	return nil
+}
+
+// writeParamsTemplate writes a JSON object with a value for every non-secret
+// parameter, for filling in and passing to shed run. A value is its default
+// when one is set and empty otherwise.
+func writeParamsTemplate(w io.Writer, params brackets.Parameters, defaults map[string]string) error {
+	values := params.WithoutSecrets().ToValued()
+	for i := range values {
+		values[i].Value = defaults[values[i].Name]
+	}
+
+	bb, err := values.ToJSONObject()
+	if err != nil {
+		return fmt.Errorf("failed to marshal params template: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(w, string(bb)); err != nil {
+		return fmt.Errorf("failed to write params template: %w", err)
+	}
+
+	return nil
+}
+
+// writeUsage writes an example shed run invocation for the command, with each
+// non-secret parameter set to its default value or a <name> placeholder, and
+// lists the secrets that need a stored value.
+func writeUsage(w io.Writer, name string, params brackets.Parameters, defaults map[string]string) error {
+	values := make(map[string]string)
+
+	for _, param := range params.WithoutSecrets() {
+		value, ok := defaults[param.Name]
+		if !ok {
+			value = "<" + param.Name + ">"
+		}
+
+		values[param.Name] = value
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("shed run " + name)
+
+	if len(values) > 0 {
+		var buf bytes.Buffer
+
+		enc := json.NewEncoder(&buf)
+		// Keep the <name> placeholders readable
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(values); err != nil {
+			return fmt.Errorf("failed to marshal usage params: %w", err)
+		}
+
+		sb.WriteString(" " + brackets.ShellQuoteForExport(strings.TrimSpace(buf.String())))
+	}
+
+	if secrets := params.OnlySecrets(); len(secrets) > 0 {
+		keys := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			keys = append(keys, secret.Key)
+		}
+
+		fmt.Fprintf(&sb, "\n# needs stored secrets (shed secret add): %s", strings.Join(keys, ", "))
+	}
+
+	if _, err := fmt.Fprintln(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+
+	return nil
+}
+
+func writeEnv(sb *strings.Builder, env map[string]string) {
+	if len(env) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "\nEnv:         %d", len(env))
+	sb.WriteString("\n  Details:")
+
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		fmt.Fprintf(sb, "\n    - %s=%s", k, env[k])
+	}
+}
+
 func writeSecrets(sb strings.Builder, secrets *[]store.Secret) {
 	fmt.Fprintf(&sb, "Secrets:  %d", len(*secrets))
 
@@ -0,0 +1,146 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+func TestWriteDescribeJSON(t *testing.T) { // nolint:funlen
+	t.Parallel()
+
+	cmd := &store.Command{
+		ID:          7,
+		Name:        "deploy",
+		Command:     "deploy {{version}} {{env|target}} {{!token}}",
+		Description: "deploys the app",
+		Parameters: brackets.Parameters{
+			{Name: "version"},
+			{Name: "env", Description: "target"},
+		},
+		Env:       map[string]string{"REGION": "us-east-1"},
+		CreatedAt: "2025-01-01 00:00:00",
+		UpdatedAt: "2025-01-02 00:00:00",
+	}
+
+	want := `{
+  "version": 2,
+  "id": 7,
+  "name": "deploy",
+  "command": "deploy {{version}} {{env|target}} {{!token}}",
+  "description": "deploys the app",
+  "parameters": [
+    {
+      "name": "env",
+      "description": "target"
+    },
+    {
+      "name": "version"
+    }
+  ],
+  "env": {
+    "REGION": "us-east-1"
+  },
+  "secrets": [
+    "token"
+  ],
+  "missing_secrets": [],
+  "created_at": "2025-01-01 00:00:00",
+  "updated_at": "2025-01-02 00:00:00"
+}
+`
+
+	var buf bytes.Buffer
+	if err := writeDescribeJSON(&buf, cmd, []store.Secret{{Key: "token"}}, []string{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != want {
+		t.Fatalf("expected %s, got %s", want, buf.String())
+	}
+}
+
+func TestWriteParamsTemplate(t *testing.T) {
+	t.Parallel()
+
+	params := brackets.Parameters{
+		{Name: "version"},
+		{Name: "env", Description: "target"},
+		{Name: brackets.SecretName("token")},
+	}
+
+	var buf bytes.Buffer
+	defaults := map[string]string{"env": "staging", "token": "leaked"}
+	if err := writeParamsTemplate(&buf, params, defaults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected a JSON object, got %q: %v", buf.String(), err)
+	}
+
+	want := map[string]string{"env": "staging", "version": ""}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWriteUsage(t *testing.T) {
+	t.Parallel()
+
+	params := brackets.Parameters{
+		{Name: "env", Description: "target"},
+		{Name: "version"},
+		{Name: brackets.SecretName("token")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeUsage(&buf, "deploy", params, map[string]string{"env": "it's prod"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a run line and a secrets line, got %q", buf.String())
+	}
+
+	prefix := "shed run deploy '"
+	if !strings.HasPrefix(lines[0], prefix) || !strings.HasSuffix(lines[0], "'") {
+		t.Fatalf("expected a quoted shed run invocation, got %q", lines[0])
+	}
+
+	quoted := strings.TrimSuffix(strings.TrimPrefix(lines[0], prefix), "'")
+
+	var got map[string]string
+	if err := json.Unmarshal([]byte(strings.ReplaceAll(quoted, `'\''`, "'")), &got); err != nil {
+		t.Fatalf("expected a JSON object, got %q: %v", quoted, err)
+	}
+
+	want := map[string]string{"env": "it's prod", "version": "<version>"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if !strings.Contains(lines[1], "token") {
+		t.Fatalf("expected the secret to be noted, got %q", lines[1])
+	}
+}
+
+func TestWriteUsage_NoParams(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := writeUsage(&buf, "list_files", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "shed run list_files\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
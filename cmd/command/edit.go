@@ -2,31 +2,56 @@ package command
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/brackets"
 	"github.com/spf13/cobra"
 )
 
 var (
 	editDescription string
 	editName        string
+	editAddParams   []string
+	editRmParams    []string
+	editBakeParams  []string
+
+	editDescriptionOnly bool
 )
 
 const (
-	editMinArgs = 2
+	editMinArgs = 1
 	editMaxArgs = 3
 )
 
+var (
+	ErrMissingEditCommand = errors.New("a command string is required unless --add-param, --rm-param or --bake is set")
+	ErrDescriptionOnly    = errors.New(
+		"--description-only needs --description and no command string, --name, --add-param, --rm-param or --bake")
+	ErrInvalidBake = errors.New("--bake takes name=value")
+)
+
 // EditCmd represents the edit command.
 var EditCmd = &cobra.Command{
-	Use:   "edit <COMMAND_NAME> [flags] <CLI_COMMAND> [jsonValueParams]",
+	Use:   "edit <COMMAND_NAME> [flags] [CLI_COMMAND] [jsonValueParams]",
 	Short: "Edit an existing command in shed",
 	Long: `Edit an existing command in shed by updating its name, description, command string, or parameters.
 
 The command string can contain parameters using the {{name|description}} syntax.
 You can optionally provide JSON value parameters to hydrate/substitute specific parameters.
 
+With --add-param or --rm-param, a single parameter can be added to or removed
+from the existing command string without retyping it.
+
+With --bake name=value, every use of a parameter is replaced by the value for
+good, so the parameter is no longer part of the command. Secrets cannot be
+baked.
+
+With --description-only, only the description is replaced, by the value of
+--description, which may be empty to clear it.
+
 Examples:
   # Edit command string only
   shed edit list_files "ls -lah {{path|directory path}}"
@@ -40,12 +65,39 @@ Examples:
   # Edit and hydrate a parameter
   shed edit api_call "curl -XGET {{url}} -H {{auth}}" '{"url":"https://api.example.com"}'
 
+  # Add a parameter to the existing command string
+  shed edit list_files --add-param flags="extra ls flags"
+
+  # Remove a parameter from the existing command string
+  shed edit list_files --rm-param flags
+
+  # Always list the same directory, dropping the path parameter
+  shed edit list_files --bake path=/home/user
+
+  # Change only the description
+  shed edit list_files --description-only --description "List files in a directory"
+
   # Edit everything at once
   shed edit old_name --name new_name --description "New description" "new command {{param}}" '{"other":"value"}'`,
 	Args: cobra.RangeArgs(editMinArgs, editMaxArgs),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(c *cobra.Command, args []string) error {
 		commandName := args[0]
-		commandCommand := args[1]
+
+		if editDescriptionOnly {
+			return runEditDescriptionOnly(c, args)
+		}
+
+		commandCommand := ""
+		if len(args) > 1 {
+			commandCommand = args[1]
+		}
+
+		paramEdit := len(editAddParams) > 0 || len(editRmParams) > 0 || len(editBakeParams) > 0
+		if commandCommand == "" && !paramEdit {
+			logger.Error("Missing command string", "name", commandName)
+
+			return ErrMissingEditCommand
+		}
 
 		jsonValueParams := ""
 		if len(args) == editMaxArgs {
@@ -67,6 +119,8 @@ Examples:
 			return err
 		}
 
+		defer s.CloseLogged()
+
 		// Get the existing command
 		existingCmd, err := s.GetCommandByName(commandName)
 		if err != nil {
@@ -81,8 +135,17 @@ Examples:
 			return err
 		}
 
+		if paramEdit {
+			commandCommand, err = editParams(s, commandName, commandCommand)
+			if err != nil {
+				logger.Error("Failed to edit parameters", "error", err)
+
+				return err
+			}
+		}
+
 		// Determine the new name (use existing if not provided)
-		newName := commandName
+		newName := existingCmd.Name
 		if editName != "" {
 			newName = editName
 		}
@@ -115,6 +178,12 @@ Examples:
 				return err
 			}
 
+			if errors.Is(err, store.ErrAlreadyExists) {
+				logger.Error("A command with that name already exists", "name", newName)
+
+				return err
+			}
+
 			logger.Error("Failed to update command", "error", err)
 
 			return err
@@ -135,4 +204,97 @@ Examples:
 func init() {
 	EditCmd.Flags().StringVarP(&editDescription, "description", "d", "", "New description for the command")
 	EditCmd.Flags().StringVarP(&editName, "name", "n", "", "New name for the command")
+	EditCmd.Flags().StringArrayVar(&editAddParams, "add-param", nil,
+		"Append a parameter to the command string, as name or name=\"description\" (repeatable)")
+	EditCmd.Flags().StringArrayVar(&editRmParams, "rm-param", nil,
+		"Remove a parameter from the command string by name (repeatable)")
+	EditCmd.Flags().StringArrayVar(&editBakeParams, "bake", nil,
+		"Replace a parameter with a fixed value in the command string, as name=value (repeatable)")
+	EditCmd.Flags().BoolVar(&editDescriptionOnly, "description-only", false,
+		"Replace only the description, from --description")
+}
+
+// runEditDescriptionOnly handles edit --description-only, which changes the
+// description and nothing else.
+func runEditDescriptionOnly(c *cobra.Command, args []string) error {
+	commandName := args[0]
+
+	if len(args) > 1 || !c.Flags().Changed("description") || editName != "" ||
+		len(editAddParams) > 0 || len(editRmParams) > 0 || len(editBakeParams) > 0 {
+		logger.Error("Conflicting flags", "error", ErrDescriptionOnly)
+
+		return ErrDescriptionOnly
+	}
+
+	s, err := store.NewStoreFromConfig()
+	if err != nil {
+		logger.Error("Failed to initialize store", "error", err)
+
+		return err
+	}
+
+	defer s.CloseLogged()
+
+	cmd, err := s.SetDescription(commandName, editDescription)
+	if err != nil {
+		logger.Error("Failed to update description", "name", commandName, "error", err)
+
+		return err
+	}
+
+	logger.Info("Command description updated", "name", cmd.Name, "description", cmd.Description)
+
+	return nil
+}
+
+// editParams applies --add-param, --rm-param and --bake to the command string,
+// using the stored command body when no command string was given.
+func editParams(s *store.Store, name, command string) (string, error) {
+	if command == "" {
+		raw, err := s.GetCommandRaw(name)
+		if err != nil {
+			return "", err
+		}
+
+		command = raw
+	}
+
+	return applyParamEdits(command, editAddParams, editRmParams, editBakeParams)
+}
+
+// applyParamEdits removes the rm parameters, bakes the bake values given as
+// name=value into the command, then appends the add parameters given as name
+// or name=description.
+func applyParamEdits(command string, add, rm, bake []string) (string, error) {
+	var err error
+
+	for _, name := range rm {
+		command, err = brackets.RemoveParameter(command, strings.TrimSpace(name))
+		if err != nil {
+			return "", fmt.Errorf("failed to remove parameter: %w", err)
+		}
+	}
+
+	for _, param := range bake {
+		name, value, found := strings.Cut(param, "=")
+		if !found {
+			return "", fmt.Errorf("%w: %q", ErrInvalidBake, param)
+		}
+
+		command, _, err = brackets.BakeParameter(command, strings.TrimSpace(name), value)
+		if err != nil {
+			return "", fmt.Errorf("failed to bake parameter: %w", err)
+		}
+	}
+
+	for _, param := range add {
+		name, desc, _ := strings.Cut(param, "=")
+
+		command, err = brackets.AddParameter(command, strings.TrimSpace(name), strings.TrimSpace(desc))
+		if err != nil {
+			return "", fmt.Errorf("failed to add parameter: %w", err)
+		}
+	}
+
+	return command, nil
 }
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+func TestApplyParamEdits(t *testing.T) { // nolint:funlen
+	t.Parallel()
+
+	tests := map[string]struct {
+		command string
+		add     []string
+		rm      []string
+		bake    []string
+		want    string
+		wantErr error
+	}{
+		"add with description": {
+			command: "ls -la {{path}}",
+			add:     []string{"flags=extra ls flags"},
+			want:    "ls -la {{path}} {{flags|extra ls flags}}",
+		},
+		"add without description": {
+			command: "ls -la",
+			add:     []string{"path"},
+			want:    "ls -la {{path}}",
+		},
+		"remove existing": {
+			command: "ls {{flags}} {{path|dir}}",
+			rm:      []string{"flags"},
+			want:    "ls {{path|dir}}",
+		},
+		"remove then add": {
+			command: "ls {{path|dir}}",
+			add:     []string{"path=directory to list"},
+			rm:      []string{"path"},
+			want:    "ls {{path|directory to list}}",
+		},
+		"remove nonexistent": {
+			command: "ls {{path}}",
+			rm:      []string{"missing"},
+			wantErr: brackets.ErrParameterNotFound,
+		},
+		"add existing": {
+			command: "ls {{path}}",
+			add:     []string{"path=dir"},
+			wantErr: brackets.ErrParameterExists,
+		},
+		"bake used parameter": {
+			command: "ls {{flags}} {{path|dir}}",
+			bake:    []string{"path=/home/user"},
+			want:    "ls {{flags}} /home/user",
+		},
+		"bake parameter used twice": {
+			command: "cp {{file}} {{file}}.bak",
+			bake:    []string{"file=notes.txt"},
+			want:    "cp notes.txt notes.txt.bak",
+		},
+		"bake missing parameter": {
+			command: "ls {{path}}",
+			bake:    []string{"missing=x"},
+			wantErr: brackets.ErrParameterNotFound,
+		},
+		"bake without value": {
+			command: "ls {{path}}",
+			bake:    []string{"path"},
+			wantErr: ErrInvalidBake,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := applyParamEdits(tt.command, tt.add, tt.rm, tt.bake)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd represents the export command.
+var ExportCmd = &cobra.Command{
+	Use:   "export <DIR>",
+	Short: "Export every command to its own JSON file",
+	Long: `Export every command to its own <name>.json file in a directory, in the
+format shed describe --format json prints. Keeping one file per command makes
+changes easy to review when the directory is kept in git.
+
+The directory is created if needed. Files of exported commands are replaced;
+other files, including those of commands removed since an earlier export, are
+left alone. Secrets are referenced by key and their values are never
+exported, but command env values are, so files are readable by you only.
+
+Read the directory back with shed import <DIR>.
+
+Example:
+  shed export ~/dotfiles/shed
+  shed import ~/dotfiles/shed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		dir := args[0]
+
+		s, err := store.NewReadOnlyStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		paths, err := s.ExportToDir(dir)
+		if err != nil {
+			logger.Error("Failed to export commands", "dir", dir, "error", err)
+
+			return err
+		}
+
+		for _, p := range paths {
+			logger.Debug("Exported command", "path", p)
+		}
+
+		logger.Info("Commands exported", "dir", dir, "count", len(paths))
+
+		return nil
+	},
+}
@@ -0,0 +1,111 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importOverwrite         bool
+	importMergeDescriptions bool
+)
+
+// ImportCmd represents the import command.
+var ImportCmd = &cobra.Command{
+	Use:   "import <FILE|DIR>",
+	Short: "Import commands from a JSON file or directory",
+	Long: `Import commands from a JSON file holding one command object, as printed by
+shed describe --format json, or an array of them. Given a directory, such as
+one written by shed export, every .json file in it is imported.
+
+Files written by older versions of shed, without a "version" field, are
+upgraded as they are read. Files from a newer version of shed are rejected.
+
+Commands whose name is already taken are skipped unless --overwrite is set.
+With --merge-descriptions they are replaced too, but each parameter keeps the
+longer of its existing and imported descriptions. A replaced command takes the
+env of the imported one.
+
+The import is all or nothing: when one command fails, none are imported.
+
+Example:
+  # Copy a command between machines
+  shed describe deploy --format json > deploy.json
+  shed import deploy.json
+
+  # Replace existing commands, keeping the better parameter descriptions
+  shed import commands.json --merge-descriptions
+
+  # Import a directory written by shed export
+  shed import ~/dotfiles/shed --overwrite`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		path := args[0]
+
+		logger.Debug("Importing commands", "path", path, "overwrite", importOverwrite,
+			"merge_descriptions", importMergeDescriptions)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Error("Failed to read import file", "path", path, "error", err)
+
+			return fmt.Errorf("failed to read import file: %w", err)
+		}
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		result, err := importPath(s, path, info.IsDir(), store.ImportOptions{
+			Overwrite:         importOverwrite,
+			MergeDescriptions: importMergeDescriptions,
+		})
+		if err != nil {
+			logger.Error("Failed to import commands", "error", err)
+
+			return err
+		}
+
+		for _, name := range result.Skipped {
+			logger.Warn("Command already exists, skipped", "name", name)
+		}
+
+		logger.Info("Commands imported",
+			"added", len(result.Added),
+			"updated", len(result.Updated),
+			"skipped", len(result.Skipped),
+		)
+
+		return nil
+	},
+}
+
+func init() {
+	ImportCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace commands that already exist")
+	ImportCmd.Flags().BoolVar(&importMergeDescriptions, "merge-descriptions", false,
+		"Replace commands that already exist, keeping the longer parameter descriptions")
+}
+
+// importPath imports the commands of the export file at path, or of every
+// export file in it when it is a directory.
+func importPath(s *store.Store, path string, dir bool, opts store.ImportOptions) (*store.ImportResult, error) {
+	if dir {
+		return s.ImportFromDir(path, opts)
+	}
+
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	return s.ImportFromLegacyFormat(bb, opts)
+}
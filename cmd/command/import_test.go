@@ -0,0 +1,68 @@
+package command
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestImportPath(t *testing.T) {
+	t.Parallel()
+
+	src := storetest.New(t)
+
+	for name, body := range map[string]string{"greet": "echo hello {{name}}", "uptime": "uptime"} {
+		if _, err := src.AddCommand(name, body, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+
+	if _, err := src.ExportToDir(dir); err != nil {
+		t.Fatalf("unexpected error exporting commands: %v", err)
+	}
+
+	tests := map[string]struct {
+		path string
+		dir  bool
+		want []string
+	}{
+		"directory": {path: dir, dir: true, want: []string{"greet", "uptime"}},
+		"file":      {path: filepath.Join(dir, "greet.json"), dir: false, want: []string{"greet"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			result, err := importPath(s, tc.path, tc.dir, store.ImportOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error importing: %v", err)
+			}
+
+			if !slices.Equal(result.Added, tc.want) {
+				t.Fatalf("expected %v to be added, got %+v", tc.want, result)
+			}
+		})
+	}
+}
+
+func TestImportPath_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	if _, err := importPath(s, path, false, store.ImportOptions{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a not-exist error, got %v", err)
+	}
+}
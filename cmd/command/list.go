@@ -9,6 +9,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var listNeedsSecrets bool
+
+// commandSecrets is a command with the keys of the secrets it references and
+// of those among them that are not stored.
+type commandSecrets struct {
+	Command store.Command
+	Secrets []string
+	Missing []string
+}
+
 // ListCmd represents the list command.
 var ListCmd = &cobra.Command{
 	Use:   "list",
@@ -22,12 +32,15 @@ Example:
   shed list
 
   # List all commands with verbose output
-  shed list -v`,
+  shed list -v
+
+  # List only commands that use secrets, noting the ones not stored yet
+  shed list --needs-secrets`,
 	Args: cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		logger.Debug("Listing commands")
 
-		s, err := store.NewStoreFromConfig()
+		s, err := store.NewReadOnlyStoreFromConfig()
 		if err != nil {
 			logger.Error("Failed to initialize store", "error", err)
 
@@ -41,6 +54,22 @@ Example:
 			return err
 		}
 
+		var withSecrets []commandSecrets
+
+		if listNeedsSecrets {
+			withSecrets, err = needsSecrets(s, commands)
+			if err != nil {
+				logger.Error("Failed to get secrets of commands", "error", err)
+
+				return err
+			}
+
+			commands = commands[:0]
+			for _, cs := range withSecrets {
+				commands = append(commands, cs.Command)
+			}
+		}
+
 		if len(commands) == 0 {
 			logger.Info("No commands found")
 
@@ -49,7 +78,7 @@ Example:
 
 		logger.Info(fmt.Sprintf("Found %d command(s)", len(commands)))
 
-		for _, cmd := range commands {
+		for i, cmd := range commands {
 			var sb strings.Builder
 
 			fmt.Fprintf(&sb, "\nName:        %s\n", cmd.Name)
@@ -60,14 +89,14 @@ Example:
 			if len(cmd.Parameters) > 0 {
 				sb.WriteString("\n  Details:")
 				for _, param := range cmd.Parameters {
-					if param.Description != "" {
-						fmt.Fprintf(&sb, "\n    - %s: %s", param.Name, param.Description)
-					} else {
-						fmt.Fprintf(&sb, "\n    - %s", param.Name)
-					}
+					fmt.Fprintf(&sb, "\n    - %s", param)
 				}
 			}
 
+			if listNeedsSecrets {
+				writeCommandSecrets(&sb, withSecrets[i])
+			}
+
 			fmt.Fprintf(&sb, "\nCreated:     %s\n", cmd.CreatedAt)
 			fmt.Fprintf(&sb, "Updated:     %s", cmd.UpdatedAt)
 
@@ -77,3 +106,48 @@ Example:
 		return nil
 	},
 }
+
+func init() {
+	ListCmd.Flags().BoolVar(&listNeedsSecrets, "needs-secrets", false,
+		"List only commands that reference secrets, noting those not stored")
+}
+
+// needsSecrets returns the commands in cc that reference at least one secret,
+// in the same order, with the secrets they reference and are missing.
+func needsSecrets(s *store.Store, cc []store.Command) ([]commandSecrets, error) {
+	var out []commandSecrets
+
+	for i := range cc {
+		stored, missing, err := s.GetSecretsForCommand(&cc[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(stored) == 0 && len(missing) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(stored)+len(missing))
+		for _, secret := range stored {
+			keys = append(keys, secret.Key)
+		}
+
+		out = append(out, commandSecrets{
+			Command: cc[i],
+			Secrets: append(keys, missing...),
+			Missing: missing,
+		})
+	}
+
+	return out, nil
+}
+
+// writeCommandSecrets adds the secrets of a command, and the missing ones, to a
+// shed list entry.
+func writeCommandSecrets(sb *strings.Builder, cs commandSecrets) {
+	fmt.Fprintf(sb, "\nSecrets:     %s", strings.Join(cs.Secrets, ", "))
+
+	if len(cs.Missing) > 0 {
+		fmt.Fprintf(sb, "\nMissing:     %s", strings.Join(cs.Missing, ", "))
+	}
+}
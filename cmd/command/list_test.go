@@ -0,0 +1,80 @@
+package command
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestNeedsSecrets(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	if _, err := s.AddSecret("gh_token", "value", ""); err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	seed := map[string]string{
+		"list_files": "ls {{path}}",
+		"gh_issues":  "gh issue list --token {{!gh_token}}",
+		"deploy":     "deploy {{env}} --token {{!gh_token}} --key {{!aws_key}}",
+	}
+	for name, command := range seed {
+		if _, err := s.AddCommand(name, command, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	cc, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	got, err := needsSecrets(s, cc)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := map[string]struct {
+		secrets []string
+		missing []string
+	}{
+		"gh_issues": {secrets: []string{"gh_token"}},
+		"deploy":    {secrets: []string{"gh_token", "aws_key"}, missing: []string{"aws_key"}},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d commands, got %+v", len(want), got)
+	}
+
+	for _, cs := range got {
+		w, ok := want[cs.Command.Name]
+		if !ok {
+			t.Fatalf("expected %q to be filtered out", cs.Command.Name)
+		}
+
+		if !slices.Equal(cs.Secrets, w.secrets) {
+			t.Fatalf("expected secrets %v for %q, got %v", w.secrets, cs.Command.Name, cs.Secrets)
+		}
+
+		if !slices.Equal(cs.Missing, w.missing) {
+			t.Fatalf("expected missing %v for %q, got %v", w.missing, cs.Command.Name, cs.Missing)
+		}
+	}
+}
+
+func TestWriteCommandSecrets(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+
+	writeCommandSecrets(&sb, commandSecrets{Secrets: []string{"gh_token", "aws_key"}, Missing: []string{"aws_key"}})
+
+	want := "\nSecrets:     gh_token, aws_key\nMissing:     aws_key"
+	if sb.String() != want {
+		t.Fatalf("expected %q, got %q", want, sb.String())
+	}
+}
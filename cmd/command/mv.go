@@ -0,0 +1,124 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/h3jfc/shed/internal/config"
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/sqlite3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrMoveNoProfile = errors.New("--to-profile is required")
+	ErrMoveSameDB    = errors.New("the profile uses the database in use, there is nothing to move")
+)
+
+var (
+	mvToProfile string
+	mvOverwrite bool
+)
+
+// MvCmd represents the mv command.
+var MvCmd = &cobra.Command{
+	Use:   "mv <COMMAND_NAME> --to-profile <PROFILE_NAME>",
+	Short: "Move a command to another profile",
+	Long: `Move a command, with its parameters and environment, from the shed directory
+in use to another profile's database. The command is written to the profile
+before it is removed here, so a failed move never loses it. Secrets are not
+moved.
+
+A command of the same name in the profile is only replaced with --overwrite.
+
+Example:
+  # Move a command to the work profile
+  shed mv deploy --to-profile work
+
+  # Move a command from the work profile to the client profile
+  shed --shed-dir profile:work mv deploy --to-profile client
+
+  # Replace the profile's command of the same name
+  shed mv deploy --to-profile work --overwrite`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		commandName := args[0]
+
+		logger.Debug("Moving command", "name", commandName, "profile", mvToProfile, "overwrite", mvOverwrite)
+
+		if mvToProfile == "" {
+			logger.Error("No profile to move the command to")
+
+			return ErrMoveNoProfile
+		}
+
+		p, err := config.OpenProfile(mvToProfile)
+		if err != nil {
+			logger.Error("Failed to open profile", "profile", mvToProfile, "error", err)
+
+			return err
+		}
+
+		dbPath, _, err := store.ConfiguredDB()
+		if err != nil {
+			logger.Error("Database is not configured", "error", err)
+
+			return err
+		}
+
+		if filepath.Clean(dbPath) == filepath.Clean(p.DBPath) {
+			logger.Error("Command is already in the profile", "profile", mvToProfile)
+
+			return fmt.Errorf("%w: %s", ErrMoveSameDB, p.DBPath)
+		}
+
+		src, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer src.CloseLogged()
+
+		conn, err := sqlite3.DB(p.DBPath, p.Password)
+		if err != nil {
+			logger.Error("Failed to open profile database", "profile", mvToProfile, "error", err)
+
+			return err
+		}
+
+		dst := store.NewStore(conn)
+		defer dst.CloseLogged()
+
+		if err := store.MoveCommand(src, dst, commandName, mvOverwrite); err != nil {
+			if errors.Is(err, store.ErrCommandNotFound) {
+				logger.Error("Command not found", "name", commandName)
+
+				return err
+			}
+
+			if errors.Is(err, store.ErrAlreadyExists) {
+				logger.Error("Command already exists in the profile, use --overwrite to replace it",
+					"name", commandName, "profile", mvToProfile)
+
+				return err
+			}
+
+			logger.Error("Failed to move command", "error", err)
+
+			return err
+		}
+
+		logger.Info("Command moved successfully", "name", commandName, "profile", mvToProfile)
+
+		return nil
+	},
+}
+
+func init() {
+	MvCmd.Flags().StringVar(&mvToProfile, "to-profile", "", "Profile to move the command to")
+	MvCmd.Flags().BoolVar(&mvOverwrite, "overwrite", false, "Replace a command of the same name in the profile")
+}
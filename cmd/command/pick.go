@@ -0,0 +1,168 @@
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/h3jfc/shed/internal/commands"
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/brackets"
+	"github.com/spf13/cobra"
+)
+
+const pickAttempts = 3
+
+var ErrNoCommands = errors.New("no commands stored yet, add one with 'shed add'")
+
+// PickCmd represents the pick command.
+var PickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Choose a stored command from a list and run it",
+	Long: `List stored commands, choose one by number, and run it.
+
+Any parameter without a value in the command's default params file is prompted
+for before the command runs. Secrets are fetched from the secrets store as with
+shed run.
+
+Example:
+  # Choose a command interactively
+  shed pick`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		logger.Debug("Picking command")
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		cmds, err := s.ListCommands()
+		if err != nil {
+			logger.Error("Failed to list commands", "error", err)
+
+			return err
+		}
+
+		in := bufio.NewReader(c.InOrStdin())
+
+		cmd, err := selectCommand(in, os.Stderr, cmds, pickAttempts)
+		if err != nil {
+			logger.Error("Failed to pick command", "error", err)
+
+			return err
+		}
+
+		defaults, err := loadDefaultParams(cmd.Name)
+		if err != nil {
+			logger.Error("Failed to load default parameters", "error", err)
+
+			return err
+		}
+
+		params, err := promptMissingParams(in, os.Stderr, cmd.Parameters, defaults)
+		if err != nil {
+			logger.Error("Failed to read parameters", "error", err)
+
+			return err
+		}
+
+		return runWithParams(s, cmd, params)
+	},
+}
+
+// selectCommand prints a numbered list of cmds to w and reads a choice from r,
+// asking again after invalid input up to maxAttempts times.
+func selectCommand(r *bufio.Reader, w io.Writer, cmds []store.Command, maxAttempts int) (*store.Command, error) {
+	if len(cmds) == 0 {
+		return nil, ErrNoCommands
+	}
+
+	fmt.Fprintln(w, "Please select a command:")
+
+	for i, cmd := range cmds {
+		if cmd.Description != "" {
+			fmt.Fprintf(w, "%d) %s - %s\n", i+1, cmd.Name, cmd.Description)
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%d) %s\n", i+1, cmd.Name)
+	}
+
+	for attempt := range maxAttempts {
+		choice, err := readChoice(r, w, len(cmds))
+		if err == nil {
+			return &cmds[choice-1], nil
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		fmt.Fprintf(w, "Error: %v\n", err)
+
+		if attempt < maxAttempts-1 {
+			fmt.Fprintln(w, "Please try again.")
+		}
+	}
+
+	return nil, commands.ErrMaxAttemptsReached
+}
+
+// readChoice prompts for and reads a single number between 1 and n.
+func readChoice(r *bufio.Reader, w io.Writer, n int) (int, error) {
+	fmt.Fprint(w, "\nEnter your choice (number): ")
+
+	input, err := r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || input == "") {
+		return 0, err
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		return 0, commands.ErrInvalidInput
+	}
+
+	if choice < 1 || choice > n {
+		return 0, fmt.Errorf("%w: please select a number between 1 and %d", commands.ErrInvalidChoice, n)
+	}
+
+	return choice, nil
+}
+
+// promptMissingParams asks for a value for each non-secret parameter not in
+// have, returning have merged with the answers.
+func promptMissingParams(r *bufio.Reader, w io.Writer, params brackets.Parameters, have map[string]string) (map[string]string, error) {
+	values := mergeParams(have, nil)
+
+	for _, param := range params.WithoutSecrets() {
+		if _, ok := values[param.Name]; ok {
+			continue
+		}
+
+		if param.Description != "" {
+			fmt.Fprintf(w, "%s (%s): ", param.Name, param.Description)
+		} else {
+			fmt.Fprintf(w, "%s: ", param.Name)
+		}
+
+		input, err := r.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || input == "") {
+			return nil, fmt.Errorf("failed to read value for %s: %w", param.Name, err)
+		}
+
+		values[param.Name] = strings.TrimRight(input, "\r\n")
+	}
+
+	return values, nil
+}
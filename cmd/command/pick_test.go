@@ -0,0 +1,109 @@
+package command
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/commands"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+func TestSelectCommand(t *testing.T) {
+	t.Parallel()
+
+	cmds := []store.Command{
+		{Name: "build", Description: "builds the app"},
+		{Name: "deploy"},
+		{Name: "test"},
+	}
+
+	tests := map[string]struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		"valid index":                  {input: "2\n", want: "deploy"},
+		"surrounding whitespace":       {input: "  3 \n", want: "test"},
+		"no trailing newline":          {input: "1", want: "build"},
+		"out of range then valid":      {input: "4\n0\n1\n", want: "build"},
+		"non-numeric then valid":       {input: "deploy\n2\n", want: "deploy"},
+		"too many invalid attempts":    {input: "x\n9\n-1\n1\n", wantErr: commands.ErrMaxAttemptsReached},
+		"input ends before any choice": {input: "", wantErr: io.EOF},
+		"input ends after bad choice":  {input: "x\n", wantErr: io.EOF},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+
+			got, err := selectCommand(bufio.NewReader(strings.NewReader(tc.input)), &out, cmds, pickAttempts)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Name != tc.want {
+				t.Fatalf("expected command %q, got %q", tc.want, got.Name)
+			}
+
+			if !strings.Contains(out.String(), "1) build - builds the app\n2) deploy\n") {
+				t.Fatalf("expected numbered list, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestSelectCommand_NoCommands(t *testing.T) {
+	t.Parallel()
+
+	_, err := selectCommand(bufio.NewReader(strings.NewReader("1\n")), io.Discard, nil, pickAttempts)
+	if !errors.Is(err, ErrNoCommands) {
+		t.Fatalf("expected error %v, got %v", ErrNoCommands, err)
+	}
+}
+
+func TestPromptMissingParams(t *testing.T) {
+	t.Parallel()
+
+	params := brackets.Parameters{
+		{Name: "env", Description: "target"},
+		{Name: "region"},
+		{Name: "version"},
+		{Name: brackets.SecretName("token")},
+	}
+	have := map[string]string{"region": "eu"}
+
+	var out strings.Builder
+
+	got, err := promptMissingParams(bufio.NewReader(strings.NewReader("prod\n1.2.3")), &out, params, have)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"env": "prod", "region": "eu", "version": "1.2.3"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if out.String() != "env (target): version: " {
+		t.Fatalf("expected prompts for missing parameters only, got %q", out.String())
+	}
+
+	if len(have) != 1 {
+		t.Fatalf("expected defaults to be left untouched, got %v", have)
+	}
+}
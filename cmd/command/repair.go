@@ -0,0 +1,56 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// RepairCmd represents the repair command.
+var RepairCmd = &cobra.Command{
+	Use:   "repair <COMMAND_NAME>",
+	Short: "Resync a command's parameters with its body",
+	Long: `Rebuild the stored parameters of a command from its command body.
+
+Parameters used in the body but missing from the stored parameters are added,
+and stored parameters no longer used in the body are removed. Existing
+descriptions are kept, preferring the longer one when they differ.
+
+Example:
+  # Repair a command after a manual database edit
+  shed repair list_files`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		commandName := args[0]
+
+		logger.Debug("Repairing command", "name", commandName)
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		cmd, err := s.ResyncParameters(commandName)
+		if err != nil {
+			if errors.Is(err, store.ErrCommandNotFound) {
+				logger.Error("Command not found", "name", commandName)
+
+				return err
+			}
+
+			logger.Error("Failed to repair command", "error", err)
+
+			return err
+		}
+
+		logger.Info("Command repaired successfully", "name", cmd.Name, "parameters", cmd.Parameters.Names())
+
+		return nil
+	},
+}
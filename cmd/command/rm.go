@@ -12,10 +12,12 @@ import (
 var RmCmd = &cobra.Command{
 	Use:   "rm <COMMAND_NAME>",
 	Short: "Remove a command from shed",
-	Long: `Remove an existing command from shed by name.
+	Long: `Remove an existing command from shed by name or alias. Given an alias, the
+command it points to is removed; use 'shed alias --rm' to remove only the alias.
 
-This operation is irreversible. The command and all its associated data
-will be permanently deleted.
+The command is moved to the trash with its aliases, and the most recently
+removed command can be brought back with 'shed undo'. Commands in the trash for more than 30 days
+are permanently deleted the next time a command is removed.
 
 Example:
   # Remove a command
@@ -36,7 +38,9 @@ Example:
 			return err
 		}
 
-		err = s.RemoveCommand(commandName)
+		defer s.CloseLogged()
+
+		removed, err := s.SoftDeleteCommand(commandName)
 		if err != nil {
 			if errors.Is(err, store.ErrCommandNotFound) {
 				logger.Error("Command not found", "name", commandName)
@@ -49,7 +53,13 @@ Example:
 			return err
 		}
 
-		logger.Info("Command removed successfully", "name", commandName)
+		logger.Info("Command removed successfully", "name", removed.Name, "command", removed.Command)
+
+		if n, err := s.PurgeTrash(store.DefaultTrashRetention); err != nil {
+			logger.Debug("Failed to purge trash", "error", err)
+		} else if n > 0 {
+			logger.Debug("Purged old commands from trash", "count", n)
+		}
 
 		return nil
 	},
@@ -1,18 +1,83 @@
 package command
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/h3jfc/shed/internal/config"
 	"github.com/h3jfc/shed/internal/execute"
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/h3jfc/shed/internal/store"
 	"github.com/h3jfc/shed/lib/brackets"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 const (
 	maxRunArgs = 2
+
+	// Hydration sources reported by --explain.
+	sourceInline      = "inline JSON"
+	sourceDefault     = "default params file"
+	sourcePlaceholder = "no value, left as placeholder"
+	sourceResolved    = "resolved from secrets store"
+	sourceMissing     = "missing from secrets store"
+
+	// secretMask replaces secret values whenever a hydrated command is shown.
+	secretMask = "********"
+
+	// Stages of a run reported by RunError.
+	runStageLookup     = "lookup"
+	runStageParameters = "parameters"
+	runStageSecrets    = "secrets"
+	runStageHydrate    = "hydrate"
+	runStageExecute    = "execute"
+)
+
+var (
+	runPrefix    bool
+	runEcho      bool
+	runCapture   string
+	runMaxOutput int64
+	runExplain   bool
+	runAsync     bool
+	runTTY       bool
+	runTimeout   time.Duration
+
+	runInteractiveIO bool
+
+	// runTimeoutSet records whether --timeout was given, as a zero timeout
+	// then lifts the configured default.
+	runTimeoutSet bool
+
+	runListSeparator string
+)
+
+var (
+	ErrAsyncCapture    = errors.New("--async cannot be combined with --capture")
+	ErrAsyncTimeout    = errors.New("--async cannot be combined with --timeout")
+	ErrInvalidTimeout  = errors.New("timeout must not be negative")
+	ErrNoShedDir       = errors.New("no shed directory found, run 'shed init' first")
+	ErrMissingValue    = errors.New("has no value")
+	ErrSecretNotStored = errors.New("is not stored")
+
+	ErrParameterProblems = errors.New("cannot run, fix these parameter problems")
+	ErrTTYConflict       = errors.New("--tty cannot be combined with --async or --capture")
+
+	ErrInteractiveIOConflict = errors.New("--interactive-io cannot be combined with --async, --capture or --tty")
+
+	ErrCaptureEmpty     = errors.New("command printed nothing to capture")
+	ErrCaptureTruncated = errors.New("captured output was cut short by --max-output")
 )
 
 // RunCmd represents the run command.
@@ -22,10 +87,60 @@ var RunCmd = &cobra.Command{
 	Long: `Run a stored command by name, with optional parameter values.
 
 Parameters should be provided as a JSON object in the form {"param":"value"}.
-If a command requires parameters and they are not provided, an error will be returned.
+If a command requires parameters and they are not provided, an error will be returned,
+as it is for values given for parameters the command does not declare.
+All such problems, including values that do not match a parameter's pattern and
+secrets that are not stored, are listed together before shed refuses to run.
+
+Secrets (parameters starting with !, or the configured settings.secret-prefix) are
+automatically fetched from the secrets store and substituted into the command
+before execution.
+
+Default parameter values can be kept in <shed-dir>/params/<COMMAND_NAME>.json,
+a JSON object in the same form. Values given on the command line win over it.
+
+Environment variables stored with the command are set on top of the current
+environment, overriding variables of the same name.
+
+With --echo, the hydrated command is printed to stderr just before it runs,
+with secret values masked.
+
+With --capture, the trimmed stdout of the command is stored as the given secret
+instead of being displayed, creating or updating it. Nothing is stored when the
+command fails, prints nothing, or prints more than --max-output allows.
+
+With --async, the command is started in the background and shed returns at
+once, printing a run ID. Its stdout and stderr go to a log file under
+<shed-dir>/runs; list runs and read their logs with shed runs.
+
+With --explain, each parameter and secret is listed on stderr before the
+command is hydrated, with where its value came from: the inline JSON, the
+default params file, or nowhere, and for secrets whether they were found.
+
+A parameter value may be a list of strings, which is joined with spaces, or
+with --list-separator, when hydrating.
+
+With --tty, the command runs attached to a pseudo-terminal, so programs that
+check for a terminal keep their colors, progress bars, and prompts. Output goes
+straight to the terminal, so --max-output does not apply. Linux and macOS only.
+
+With --interactive-io, the command shares shed's stdin, stdout and stderr, so
+commands that read input, such as ssh or a read prompt, work as in a shell.
+Output is neither logged nor captured, so it cannot be combined with --capture,
+and --max-output does not apply. When --timeout stops such a command, only the
+shell is killed.
 
-Secrets (parameters starting with !) are automatically fetched from the secrets store
-and substituted into the command before execution.
+With --max-output, at most that many bytes of stdout and of stderr are shown or
+captured. Output past the limit is dropped after a "...(truncated)" marker, and
+the command still runs to completion.
+
+With --timeout, the command is stopped, along with everything it started,
+once it has run for that long, and the run fails. Without the flag the
+settings.default-timeout config value applies; 0 means no limit, which is the
+default. Background runs started with --async are never stopped.
+
+With --prefix, a unique prefix of the command name is enough. An exact name
+match always wins over a prefix match.
 
 Examples:
   # Run a command without parameters
@@ -37,11 +152,42 @@ Examples:
   # Run a command with multiple parameters
   shed run deploy '{"environment":"production","version":"1.2.3"}'
 
+  # Print the command (secrets masked) before running it
+  shed run --echo deploy '{"environment":"production","version":"1.2.3"}'
+
+  # Pass a list, hydrated as "a.yaml b.yaml"
+  shed run apply '{"files":["a.yaml","b.yaml"]}'
+
+  # Start a long build in the background and follow it later with shed runs
+  shed run --async build
+
+  # Keep the colored output of a test runner
+  shed run --tty test
+
+  # Answer the prompts of a command that reads from stdin
+  shed run --interactive-io ssh_bastion
+
+  # Show where each parameter value comes from
+  shed run --explain deploy '{"version":"1.2.3"}'
+
+  # Store a freshly issued token as the gh_token secret
+  shed run --capture gh_token gh_auth_token
+
+  # Show at most 1 MiB of a noisy command's output
+  shed run --max-output 1048576 tail_logs
+
+  # Give up on a flaky integration suite after 10 minutes
+  shed run --timeout 10m integration_tests
+
+  # Run "deploy" by a unique prefix of its name
+  shed run --prefix dep
+
   # List available commands
   shed list`,
 	Args: cobra.RangeArgs(1, maxRunArgs),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(c *cobra.Command, args []string) error {
 		commandName := args[0]
+		runTimeoutSet = c.Flags().Changed("timeout")
 
 		jsonValueParams := "{}"
 		if len(args) == maxRunArgs {
@@ -50,19 +196,50 @@ Examples:
 
 		logger.Debug("Running command", "name", commandName, "params", jsonValueParams)
 
+		if runAsync && runCapture != "" {
+			logger.Error("Conflicting flags", "error", ErrAsyncCapture)
+
+			return ErrAsyncCapture
+		}
+
+		if runAsync && runTimeoutSet {
+			logger.Error("Conflicting flags", "error", ErrAsyncTimeout)
+
+			return ErrAsyncTimeout
+		}
+
+		if runTTY && (runAsync || runCapture != "") {
+			logger.Error("Conflicting flags", "error", ErrTTYConflict)
+
+			return ErrTTYConflict
+		}
+
+		if runInteractiveIO && (runAsync || runCapture != "" || runTTY) {
+			logger.Error("Conflicting flags", "error", ErrInteractiveIOConflict)
+
+			return ErrInteractiveIOConflict
+		}
+
 		s, err := store.NewStoreFromConfig()
 		if err != nil {
 			logger.Error("Failed to initialize store", "error", err)
 
-			return err
+			return runFailed(commandName, runStageLookup, err)
 		}
 
+		defer s.CloseLogged()
+
 		// Get the command
-		cmd, err := s.GetCommandByName(commandName)
+		getCommand := s.GetCommandByName
+		if runPrefix {
+			getCommand = s.GetCommandByPrefix
+		}
+
+		cmd, err := getCommand(commandName)
 		if err != nil {
 			logger.Error("Failed to get command", "name", commandName, "error", err)
 
-			return err
+			return runFailed(commandName, runStageLookup, err)
 		}
 
 		logger.Debug("Retrieved command",
@@ -75,66 +252,540 @@ Examples:
 		if err := validateJSON(jsonValueParams); err != nil {
 			logger.Error("Invalid JSON parameter format", "error", err)
 
-			return fmt.Errorf("invalid JSON format: %w", err)
+			return runFailed(cmd.Name, runStageParameters, fmt.Errorf("invalid JSON format: %w", err))
 		}
 
-		// Parse the command to extract secrets
-		parsed, err := brackets.Parse(cmd.Command)
+		// Parse the provided parameters
+		inlineParams, err := parseInlineParams(jsonValueParams, runListSeparator)
 		if err != nil {
-			logger.Error("Failed to parse command", "error", err)
+			logger.Error("Failed to parse parameters", "error", err)
 
-			return fmt.Errorf("failed to parse command: %w", err)
+			return runFailed(cmd.Name, runStageParameters, fmt.Errorf("failed to parse parameters: %w", err))
 		}
 
-		// Parse the provided parameters
-		var paramMap map[string]string
-		if err := json.Unmarshal([]byte(jsonValueParams), &paramMap); err != nil {
-			logger.Error("Failed to parse parameters", "error", err)
+		return runWithParams(s, cmd, inlineParams)
+	},
+}
 
-			return fmt.Errorf("failed to parse parameters: %w", err)
+func init() {
+	RunCmd.Flags().BoolVarP(&runPrefix, "prefix", "p", false, "Resolve the command by a unique prefix of its name")
+	RunCmd.Flags().BoolVarP(&runEcho, "echo", "e", false, "Print the hydrated command, secrets masked, before running it")
+	RunCmd.Flags().StringVar(&runCapture, "capture", "", "Store the command's trimmed stdout as this secret")
+	RunCmd.Flags().BoolVar(&runAsync, "async", false, "Start the command in the background and print its run ID")
+	RunCmd.Flags().BoolVar(&runTTY, "tty", false, "Run the command attached to a pseudo-terminal (Linux and macOS only)")
+	RunCmd.Flags().BoolVar(&runInteractiveIO, "interactive-io", false,
+		"Connect the command to shed's stdin, stdout and stderr so it can read input")
+	RunCmd.Flags().BoolVar(&runExplain, "explain", false, "Print where each parameter and secret value comes from")
+	RunCmd.Flags().StringVar(&runListSeparator, "list-separator", brackets.DefaultListSeparator,
+		"Join list parameter values with this separator")
+	RunCmd.Flags().Int64Var(&runMaxOutput, "max-output", 0, "Cap stdout and stderr at this many bytes each (0 for no limit)")
+	RunCmd.Flags().DurationVar(&runTimeout, "timeout", 0,
+		"Stop the command after this long, e.g. 30s or 10m (default settings.default-timeout, 0 for no limit)")
+}
+
+// runWithParams hydrates a stored command with inline values layered over its
+// default params file and secrets, then runs it or captures its output.
+func runWithParams(s *store.Store, cmd *store.Command, inlineParams map[string]string) error { // nolint:funlen
+	// Parse the command to extract secrets
+	parsed, err := brackets.Parse(cmd.Command)
+	if err != nil {
+		logger.Error("Failed to parse command", "error", err)
+
+		return runFailed(cmd.Name, runStageParameters, fmt.Errorf("failed to parse command: %w", err))
+	}
+
+	defaultParams, err := loadDefaultParams(cmd.Name)
+	if err != nil {
+		logger.Error("Failed to load default parameters", "error", err)
+
+		return runFailed(cmd.Name, runStageParameters, err)
+	}
+
+	paramMap := mergeParams(defaultParams, inlineParams)
+
+	var secretErr error
+
+	// Fetch secrets and add them to parameter map
+	for _, secret := range *parsed.Secrets {
+		secretValue, err := s.GetSecretByKey(secret.Key)
+		if errors.Is(err, store.ErrSecretNotFound) {
+			// Reported with the other parameter problems below
+			continue
 		}
 
-		// Fetch secrets and add them to parameter map
-		for _, secret := range *parsed.Secrets {
-			secretValue, err := s.GetSecretByKey(secret.Key)
-			if err != nil {
-				logger.Error("Failed to get secret", "key", secret.Key, "error", err)
+		if err != nil {
+			logger.Error("Failed to get secret", "key", secret.Key, "error", err)
 
-				return fmt.Errorf("failed to get secret %s: %w", secret.Key, err)
+			if secretErr == nil {
+				secretErr = fmt.Errorf("failed to get secret %s: %w", secret.Key, err)
 			}
-			// Secret parameters are prefixed with ! in the command string
-			paramMap["!"+secret.Key] = secretValue.Value
-			logger.Debug("Loaded secret", "key", secret.Key)
+
+			continue
 		}
+		// Secret parameters are prefixed (! by default) in the command string
+		paramMap[brackets.SecretName(secret.Key)] = secretValue.Value
 
-		// Convert parameter map back to JSON for hydration
-		updatedParams, err := json.Marshal(paramMap)
-		if err != nil {
-			logger.Error("Failed to marshal parameters", "error", err)
+		logger.Debug("Loaded secret", "key", secret.Key)
+	}
 
-			return fmt.Errorf("failed to marshal parameters: %w", err)
+	if secretErr != nil {
+		return runFailed(cmd.Name, runStageSecrets, secretErr)
+	}
+
+	missingSecrets := parsed.Secrets.MissingSubset(brackets.ValuedParametersFromMap(paramMap))
+
+	if runExplain {
+		trace := traceHydration(*parsed.Parameters, *parsed.Secrets, defaultParams, inlineParams, missingSecrets)
+		writeTrace(os.Stderr, trace)
+	}
+
+	if report := checkParameters(*parsed.Parameters, inlineParams, paramMap, missingSecrets); report != nil {
+		for _, problem := range report.Problems {
+			logger.Error("Parameter problem", "error", problem)
+		}
+
+		if len(missingSecrets) > 0 {
+			logger.Info("Add missing secrets with shed secret add")
+		}
+
+		return runFailed(cmd.Name, runStageParameters, report)
+	}
+
+	// Hydrate the command with parameter values
+	hydratedCmd, maskedCmd, err := hydrate(cmd.Command, paramMap)
+	if err != nil {
+		logger.Error("Failed to hydrate command", "error", err)
+
+		return runFailed(cmd.Name, runStageHydrate, err)
+	}
+
+	logger.Debug("Hydrated command", "command", maskedCmd)
+
+	if runAsync {
+		if runEcho {
+			echoCommand(os.Stderr, maskedCmd)
 		}
 
-		// Hydrate the command with parameter values
-		hydratedCmd, err := brackets.HydrateStringFromJSON(cmd.Command, string(updatedParams))
+		shedDir, err := configuredShedDir()
 		if err != nil {
-			logger.Error("Failed to hydrate command", "error", err)
+			return runFailed(cmd.Name, runStageExecute, err)
+		}
+
+		record, err := startAsync(shedDir, cmd.Name, hydratedCmd, cmd.Env)
+		if err != nil {
+			logger.Error("Failed to start command in the background", "error", err)
 
-			return fmt.Errorf("failed to hydrate command: %w", err)
+			return runFailed(cmd.Name, runStageExecute, err)
 		}
 
-		logger.Debug("Hydrated command", "command", hydratedCmd)
-		logger.Info("Executing command", "name", cmd.Name)
+		logger.Info("Command started in the background", "name", cmd.Name, "run", record.ID, "log", record.Log)
+		fmt.Fprintln(os.Stdout, record.ID)
+
+		return nil
+	}
+
+	timeout, err := effectiveRunTimeout()
+	if err != nil {
+		logger.Error("Invalid timeout", "error", err)
+
+		return runFailed(cmd.Name, runStageExecute, err)
+	}
+
+	ctx, cancel := runContext(timeout)
+	defer cancel()
+
+	logger.Info("Executing command", "name", cmd.Name)
+
+	if runCapture != "" {
+		if runEcho {
+			echoCommand(os.Stderr, maskedCmd)
+		}
 
-		// Execute the command
-		if err := execute.Run(hydratedCmd); err != nil {
-			logger.Error("Command execution failed", "error", err)
+		if _, err := captureToSecret(ctx, s, runCapture, hydratedCmd, cmd.Env, runMaxOutput); err != nil {
+			logTimeout(err, timeout)
+			logger.Error("Failed to capture command output", "key", runCapture, "error", err)
 
-			return fmt.Errorf("command execution failed: %w", err)
+			return runFailed(cmd.Name, runStageExecute, err)
 		}
 
-		logger.Info("Command executed successfully", "name", cmd.Name)
+		logger.Info("Command output captured into secret", "name", cmd.Name, "key", runCapture)
 
 		return nil
-	},
+	}
+
+	// Execute the command
+	if err := echoAndRun(ctx, os.Stderr, hydratedCmd, maskedCmd, cmd.Env, runEcho); err != nil {
+		logTimeout(err, timeout)
+		logger.Error("Command execution failed", "error", err)
+
+		return runFailed(cmd.Name, runStageExecute, fmt.Errorf("command execution failed: %w", err))
+	}
+
+	logger.Info("Command executed successfully", "name", cmd.Name)
+
+	return nil
+}
+
+// ParameterReport lists every problem with the values given for a run, so
+// they can all be fixed before running again. Each problem is a
+// *brackets.ParameterError.
+type ParameterReport struct {
+	Problems []error
+}
+
+func (r *ParameterReport) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%v: %d found", ErrParameterProblems, len(r.Problems))
+
+	for _, problem := range r.Problems {
+		fmt.Fprintf(&sb, "\n  - %v", problem)
+	}
+
+	return sb.String()
+}
+
+func (r *ParameterReport) Unwrap() []error {
+	return append([]error{ErrParameterProblems}, r.Problems...)
+}
+
+// checkParameters collects every problem with the values of a run: inline
+// values the command does not declare or that miss their pattern, required
+// parameters left without a value by both the inline JSON and the default
+// params file, and secrets that are not stored. It returns nil when there are
+// none.
+func checkParameters(
+	params brackets.Parameters,
+	inline, merged map[string]string,
+	missingSecrets brackets.Secrets,
+) *ParameterReport {
+	var problems []error
+
+	if err := brackets.ValuedParametersFromMap(inline).Validate(params); err != nil {
+		invalid := unwrapJoined(err)
+
+		// Inline values come from a map, so sort for a stable report
+		slices.SortStableFunc(invalid, func(a, b error) int {
+			return strings.Compare(a.Error(), b.Error())
+		})
+
+		problems = append(problems, invalid...)
+	}
+
+	for _, p := range brackets.ValuedParametersFromMap(merged).MissingSubset(params) {
+		problems = append(problems, &brackets.ParameterError{Name: p.Name, Kind: brackets.KindParameter, Err: ErrMissingValue})
+	}
+
+	for _, secret := range missingSecrets {
+		problems = append(problems, &brackets.ParameterError{
+			Name: secret.Key,
+			Kind: brackets.KindSecret,
+			Err:  ErrSecretNotStored,
+		})
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ParameterReport{Problems: problems}
+}
+
+// unwrapJoined splits an error built by errors.Join into its parts.
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
+
+// effectiveRunTimeout returns how long a run may take: --timeout when given,
+// and the settings.default-timeout config value otherwise. 0 means no limit.
+func effectiveRunTimeout() (time.Duration, error) {
+	timeout := viper.GetDuration("settings.default-timeout")
+	if runTimeoutSet {
+		timeout = runTimeout
+	}
+
+	if timeout < 0 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidTimeout, timeout)
+	}
+
+	return timeout, nil
+}
+
+// runContext returns the context a run executes under. With a timeout it is
+// also done on Ctrl-C, since the command then runs in a process group of its
+// own that the terminal's interrupt no longer reaches. Without one it is never
+// done, and the command is left in shed's process group.
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.Background(), func() {}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// logTimeout logs that a command was stopped for running past its timeout.
+func logTimeout(err error, timeout time.Duration) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Error("Command timed out", "timeout", timeout)
+	}
+}
+
+// RunError is a failed run of a stored command. Stage names the step that
+// failed and ExitCode is the command's exit status when it ran and exited
+// non-zero, and 0 otherwise. Reported is set when the failure has already been
+// logged as a structured record, so it is not logged again.
+type RunError struct {
+	Name     string
+	Stage    string
+	ExitCode int
+	Reported bool
+	Err      error
+}
+
+func (e *RunError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// runFailed wraps err in a RunError for the named command and stage. In JSON
+// log mode the failure is also logged as structured fields, so tools driving
+// shed can parse it instead of matching on the free-text error.
+func runFailed(name, stage string, err error) error {
+	re := &RunError{Name: name, Stage: stage, Err: err}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		re.ExitCode = exitErr.ExitCode()
+	}
+
+	if logger.Mode() == logger.ModeJSON {
+		logger.Error("Run failed",
+			"name", re.Name,
+			"stage", re.Stage,
+			"exit_code", re.ExitCode,
+			"error", re.Err.Error(),
+		)
+
+		re.Reported = true
+	}
+
+	return re
+}
+
+// traceEntry records where the value of one parameter or secret came from.
+type traceEntry struct {
+	Kind   string
+	Name   string
+	Source string
+}
+
+// traceHydration explains, for --explain, where each parameter's value comes
+// from and whether each secret was found. Inline values win over defaults, as
+// in mergeParams.
+func traceHydration(
+	params brackets.Parameters,
+	secrets brackets.Secrets,
+	defaults, inline map[string]string,
+	missing brackets.Secrets,
+) []traceEntry {
+	trace := make([]traceEntry, 0, len(params)+len(secrets))
+
+	for _, param := range params.WithoutSecrets() {
+		source := sourcePlaceholder
+
+		if _, ok := inline[param.Name]; ok {
+			source = sourceInline
+		} else if _, ok := defaults[param.Name]; ok {
+			source = sourceDefault
+		}
+
+		trace = append(trace, traceEntry{Kind: brackets.KindParameter, Name: param.Name, Source: source})
+	}
+
+	for _, secret := range secrets {
+		source := sourceResolved
+		if slices.ContainsFunc(missing, func(m brackets.Secret) bool { return m.Key == secret.Key }) {
+			source = sourceMissing
+		}
+
+		trace = append(trace, traceEntry{Kind: brackets.KindSecret, Name: secret.Key, Source: source})
+	}
+
+	return trace
+}
+
+// writeTrace prints a hydration trace to w, one line per entry.
+func writeTrace(w io.Writer, trace []traceEntry) {
+	for _, entry := range trace {
+		fmt.Fprintf(w, "%-9s %s: %s\n", entry.Kind, entry.Name, entry.Source)
+	}
+}
+
+// loadDefaultParams reads the default params file for a command from the shed
+// directory holding the active config file.
+func loadDefaultParams(name string) (map[string]string, error) {
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		return map[string]string{}, nil
+	}
+
+	params, err := config.LoadDefaultParams(filepath.Dir(configFile), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default params: %w", err)
+	}
+
+	if len(params) > 0 {
+		logger.Debug("Loaded default parameters", "path", config.ParamsFilePath(filepath.Dir(configFile), name))
+	}
+
+	return params, nil
+}
+
+// parseInlineParams decodes the inline JSON params. A list value is joined
+// with sep, so {"files":["a","b"]} becomes "a b" with the default separator.
+func parseInlineParams(jsonValueParams, sep string) (map[string]string, error) {
+	vp, err := brackets.ValuedParametersFromJSONList(jsonValueParams, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]string, len(vp))
+	for _, p := range vp {
+		params[p.Name] = p.Value
+	}
+
+	return params, nil
+}
+
+// mergeParams layers inline params over defaults. Inline values win.
+func mergeParams(defaults, inline map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(inline))
+	maps.Copy(merged, defaults)
+	maps.Copy(merged, inline)
+
+	return merged
+}
+
+// hydrate fills the command template with the given parameter values. It also
+// returns the command with every substituted secret value replaced by
+// secretMask, for showing it without leaking secrets.
+func hydrate(command string, params map[string]string) (string, string, error) {
+	p, err := brackets.ParseParameters(command)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hydrate command: %w", err)
+	}
+
+	vp := brackets.ValuedParametersFromMap(params)
+	hydrated, spans := brackets.HydrateStringSpans(command, vp)
+
+	if missing := vp.MissingSubset(p); len(missing) > 0 {
+		// Parameters without a value are left as placeholders
+		logger.Debug("Parameters left unhydrated", "missing", missing.Names())
+	}
+
+	return hydrated, brackets.MaskSpans(hydrated, spans, secretMask), nil
+}
+
+// echoAndRun runs the hydrated command until ctx is done, first printing the
+// masked command to w when echo is set. Output is capped by --max-output,
+// unless --tty runs it in a pseudo-terminal or --interactive-io connects it to
+// shed's own stdin and output.
+func echoAndRun(ctx context.Context, w io.Writer, hydrated, masked string, env map[string]string, echo bool) error {
+	if echo {
+		echoCommand(w, masked)
+	}
+
+	if runTTY {
+		return execute.RunPTYContext(ctx, hydrated, "", env)
+	}
+
+	if runInteractiveIO {
+		return execute.RunInteractiveContext(ctx, hydrated, "", env)
+	}
+
+	return execute.RunContext(ctx, hydrated, "", env, runMaxOutput)
+}
+
+// echoCommand prints a masked command to w, shell trace style.
+func echoCommand(w io.Writer, masked string) {
+	fmt.Fprintf(w, "+ %s\n", masked)
+}
+
+// startAsync starts hydrated in the background with its output going to a new
+// log under the runs directory, and records the run.
+func startAsync(shedDir, name, hydrated string, env map[string]string) (*config.RunRecord, error) {
+	record, err := config.NewRunRecord(shedDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pid, err := execute.Start(hydrated, "", env, record.Log, record.Exit)
+	if err != nil {
+		return nil, fmt.Errorf("command execution failed: %w", err)
+	}
+
+	record.PID = pid
+
+	if err := config.SaveRunRecord(shedDir, record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// configuredShedDir returns the directory of the config file in use.
+func configuredShedDir() (string, error) {
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		return "", ErrNoShedDir
+	}
+
+	return filepath.Dir(configFile), nil
+}
+
+// captureToSecret runs the hydrated command until ctx is done and stores its
+// trimmed stdout as the secret key. Nothing is stored when the command fails
+// or is stopped, prints nothing, or prints more than maxBytes, as a cut short
+// or empty value would silently replace a good secret.
+func captureToSecret(
+	ctx context.Context,
+	s *store.Store,
+	key, hydrated string,
+	env map[string]string,
+	maxBytes int64,
+) (*store.Secret, error) {
+	out, err := execute.RunWithResultContext(ctx, hydrated, "", env, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("command execution failed: %w", err)
+	}
+
+	value := strings.TrimSpace(out)
+
+	if maxBytes > 0 && strings.HasSuffix(value, execute.TruncatedMarker) {
+		return nil, fmt.Errorf("%w, raise the limit to capture it", ErrCaptureTruncated)
+	}
+
+	if value == "" {
+		return nil, ErrCaptureEmpty
+	}
+
+	secret, err := s.SetSecretValue(key, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store captured output: %w", err)
+	}
+
+	return secret, nil
 }
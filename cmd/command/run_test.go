@@ -0,0 +1,452 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store/storetest"
+	"github.com/h3jfc/shed/lib/brackets"
+	"github.com/spf13/viper"
+)
+
+func TestHydrate_MasksSecretSpans(t *testing.T) {
+	t.Parallel()
+
+	// The secret value also appears literally and as a plain parameter value
+	params := map[string]string{"user": "s3cr3t", "!token": "s3cr3t"}
+	command := "echo s3cr3t {{user}} {{!token}}"
+
+	hydrated, masked, err := hydrate(command, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "echo s3cr3t s3cr3t s3cr3t"; hydrated != want {
+		t.Fatalf("expected hydrated command %q, got %q", want, hydrated)
+	}
+
+	if want := "echo s3cr3t s3cr3t " + secretMask; masked != want {
+		t.Fatalf("expected masked command %q, got %q", want, masked)
+	}
+}
+
+func TestMergeParams(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		defaults map[string]string
+		inline   map[string]string
+		want     map[string]string
+	}{
+		"defaults applied": {
+			defaults: map[string]string{"environment": "staging", "version": "1.0.0"},
+			inline:   map[string]string{},
+			want:     map[string]string{"environment": "staging", "version": "1.0.0"},
+		},
+		"inline wins": {
+			defaults: map[string]string{"environment": "staging", "version": "1.0.0"},
+			inline:   map[string]string{"environment": "production"},
+			want:     map[string]string{"environment": "production", "version": "1.0.0"},
+		},
+		"no defaults": {
+			defaults: map[string]string{},
+			inline:   map[string]string{"environment": "production"},
+			want:     map[string]string{"environment": "production"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := mergeParams(tt.defaults, tt.inline); !maps.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTraceHydration(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := brackets.Parse("deploy {{env}} {{version}} {{region}} {{!token}} {{!db_pass}}")
+	if err != nil {
+		t.Fatalf("failed to parse command: %v", err)
+	}
+
+	defaults := map[string]string{"env": "staging", "region": "eu-west-1"}
+	inline := map[string]string{"env": "production"}
+	missing := brackets.Secrets{{Key: "db_pass"}}
+
+	got := traceHydration(*parsed.Parameters, *parsed.Secrets, defaults, inline, missing)
+
+	want := map[string]string{
+		"env":     sourceInline,
+		"version": sourcePlaceholder,
+		"region":  sourceDefault,
+		"token":   sourceResolved,
+		"db_pass": sourceMissing,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %v", len(want), len(got), got)
+	}
+
+	for _, entry := range got {
+		if entry.Source != want[entry.Name] {
+			t.Fatalf("expected %s %s source %q, got %q", entry.Kind, entry.Name, want[entry.Name], entry.Source)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	writeTrace(&buf, got)
+
+	if !strings.Contains(buf.String(), "secret    db_pass: "+sourceMissing) {
+		t.Fatalf("expected missing secret in trace, got %q", buf.String())
+	}
+}
+
+func TestEchoAndRun(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell redirect")
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+
+	params := map[string]string{"!token": "s3cr3t", "out": out}
+	command := "echo {{!token}} > {{out}}"
+
+	hydrated, masked, err := hydrate(command, params)
+	if err != nil {
+		t.Fatalf("unexpected error hydrating command: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := echoAndRun(context.Background(), &buf, hydrated, masked, nil, true); err != nil {
+		t.Fatalf("unexpected error running command: %v", err)
+	}
+
+	echoed := buf.String()
+	if !strings.Contains(echoed, "echo "+secretMask+" > "+out) {
+		t.Fatalf("expected masked command to be echoed, got %q", echoed)
+	}
+
+	if strings.Contains(echoed, "s3cr3t") {
+		t.Fatalf("expected secret value to be masked, got %q", echoed)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected command to run and write %v: %v", out, err)
+	}
+
+	if strings.TrimSpace(string(content)) != "s3cr3t" {
+		t.Fatalf("expected command output %q, got %q", "s3cr3t", content)
+	}
+}
+
+func TestEchoAndRun_NoEcho(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := echoAndRun(context.Background(), &buf, "echo hi", "echo hi", nil, false); err != nil {
+		t.Fatalf("unexpected error running command: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing echoed, got %q", buf.String())
+	}
+}
+
+func TestCaptureToSecret_OK(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	secret, err := captureToSecret(context.Background(), s, "api_token", "echo '  first-token  '", nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error capturing output: %v", err)
+	}
+
+	if secret.Value != "first-token" {
+		t.Fatalf("expected secret value %q, got %q", "first-token", secret.Value)
+	}
+
+	// A second capture updates the existing secret
+	if _, err := captureToSecret(context.Background(), s, "api_token", "echo second-token", nil, 0); err != nil {
+		t.Fatalf("unexpected error capturing output: %v", err)
+	}
+
+	got, err := s.GetSecretByKey("api_token")
+	if err != nil {
+		t.Fatalf("unexpected error getting secret: %v", err)
+	}
+
+	if got.Value != "second-token" {
+		t.Fatalf("expected secret value %q, got %q", "second-token", got.Value)
+	}
+}
+
+func TestCaptureToSecret_ErrCommandFailed(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+
+	s := storetest.New(t)
+
+	if _, err := captureToSecret(context.Background(), s, "api_token", "echo leaked && exit 1", nil, 0); err == nil {
+		t.Fatal("expected error for failing command, got nil")
+	}
+
+	if _, err := s.GetSecretByKey("api_token"); err == nil {
+		t.Fatal("expected no secret to be stored for a failing command")
+	}
+}
+
+func TestCaptureToSecret_Refused(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+
+	tests := map[string]struct {
+		command  string
+		maxBytes int64
+		want     error
+	}{
+		"empty output":     {command: "echo '   '", want: ErrCaptureEmpty},
+		"truncated output": {command: "echo 0123456789abcdef", maxBytes: 8, want: ErrCaptureTruncated},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			if _, err := s.AddSecret("api_token", "good-token", ""); err != nil {
+				t.Fatalf("unexpected error adding secret: %v", err)
+			}
+
+			_, err := captureToSecret(context.Background(), s, "api_token", tc.command, nil, tc.maxBytes)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected error %v, got %v", tc.want, err)
+			}
+
+			got, err := s.GetSecretByKey("api_token")
+			if err != nil {
+				t.Fatalf("unexpected error getting secret: %v", err)
+			}
+
+			if got.Value != "good-token" {
+				t.Fatalf("expected the secret to be kept, got %q", got.Value)
+			}
+		})
+	}
+}
+
+func TestRunWithParams_JSONFailure(t *testing.T) { // nolint:paralleltest
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+
+	logger.Reset()
+	t.Cleanup(logger.Reset)
+
+	var buf bytes.Buffer
+	logger.SetWriter(&buf)
+	logger.New(logger.ModeJSON)
+
+	s := storetest.New(t)
+
+	cmd, err := s.AddCommand("failing", "exit 3", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	err = runWithParams(s, cmd, map[string]string{})
+
+	var runErr *RunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("expected a RunError, got %v", err)
+	}
+
+	if runErr.Stage != runStageExecute || runErr.ExitCode != 3 {
+		t.Fatalf("expected stage %q and exit code %v, got %q and %v", runStageExecute, 3, runErr.Stage, runErr.ExitCode)
+	}
+
+	if !runErr.Reported {
+		t.Fatalf("expected the failure to be marked as reported")
+	}
+
+	output := buf.String()
+	for _, want := range []string{`"msg":"Run failed"`, `"name":"failing"`, `"stage":"execute"`, `"exit_code":3`} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected output to contain %s, got: %s", want, output)
+		}
+	}
+}
+
+func TestRunWithParams_DefaultTimeout(t *testing.T) { // nolint:paralleltest
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell syntax")
+	}
+
+	viper.Set("settings.default-timeout", "100ms")
+	t.Cleanup(func() { viper.Set("settings.default-timeout", nil) })
+
+	s := storetest.New(t)
+
+	cmd, err := s.AddCommand("slow", "sleep 30", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	start := time.Now()
+	err = runWithParams(s, cmd, map[string]string{})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the command to be stopped promptly, took %v", elapsed)
+	}
+}
+
+func TestRunCmd_InteractiveIOConflict(t *testing.T) { // nolint:paralleltest
+	tests := map[string]func(){
+		"capture": func() { runCapture = "token" },
+		"async":   func() { runAsync = true },
+		"tty":     func() { runTTY = true },
+	}
+
+	for name, set := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() {
+				runInteractiveIO, runCapture, runAsync, runTTY = false, "", false, false
+			})
+
+			runInteractiveIO = true
+
+			set()
+
+			if err := RunCmd.RunE(RunCmd, []string{"greet"}); !errors.Is(err, ErrInteractiveIOConflict) {
+				t.Fatalf("expected error %v, got %v", ErrInteractiveIOConflict, err)
+			}
+		})
+	}
+}
+
+func TestEffectiveRunTimeout(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		viper.Set("settings.default-timeout", nil)
+		runTimeout, runTimeoutSet = 0, false
+	})
+
+	// Neither flag nor config: no limit
+	if got, err := effectiveRunTimeout(); err != nil || got != 0 {
+		t.Fatalf("expected no timeout, got %v, %v", got, err)
+	}
+
+	viper.Set("settings.default-timeout", "5m")
+
+	if got, err := effectiveRunTimeout(); err != nil || got != 5*time.Minute {
+		t.Fatalf("expected the config timeout %v, got %v, %v", 5*time.Minute, got, err)
+	}
+
+	// The flag wins, even when it lifts the limit
+	runTimeout, runTimeoutSet = 0, true
+
+	if got, err := effectiveRunTimeout(); err != nil || got != 0 {
+		t.Fatalf("expected the flag to lift the timeout, got %v, %v", got, err)
+	}
+
+	runTimeout = -time.Second
+
+	if _, err := effectiveRunTimeout(); !errors.Is(err, ErrInvalidTimeout) {
+		t.Fatalf("expected %v, got %v", ErrInvalidTimeout, err)
+	}
+}
+
+func TestRunWithParams_ReportsAllParameterProblems(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	cmd, err := s.AddCommand("deploy",
+		`deploy --env {{env}} --port {{port|the port|/^\d+$/}} --region {{region}} --token {{!token}}`, "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	err = runWithParams(s, cmd, map[string]string{"port": "http", "extra": "x"})
+
+	var report *ParameterReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected a ParameterReport, got %v", err)
+	}
+
+	if !errors.Is(err, ErrParameterProblems) {
+		t.Fatalf("expected error %v, got %v", ErrParameterProblems, err)
+	}
+
+	want := []struct {
+		name string
+		err  error
+	}{
+		{"extra", brackets.ErrUndeclaredParameter},
+		{"port", brackets.ErrPatternMismatch},
+		{"env", ErrMissingValue},
+		{"region", ErrMissingValue},
+		{"token", ErrSecretNotStored},
+	}
+
+	if len(report.Problems) != len(want) {
+		t.Fatalf("expected %v problems, got %v", len(want), report.Problems)
+	}
+
+	for _, w := range want {
+		found := slices.ContainsFunc(report.Problems, func(problem error) bool {
+			var perr *brackets.ParameterError
+
+			return errors.As(problem, &perr) && perr.Name == w.name && errors.Is(problem, w.err)
+		})
+		if !found {
+			t.Fatalf("expected a %v problem for %s, got %v", w.err, w.name, report.Problems)
+		}
+
+		if !strings.Contains(err.Error(), w.name) {
+			t.Fatalf("expected the report to mention %s, got %q", w.name, err)
+		}
+	}
+}
+
+func TestCheckParameters_None(t *testing.T) {
+	t.Parallel()
+
+	params := brackets.Parameters{{Name: "env"}}
+	values := map[string]string{"env": "prod"}
+
+	if report := checkParameters(params, values, values, nil); report != nil {
+		t.Fatalf("expected no problems, got %v", report)
+	}
+}
+
+// prepStore returns a store backed by a fresh, migrated database.
@@ -0,0 +1,239 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/h3jfc/shed/internal/config"
+	"github.com/h3jfc/shed/internal/execute"
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// followInterval is how often shed runs --follow checks a log for new output.
+const followInterval = 200 * time.Millisecond
+
+var (
+	runsTail   int
+	runsFollow bool
+)
+
+var (
+	ErrInvalidTail     = errors.New("--tail must not be negative")
+	ErrFollowWithoutID = errors.New("--tail and --follow need a run ID")
+)
+
+// RunsCmd represents the runs command.
+var RunsCmd = &cobra.Command{
+	Use:   "runs [RUN_ID]",
+	Short: "List background runs or print the log of one",
+	Long: `List commands started with shed run --async, most recent first, with
+whether each is still running or how it exited, or print the log of one run by
+its ID.
+
+Logs are kept under <shed-dir>/runs and hold the command's stdout and stderr.
+With --tail N only the last N lines of the log are printed. With --follow shed
+keeps printing output as the run writes it, until the run finishes.
+
+Exit codes are recorded on Unix. On Windows a finished run is shown as
+finished, without its exit code.
+
+Example:
+  # List background runs
+  shed runs
+
+  # Print the log of a run
+  shed runs lz3k1a9x0q
+
+  # Print the last 20 lines of a run and keep following it
+  shed runs lz3k1a9x0q --tail 20 --follow`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		if runsTail < 0 {
+			logger.Error("Invalid flag", "error", ErrInvalidTail)
+
+			return ErrInvalidTail
+		}
+
+		if len(args) == 0 && (runsTail > 0 || runsFollow) {
+			logger.Error("Invalid flags", "error", ErrFollowWithoutID)
+
+			return ErrFollowWithoutID
+		}
+
+		shedDir, err := configuredShedDir()
+		if err != nil {
+			logger.Error("Failed to find shed directory", "error", err)
+
+			return err
+		}
+
+		if len(args) == 1 {
+			logger.Debug("Printing run log", "run", args[0], "tail", runsTail, "follow", runsFollow)
+
+			return printRunLog(c.OutOrStdout(), shedDir, args[0], runsTail, runsFollow)
+		}
+
+		records, err := config.ListRunRecords(shedDir)
+		if err != nil {
+			logger.Error("Failed to list runs", "error", err)
+
+			return err
+		}
+
+		if len(records) == 0 {
+			logger.Info("No background runs found")
+
+			return nil
+		}
+
+		logger.Info(fmt.Sprintf("Found %d run(s)", len(records)))
+
+		for _, r := range records {
+			logger.Info(formatRunRecord(r))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RunsCmd.Flags().IntVarP(&runsTail, "tail", "n", 0, "Print only the last N lines of the log")
+	RunsCmd.Flags().BoolVarP(&runsFollow, "follow", "f", false, "Keep printing output until the run finishes")
+}
+
+// runStatus describes whether a run is still going and, once it has finished,
+// how it exited.
+func runStatus(r config.RunRecord) string {
+	code, ok, err := r.ExitCode()
+	if err != nil {
+		logger.Debug("Failed to read run exit code", "run", r.ID, "error", err)
+
+		return "unknown"
+	}
+
+	switch {
+	case ok:
+		return fmt.Sprintf("exited %d", code)
+	case execute.ProcessRunning(r.PID):
+		return "running"
+	default:
+		return "finished"
+	}
+}
+
+// runActive reports whether the command of a run is still running.
+func runActive(r config.RunRecord) bool {
+	if _, ok, _ := r.ExitCode(); ok {
+		return false
+	}
+
+	return execute.ProcessRunning(r.PID)
+}
+
+// formatRunRecord renders a run for shed runs in the style of shed list.
+func formatRunRecord(r config.RunRecord) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "\nID:      %s\n", r.ID)
+	fmt.Fprintf(&sb, "Name:    %s\n", r.Name)
+	fmt.Fprintf(&sb, "PID:     %d\n", r.PID)
+	fmt.Fprintf(&sb, "Status:  %s\n", runStatus(r))
+	fmt.Fprintf(&sb, "Started: %s\n", r.StartedAt.Format(time.DateTime))
+	fmt.Fprintf(&sb, "Log:     %s", r.Log)
+
+	return sb.String()
+}
+
+// printRunLog copies the log of the run with the given ID to w, or only its
+// last tail lines when tail is positive. With follow, it keeps copying output
+// as it is written until the run finishes.
+func printRunLog(w io.Writer, shedDir, id string, tail int, follow bool) error {
+	r, err := config.GetRunRecord(shedDir, id)
+	if err != nil {
+		logger.Error("Failed to get run", "run", id, "error", err)
+
+		return err
+	}
+
+	f, err := os.Open(r.Log)
+	if err != nil {
+		logger.Error("Failed to open run log", "run", id, "log", r.Log, "error", err)
+
+		return fmt.Errorf("failed to open run log: %w", err)
+	}
+	defer f.Close()
+
+	if tail > 0 {
+		bb, err := io.ReadAll(f)
+		if err != nil {
+			return fmt.Errorf("failed to read run log: %w", err)
+		}
+
+		_, err = w.Write(lastLines(bb, tail))
+	} else {
+		_, err = io.Copy(w, f)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to print run log: %w", err)
+	}
+
+	if !follow {
+		return nil
+	}
+
+	if err := followRunLog(w, f, *r, followInterval); err != nil {
+		return err
+	}
+
+	logger.Info("Run finished", "run", r.ID, "status", runStatus(*r))
+
+	return nil
+}
+
+// followRunLog copies output appended to f to w, checking every interval,
+// until the run has finished and all of its output is copied.
+func followRunLog(w io.Writer, f io.Reader, r config.RunRecord, interval time.Duration) error {
+	for {
+		// Checked before copying so output written just before the run
+		// finished is not missed
+		active := runActive(r)
+
+		if _, err := io.Copy(w, f); err != nil {
+			return fmt.Errorf("failed to print run log: %w", err)
+		}
+
+		if !active {
+			return nil
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// lastLines returns the last n lines of bb. A final line without a trailing
+// newline counts as a line.
+func lastLines(bb []byte, n int) []byte {
+	end := len(bb)
+	if end > 0 && bb[end-1] == '\n' {
+		end--
+	}
+
+	for i := end - 1; i >= 0; i-- {
+		if bb[i] != '\n' {
+			continue
+		}
+
+		n--
+		if n == 0 {
+			return bb[i+1:]
+		}
+	}
+
+	return bb
+}
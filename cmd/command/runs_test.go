@@ -0,0 +1,140 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3jfc/shed/internal/config"
+)
+
+func TestStartAsync(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	shedDir := t.TempDir()
+	start := time.Now()
+
+	record, err := startAsync(shedDir, "build", "echo building; sleep 2", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected startAsync to return promptly, took %v", elapsed)
+	}
+
+	saved, err := config.GetRunRecord(shedDir, record.ID)
+	if err != nil {
+		t.Fatalf("expected the run to be recorded, got %v", err)
+	}
+
+	if saved.Name != "build" || saved.PID == 0 || saved.Log != record.Log {
+		t.Fatalf("expected record %+v, got %+v", record, saved)
+	}
+
+	if !strings.HasPrefix(saved.Log, config.RunsDir(shedDir)) {
+		t.Fatalf("expected log under %s, got %s", config.RunsDir(shedDir), saved.Log)
+	}
+
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		var buf bytes.Buffer
+		if err := printRunLog(&buf, shedDir, record.ID, 0, false); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if strings.Contains(buf.String(), "building") {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected log to contain %q, got %q", "building", buf.String())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrintRunLog_ErrRunNotFound(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	if err := printRunLog(&buf, t.TempDir(), "missing", 0, false); !errors.Is(err, config.ErrRunNotFound) {
+		t.Fatalf("expected %v, got %v", config.ErrRunNotFound, err)
+	}
+}
+
+func TestPrintRunLog_Follow(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	shedDir := t.TempDir()
+
+	record, err := startAsync(shedDir, "build", "echo one; sleep 0.3; echo two; exit 4", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	r, err := config.GetRunRecord(shedDir, record.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	f, err := os.Open(r.Log)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer f.Close()
+
+	if err := followRunLog(&buf, f, *r, 10*time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := buf.String(); got != "one\ntwo\n" {
+		t.Fatalf("expected the whole log once the run finished, got %q", got)
+	}
+
+	if got := runStatus(*r); got != "exited 4" {
+		t.Fatalf("expected status %q, got %q", "exited 4", got)
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		in   string
+		n    int
+		want string
+	}{
+		"fewer lines than n": {in: "a\nb\n", n: 5, want: "a\nb\n"},
+		"last two":           {in: "a\nb\nc\n", n: 2, want: "b\nc\n"},
+		"no trailing line":   {in: "a\nb\nc", n: 1, want: "c"},
+		"empty":              {in: "", n: 3, want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := string(lastLines([]byte(tt.in), tt.n)); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
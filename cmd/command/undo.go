@@ -0,0 +1,61 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// UndoCmd represents the undo command.
+var UndoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the most recently removed command",
+	Long: `Restore the most recently removed command from the trash.
+
+The command comes back with its original body, description, parameters,
+environment variables and aliases. An alias whose name has been taken since is
+left out. Running undo again restores the removal before that.
+
+Example:
+  # Remove a command, then bring it back
+  shed rm list_files
+  shed undo`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		logger.Debug("Restoring last removed command")
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		cmd, err := s.RestoreLastDeleted()
+		if err != nil {
+			if errors.Is(err, store.ErrTrashEmpty) {
+				logger.Error("No removed command to restore")
+
+				return err
+			}
+
+			if errors.Is(err, store.ErrAlreadyExists) {
+				logger.Error("A command with the same name exists, remove or rename it first", "error", err)
+
+				return err
+			}
+
+			logger.Error("Failed to restore command", "error", err)
+
+			return err
+		}
+
+		logger.Info("Command restored successfully", "name", cmd.Name, "command", cmd.Command)
+
+		return nil
+	},
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/h3jfc/shed/internal/commands"
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var ErrNoShedDir = errors.New("no shed directory found, run 'shed init' first")
+
+// envCmd represents the env command.
+var envCmd = &cobra.Command{
+	Use:   "env <bash|zsh|fish|powershell>",
+	Short: "Print shell setup for SHED_DIR and completions",
+	Long: `Print the lines that set SHED_DIR to the shed directory in use and load
+shed's shell completions, for the shell to evaluate on startup.
+
+Examples:
+  # bash, in ~/.bashrc
+  eval "$(shed env bash)"
+
+  # zsh, in ~/.zshrc
+  eval "$(shed env zsh)"
+
+  # fish, in ~/.config/fish/config.fish
+  shed env fish | source
+
+  # PowerShell, in $PROFILE
+  shed env powershell | Out-String | Invoke-Expression`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: commands.EnvShells,
+	RunE: func(c *cobra.Command, args []string) error {
+		configFile := viper.ConfigFileUsed()
+		if configFile == "" {
+			logger.Error("Shed directory not found", "error", ErrNoShedDir)
+
+			return ErrNoShedDir
+		}
+
+		dir, err := filepath.Abs(filepath.Dir(configFile))
+		if err != nil {
+			logger.Error("Failed to resolve shed directory", "error", err)
+
+			return err
+		}
+
+		if err := commands.Env(c.OutOrStdout(), dir, args[0], rootCmd.Name()); err != nil {
+			logger.Error("Failed to print shell environment", "error", err)
+
+			return err
+		}
+
+		return nil
+	},
+}
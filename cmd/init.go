@@ -9,35 +9,53 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var ErrShedAlreadyInitialized = errors.New("shed already initialized")
+var (
+	initConfigFormat string
+
+	// initExistingDir is the valid shed directory found before init runs, if any.
+	initExistingDir string
+)
 
 // initCmd represents the add command.
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Init command that displays the current configuration",
-	Long:  `init`,
+	Long: `Set up a shed directory with a config file and an encrypted database.
+
+When shed is already initialized, init leaves the directory as it is and only
+runs pending database migrations, without asking for a password.`,
 	PreRunE: func(_ *cobra.Command, _ []string) error {
 		logger.Debug("Starting shed initialization process")
 
+		if err := config.ValidateFormat(initConfigFormat); err != nil {
+			logger.Error("Invalid config format", "format", initConfigFormat)
+
+			return err
+		}
+
 		logger.Debug("Checking for existing shed configuration")
 		p, err := config.FindDir()
 		if err != nil && !errors.Is(err, config.ErrNoPathFound) {
 			return err
 		}
 
+		initExistingDir = ""
 		if err == nil && p != "" {
-			logger.Info("Shed is already initialized at location", "location", p)
-			logger.Debug("Initialization aborted to prevent overwriting existing configuration")
+			logger.Debug("Shed is already initialized, migrating instead", "location", p)
 
-			return ErrShedAlreadyInitialized
+			initExistingDir = p
 		}
 
 		return nil
 	},
 	RunE: func(c *cobra.Command, _ []string) error {
+		if initExistingDir != "" {
+			return commands.Upgrade(c.Context(), initExistingDir)
+		}
+
 		logger.Info("Initializing shed configuration")
 
-		if err := commands.Init(c.Context()); err != nil {
+		if err := commands.Init(c.Context(), initConfigFormat); err != nil {
 			logger.Debug("Error running init command", "error", err)
 
 			return err
@@ -48,5 +66,7 @@ var initCmd = &cobra.Command{
 }
 
 func init() {
+	initCmd.Flags().StringVar(&initConfigFormat, "config-format", config.DefaultConfigFormat,
+		"Format of the config file to create (toml, yaml or yml)")
 	rootCmd.AddCommand(initCmd)
 }
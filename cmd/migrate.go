@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/sqlite3"
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd represents the migrate command.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the shed database to the current schema",
+	Long: `Apply any database migrations this version of shed ships with that the
+configured database does not have yet. Databases are migrated when created by
+shed init; run this after upgrading shed to bring an existing one up to date.
+Running it on an up-to-date database does nothing.
+
+Example:
+  shed migrate`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		dbPath, encryptionKey, err := store.ConfiguredDB()
+		if err != nil {
+			logger.Error("Database is not configured", "error", err)
+
+			return err
+		}
+
+		logger.Debug("Migrating database", "path", dbPath)
+
+		if err := sqlite3.MigrateShedDB(dbPath, encryptionKey); err != nil {
+			logger.Error("Failed to migrate database", "error", err)
+
+			return err
+		}
+
+		logger.Info("Database is up to date", "path", dbPath)
+
+		return nil
+	},
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/h3jfc/shed/internal/config"
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var profileConfigFormat string
+
+// profileCmd represents the parent profile command.
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage shed profiles",
+	Long: `Manage shed profiles.
+
+A profile is a shed directory of its own, with its own config file and
+database, kept under profiles/ in the default shed directory. Select one with
+--shed-dir profile:NAME.
+
+Available commands:
+  clone   Create a profile from the commands and secrets in use`,
+}
+
+// profileCloneCmd represents the profile clone command.
+var profileCloneCmd = &cobra.Command{
+	Use:   "clone <PROFILE_NAME>",
+	Short: "Create a profile from the commands and secrets in use",
+	Long: `Create a new profile holding a copy of every command and secret of the shed
+directory in use. The profile gets its own database, encrypted with a new
+password that is prompted for. Aliases, the trash and the audit log are not
+copied.
+
+Examples:
+  # Start a work profile from the default shed directory
+  shed profile clone work
+
+  # Start a profile from another profile
+  shed --shed-dir profile:work profile clone client`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		name := args[0]
+
+		s, err := store.NewReadOnlyStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.Close()
+
+		p, err := config.CreateProfile(name, profileConfigFormat)
+		if err != nil {
+			logger.Error("Failed to create profile", "name", name, "error", err)
+
+			return err
+		}
+
+		if err := s.CloneTo(p.DBPath, p.Password); err != nil {
+			logger.Error("Failed to copy commands and secrets to profile", "name", name, "error", err)
+
+			// Only this run created the profile, so leave nothing half made behind
+			os.RemoveAll(p.Dir)
+
+			return err
+		}
+
+		logger.Info("Profile created", "name", name, "location", p.Dir)
+		logger.Info("Use it with --shed-dir " + config.ProfilePrefix + name)
+
+		return nil
+	},
+}
+
+func init() {
+	profileCloneCmd.Flags().StringVar(&profileConfigFormat, "config-format", config.DefaultConfigFormat,
+		"Format of the profile's config file (toml, yaml or yml)")
+	profileCmd.AddCommand(profileCloneCmd)
+	rootCmd.AddCommand(profileCmd)
+}
@@ -4,12 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime/debug"
 	"slices"
 
 	"github.com/h3jfc/shed/cmd/command"
 	"github.com/h3jfc/shed/cmd/secret"
 	"github.com/h3jfc/shed/internal/config"
+	shellexec "github.com/h3jfc/shed/internal/execute"
 	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/brackets"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -19,6 +23,8 @@ var (
 	Version = "NOT SET"
 )
 
+var ErrInvalidLogFormat = errors.New("invalid log format")
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "shed",
@@ -37,6 +43,14 @@ to quickly create a Cobra application.`,
 			ll = "verbose"
 		}
 
+		switch format := c.Flags().Lookup("log-format").Value.String(); format {
+		case "text":
+		case "json":
+			ll = "json"
+		default:
+			return fmt.Errorf("%w: %q, must be text or json", ErrInvalidLogFormat, format)
+		}
+
 		logger.New(logger.ModeFromString(ll))
 
 		if ll == "verbose" {
@@ -44,7 +58,14 @@ to quickly create a Cobra application.`,
 		}
 
 		if !isInitCommand(c) {
-			initConfig(c.Flags().Lookup("shed-dir").Value.String())
+			shedDir, err := config.ResolveShedDir(c.Flags().Lookup("shed-dir").Value.String())
+			if err != nil {
+				logger.Error("Invalid shed directory", "error", err)
+
+				return err
+			}
+
+			initConfig(shedDir)
 
 			if err := viper.BindPFlags(c.Flags()); err != nil {
 				logger.Debug("Error binding flags to viper config", "error", err)
@@ -67,21 +88,67 @@ to quickly create a Cobra application.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Error executing command", "error", err)
-		os.Exit(1)
+	verbose := func() bool {
+		return rootCmd.PersistentFlags().Lookup("verbose").Value.String() == "true"
 	}
+
+	os.Exit(execute(rootCmd.Execute, verbose))
+}
+
+// execute runs fn and returns the process exit code, which is the exit status
+// of the stored command when a run of it failed. A panic is recovered and
+// logged as a short error instead of a raw stack trace, which is only printed
+// when verbose reports true.
+func execute(fn func() error, verbose func() bool) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Shed hit an unexpected error and had to stop", "error", r)
+
+			if verbose() {
+				logger.Error(string(debug.Stack()))
+			} else {
+				logger.Error("Run again with --verbose for the full stack trace")
+			}
+
+			code = 1
+		}
+	}()
+
+	if err := fn(); err != nil {
+		var re *command.RunError
+		if !errors.As(err, &re) {
+			logger.Error("Error executing command", "error", err)
+
+			return 1
+		}
+
+		if !re.Reported {
+			logger.Error("Error executing command", "error", err)
+		}
+
+		// A command stopped by a signal has no exit status of its own
+		if re.ExitCode > 0 {
+			return re.ExitCode
+		}
+
+		return 1
+	}
+
+	return 0
 }
 
 func init() {
-	rootCmd.PersistentFlags().String("shed-dir", os.Getenv("SHED_DIR"), "Path to the Shed configuration directory")
+	rootCmd.PersistentFlags().String("shed-dir", os.Getenv("SHED_DIR"), "Path to the Shed configuration directory, or profile:NAME to use a profile")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().String("log-format", "text", "Log output format, text or json")
 
 	// Register secret commands
 	rootCmd.AddCommand(secret.Init())
 
 	// Register main commands
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(envCmd)
 	rootCmd.AddCommand(command.AddCmd)
 	rootCmd.AddCommand(command.ListCmd)
 	rootCmd.AddCommand(command.RunCmd)
@@ -89,12 +156,22 @@ func init() {
 	rootCmd.AddCommand(command.EditCmd)
 	rootCmd.AddCommand(command.DescribeCmd)
 	rootCmd.AddCommand(command.CpCmd)
+	rootCmd.AddCommand(command.MvCmd)
+	rootCmd.AddCommand(command.RepairCmd)
+	rootCmd.AddCommand(command.UndoCmd)
+	rootCmd.AddCommand(command.PickCmd)
+	rootCmd.AddCommand(command.RunsCmd)
+	rootCmd.AddCommand(command.ImportCmd)
+	rootCmd.AddCommand(command.ExportCmd)
+	rootCmd.AddCommand(command.AliasCmd)
+	rootCmd.AddCommand(command.DedupeCmd)
+	rootCmd.AddCommand(command.AuditCmd)
 }
 
 // initConfig reads in config file and ENV variables.
 func initConfig(shedDir string) {
 	// Initialize the configuration system
-	logger.Info("initializing config")
+	logger.Debug("initializing config")
 
 	if err := Init(shedDir); err != nil {
 		logger.Error("Error initializing config: %v\n", err)
@@ -110,8 +187,8 @@ func initConfig(shedDir string) {
 // Init initializes the configuration system.
 func Init(shedDir string) error {
 	// Set up Viper
+	// The config type is taken from the file extension (config.toml, config.yaml, ...)
 	viper.SetConfigName("config")
-	viper.SetConfigType("toml")
 	viper.SetEnvPrefix("SHED")
 	viper.AutomaticEnv()
 
@@ -145,9 +222,74 @@ func Init(shedDir string) error {
 	encryptionKey := viper.GetString("shed-db.password")
 	logger.Debug("Database configuration", "path", dbPath, "encryption_key_set", encryptionKey != "")
 
+	// An explicitly empty prefix is rejected rather than falling back to !
+	if viper.IsSet("settings.secret-prefix") {
+		prefix := viper.GetString("settings.secret-prefix")
+		logger.Debug("Using configured secret prefix", "prefix", prefix)
+
+		if err := brackets.SetSecretPrefix(prefix); err != nil {
+			return fmt.Errorf("invalid secret prefix: %w", err)
+		}
+	}
+
+	if viper.GetBool("settings.trim-trailing-separator") {
+		logger.Debug("Trimming dangling trailing separators from commands")
+		brackets.SetTrimTrailingSeparator(true)
+	}
+
+	if viper.IsSet("settings.shell-args") {
+		args := viper.GetStringSlice("settings.shell-args")
+		logger.Debug("Using configured shell args", "args", args)
+
+		if err := shellexec.SetShellArgs(args); err != nil {
+			return fmt.Errorf("invalid shell args: %w", err)
+		}
+	}
+
+	if tmpl := viper.GetString("settings.default-description-template"); tmpl != "" {
+		logger.Debug("Using configured default description template", "template", tmpl)
+		store.SetDefaultDescriptionTemplate(tmpl)
+	}
+
+	if rules, ok := nameRulesFromSettings(); ok {
+		logger.Debug("Using configured name rules",
+			"allow_hyphens", rules.AllowHyphens,
+			"allow_dots", rules.AllowDots,
+			"max_length", rules.MaxLength,
+		)
+
+		if err := store.SetNameRules(rules); err != nil {
+			return fmt.Errorf("invalid name rules: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// nameRulesFromSettings builds command name rules from the settings section,
+// starting from the strict defaults. It reports false when none are set.
+func nameRulesFromSettings() (store.NameRules, bool) {
+	rules := store.DefaultNameRules
+	set := false
+
+	if viper.IsSet("settings.name-allow-hyphens") {
+		rules.AllowHyphens = viper.GetBool("settings.name-allow-hyphens")
+		set = true
+	}
+
+	if viper.IsSet("settings.name-allow-dots") {
+		rules.AllowDots = viper.GetBool("settings.name-allow-dots")
+		set = true
+	}
+
+	if viper.IsSet("settings.name-max-length") {
+		rules.MaxLength = viper.GetInt("settings.name-max-length")
+		set = true
+	}
+
+	return rules, set
+}
+
 func isInitCommand(cmd *cobra.Command) bool {
 	if cmd.CalledAs() == initCmd.Name() {
 		return true
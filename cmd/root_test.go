@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/h3jfc/shed/cmd/command"
+	"github.com/h3jfc/shed/internal/logger"
+)
+
+var errTest = errors.New("test error")
+
+func newTestLogger(mode logger.LogMode) *bytes.Buffer {
+	logger.Reset()
+
+	var buf bytes.Buffer
+	logger.SetWriter(&buf)
+	logger.New(mode)
+
+	return &buf
+}
+
+func TestExecute_OK(t *testing.T) { // nolint:paralleltest
+	newTestLogger(logger.ModeMessageLevel)
+
+	if code := execute(func() error { return nil }, func() bool { return false }); code != 0 {
+		t.Fatalf("expected exit code %v, got %v", 0, code)
+	}
+}
+
+func TestExecute_Err(t *testing.T) { // nolint:paralleltest
+	buf := newTestLogger(logger.ModeMessageLevel)
+
+	code := execute(func() error { return errTest }, func() bool { return false })
+	if code != 1 {
+		t.Fatalf("expected exit code %v, got %v", 1, code)
+	}
+
+	if !strings.Contains(buf.String(), errTest.Error()) {
+		t.Fatalf("expected output to contain %q, got %v", errTest.Error(), buf.String())
+	}
+}
+
+func TestExecute_RunError(t *testing.T) { // nolint:paralleltest
+	tests := map[string]struct {
+		err      *command.RunError
+		wantCode int
+		wantLogs int
+	}{
+		"exit code":    {err: &command.RunError{ExitCode: 3, Err: errTest}, wantCode: 3, wantLogs: 1},
+		"no exit code": {err: &command.RunError{Err: errTest}, wantCode: 1, wantLogs: 1},
+		"reported":     {err: &command.RunError{ExitCode: 2, Reported: true, Err: errTest}, wantCode: 2, wantLogs: 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := newTestLogger(logger.ModeJSON)
+
+			code := execute(func() error { return tc.err }, func() bool { return false })
+			if code != tc.wantCode {
+				t.Fatalf("expected exit code %v, got %v", tc.wantCode, code)
+			}
+
+			if got := strings.Count(buf.String(), "\n"); got != tc.wantLogs {
+				t.Fatalf("expected %d log records, got %d: %v", tc.wantLogs, got, buf.String())
+			}
+		})
+	}
+}
+
+func TestExecute_RecoversPanic(t *testing.T) { // nolint:paralleltest
+	buf := newTestLogger(logger.ModeMessageLevel)
+
+	code := execute(func() error { panic("invariant violated") }, func() bool { return false })
+	if code != 1 {
+		t.Fatalf("expected exit code %v, got %v", 1, code)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "invariant violated") {
+		t.Fatalf("expected output to contain the panic value, got %v", out)
+	}
+
+	if strings.Contains(out, "goroutine") {
+		t.Fatalf("expected no stack trace outside verbose mode, got %v", out)
+	}
+}
+
+func TestExecute_RecoversPanicVerbose(t *testing.T) { // nolint:paralleltest
+	buf := newTestLogger(logger.ModeVerbose)
+
+	code := execute(func() error { panic("invariant violated") }, func() bool { return true })
+	if code != 1 {
+		t.Fatalf("expected exit code %v, got %v", 1, code)
+	}
+
+	if !strings.Contains(buf.String(), "goroutine") {
+		t.Fatalf("expected a stack trace in verbose mode, got %v", buf.String())
+	}
+}
@@ -2,34 +2,55 @@ package secret
 
 import (
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/h3jfc/shed/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var addSecretDescription string
+var (
+	addSecretDescription string
+	addSecretStdin       bool
+)
+
+var (
+	ErrSecretValueSource  = errors.New("provide the secret value as an argument or on stdin, not both")
+	ErrSecretValueMissing = errors.New("provide the secret value as an argument or on stdin")
+)
 
-const addSecretRequiredArgs = 2
+const (
+	addSecretMinArgs = 1
+	addSecretMaxArgs = 2
+
+	// stdinValue as the value argument reads the secret value from stdin.
+	stdinValue = "-"
+)
 
 // addCmd represents the add secret command.
 var addCmd = &cobra.Command{
-	Use:   "add <KEY> <VALUE>",
+	Use:   "add <KEY> [VALUE|-]",
 	Short: "Add a new secret to shed",
 	Long: `Add a new secret to shed with a key, value, and optional description.
 
 Secrets are used to store sensitive information like passwords, API keys, and tokens.
 They can be referenced in commands using the {{!key}} syntax.
 
+Pass - as the value, or use --stdin, to read the value from stdin instead. This
+keeps it out of shell history and the process table. A single trailing newline
+is dropped.
+
 Example:
   shed secret add github_token ghp_abc123xyz --description "GitHub API token"
-  shed secret add db_password mysecretpass -d "Database password"`,
-	Args: cobra.ExactArgs(addSecretRequiredArgs),
-	RunE: func(_ *cobra.Command, args []string) error {
+  shed secret add db_password mysecretpass -d "Database password"
+  pass show db | shed secret add db_password -
+  shed secret add db_password --stdin < password.txt`,
+	Args: cobra.RangeArgs(addSecretMinArgs, addSecretMaxArgs),
+	RunE: func(c *cobra.Command, args []string) error {
 		key := args[0]
-		value := args[1]
 
-		logger.Debug("Adding secret", "key", key, "description", addSecretDescription)
+		logger.Debug("Adding secret", "key", key, "description", addSecretDescription, "stdin", addSecretStdin)
 
 		s, err := store.NewStoreFromConfig()
 		if err != nil {
@@ -38,7 +59,9 @@ Example:
 			return err
 		}
 
-		secret, err := s.AddSecret(key, value, addSecretDescription)
+		defer s.CloseLogged()
+
+		secret, err := addSecret(s, args, addSecretStdin, c.InOrStdin())
 		if err != nil {
 			if errors.Is(err, store.ErrAlreadyExists) {
 				logger.Error("Secret already exists", "key", key)
@@ -66,3 +89,40 @@ Example:
 		return nil
 	},
 }
+
+// addSecret adds the secret named by args[0], taking the value from args[1] or,
+// when fromStdin is set or the value is "-", from stdin.
+func addSecret(s *store.Store, args []string, fromStdin bool, stdin io.Reader) (*store.Secret, error) {
+	value, err := secretValue(args, fromStdin, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AddSecret(args[0], value, addSecretDescription)
+}
+
+// secretValue returns the value for the secret named by args[0]: args[1], or
+// stdin when fromStdin is set or args[1] is "-". An explicit empty args[1] is
+// store.ErrEmptySecret.
+func secretValue(args []string, fromStdin bool, stdin io.Reader) (string, error) {
+	key := args[0]
+	hasValue := len(args) == addSecretMaxArgs
+
+	if fromStdin && hasValue && args[1] != stdinValue {
+		return "", ErrSecretValueSource
+	}
+
+	if fromStdin || (hasValue && args[1] == stdinValue) {
+		return store.ReadSecretValue(stdin)
+	}
+
+	if !hasValue {
+		return "", fmt.Errorf("%w: missing value for %q", ErrSecretValueMissing, key)
+	}
+
+	if args[1] == "" {
+		return "", fmt.Errorf("%w: %q", store.ErrEmptySecret, key)
+	}
+
+	return args[1], nil
+}
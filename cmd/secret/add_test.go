@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestAddSecret_FromStdin(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args      []string
+		fromStdin bool
+	}{
+		"dash value": {args: []string{"token", "-"}},
+		"stdin flag": {args: []string{"token"}, fromStdin: true},
+		"both":       {args: []string{"token", "-"}, fromStdin: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			secret, err := addSecret(s, tc.args, tc.fromStdin, bytes.NewBufferString("s3cr3t\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := s.GetSecretByKey("token")
+			if err != nil {
+				t.Fatalf("unexpected error getting secret: %v", err)
+			}
+
+			if got.Value != "s3cr3t" || secret.Value != "s3cr3t" {
+				t.Fatalf("expected value %q, got %q", "s3cr3t", got.Value)
+			}
+
+			for _, arg := range tc.args {
+				if arg == got.Value {
+					t.Fatalf("secret value should not appear in args %v", tc.args)
+				}
+			}
+		})
+	}
+}
+
+func TestAddSecret_ValueSource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args      []string
+		fromStdin bool
+		want      error
+	}{
+		"value and stdin flag":       {args: []string{"token", "s3cr3t"}, fromStdin: true, want: ErrSecretValueSource},
+		"empty value and stdin flag": {args: []string{"token", ""}, fromStdin: true, want: ErrSecretValueSource},
+		"no value":                   {args: []string{"token"}, want: ErrSecretValueMissing},
+		"empty value":                {args: []string{"token", ""}, want: store.ErrEmptySecret},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			_, err := addSecret(s, tc.args, tc.fromStdin, bytes.NewBufferString("other\n"))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected error %v, got %v", tc.want, err)
+			}
+
+			if _, err := s.GetSecretByKey("token"); !errors.Is(err, store.ErrSecretNotFound) {
+				t.Fatalf("expected error %v, got %v", store.ErrSecretNotFound, err)
+			}
+		})
+	}
+}
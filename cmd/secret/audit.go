@@ -0,0 +1,94 @@
+package secret
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit secrets command.
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report unused secrets and commands referencing missing ones",
+	Long: `Report which commands reference each secret.
+
+Secrets no command references are listed as candidates for deletion. Secrets
+referenced by a command but not stored are listed with the commands that need
+them, since those commands will fail to run until the secret is added.
+
+Example:
+  # Audit secret usage
+  shed secret audit
+
+  # Also show which commands use each secret
+  shed secret audit -v`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		logger.Debug("Auditing secrets")
+
+		s, err := store.NewReadOnlyStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		report, err := s.SecretsUsageReport()
+		if err != nil {
+			logger.Error("Failed to build secrets usage report", "error", err)
+
+			return err
+		}
+
+		keys, err := s.ListSecretKeys()
+		if err != nil {
+			logger.Error("Failed to list secrets", "error", err)
+
+			return err
+		}
+
+		unused, missing := splitUsageReport(report, keys)
+
+		for _, key := range keys {
+			logger.Debug("Secret usage", "key", key, "commands", strings.Join(report[key], ", "))
+		}
+
+		for _, key := range unused {
+			logger.Warn("Unused secret, candidate for deletion", "key", key)
+		}
+
+		for _, key := range missing {
+			logger.Warn("Missing secret referenced by commands",
+				"key", key,
+				"commands", strings.Join(report[key], ", "),
+			)
+		}
+
+		if len(unused) == 0 && len(missing) == 0 {
+			logger.Info("All secrets are in use and every referenced secret exists")
+		}
+
+		return nil
+	},
+}
+
+// splitUsageReport returns the stored keys no command references and the
+// referenced keys that are not stored, both sorted.
+func splitUsageReport(report map[string][]string, stored []string) (unused, missing []string) {
+	for key, names := range report {
+		switch {
+		case !slices.Contains(stored, key):
+			missing = append(missing, key)
+		case len(names) == 0:
+			unused = append(unused, key)
+		}
+	}
+
+	slices.Sort(unused)
+	slices.Sort(missing)
+
+	return unused, missing
+}
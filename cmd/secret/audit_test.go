@@ -0,0 +1,28 @@
+package secret
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitUsageReport(t *testing.T) {
+	t.Parallel()
+
+	report := map[string][]string{
+		"used_key":    {"backup", "deploy"},
+		"unused_b":    {},
+		"unused_a":    {},
+		"missing_key": {"backup"},
+	}
+	stored := []string{"unused_a", "unused_b", "used_key"}
+
+	unused, missing := splitUsageReport(report, stored)
+
+	if want := []string{"unused_a", "unused_b"}; !slices.Equal(unused, want) {
+		t.Fatalf("expected unused %v, got %v", want, unused)
+	}
+
+	if want := []string{"missing_key"}; !slices.Equal(missing, want) {
+		t.Fatalf("expected missing %v, got %v", want, missing)
+	}
+}
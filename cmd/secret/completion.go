@@ -0,0 +1,43 @@
+package secret
+
+import (
+	"strings"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/itertools"
+	"github.com/spf13/cobra"
+)
+
+// completeSecretKeys completes the first argument with stored secret keys. When
+// the store cannot be opened it offers nothing rather than failing the shell.
+func completeSecretKeys(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	s, err := store.NewReadOnlyStoreFromConfig()
+	if err != nil {
+		logger.Debug("Secret completion unavailable", "error", err)
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	defer s.CloseLogged()
+
+	return secretKeyCompletions(s, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// secretKeyCompletions returns the stored secret keys starting with prefix.
+func secretKeyCompletions(s *store.Store, prefix string) []string {
+	keys, err := s.ListSecretKeys()
+	if err != nil {
+		logger.Debug("Failed to list secret keys for completion", "error", err)
+
+		return nil
+	}
+
+	return itertools.Filter(keys, func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
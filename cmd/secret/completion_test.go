@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestSecretKeyCompletions(t *testing.T) {
+	t.Parallel()
+
+	s := storetest.New(t)
+
+	for _, key := range []string{"gh_token", "aws_key", "gh_app_id"} {
+		if _, err := s.AddSecret(key, "value", ""); err != nil {
+			t.Fatalf("unexpected error adding secret: %v", err)
+		}
+	}
+
+	tests := map[string]struct {
+		prefix string
+		want   []string
+	}{
+		"all":       {prefix: "", want: []string{"aws_key", "gh_app_id", "gh_token"}},
+		"prefix":    {prefix: "gh_", want: []string{"gh_app_id", "gh_token"}},
+		"exact key": {prefix: "aws_key", want: []string{"aws_key"}},
+		"no match":  {prefix: "db", want: []string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := secretKeyCompletions(s, tc.prefix)
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
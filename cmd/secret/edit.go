@@ -42,12 +42,18 @@ Example:
 			return err
 		}
 
+		defer s.CloseLogged()
+
 		// Get existing secret to preserve description if not provided
 		existing, err := s.GetSecretByKey(key)
 		if err != nil {
-			logger.Error("Secret not found", "key", key, "error", err)
+			if errors.Is(err, store.ErrSecretNotFound) {
+				logger.Error("Secret not found", "key", key)
+			} else {
+				logger.Error("Failed to get secret", "key", key, "error", err)
+			}
 
-			return fmt.Errorf("failed to update secret: %w", store.ErrSecretNotFound)
+			return fmt.Errorf("failed to update secret: %w", err)
 		}
 
 		description := existing.Description
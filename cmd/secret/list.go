@@ -9,6 +9,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	listSecretSort    string
+	listSecretReverse bool
+)
+
 // listCmd represents the list secrets command.
 var listCmd = &cobra.Command{
 	Use:   "list",
@@ -18,24 +23,43 @@ var listCmd = &cobra.Command{
 Displays the key, description, and timestamps for each secret.
 Note: Secret values are not displayed for security reasons.
 
+Secrets are listed newest first unless --sort is given. --sort accepts
+key, created, or updated and orders ascending; --reverse flips the order.
+
 Example:
   # List all secrets
   shed secret list
 
+  # List secrets alphabetically by key
+  shed secret list --sort key
+
+  # List the most recently updated secrets first
+  shed secret list --sort updated --reverse
+
   # List all secrets with verbose output
   shed secret list -v`,
 	Args: cobra.NoArgs,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		logger.Debug("Listing secrets")
+		logger.Debug("Listing secrets", "sort", listSecretSort, "reverse", listSecretReverse)
+
+		sortBy, err := store.ParseSecretSort(listSecretSort)
+		if err != nil {
+			logger.Error("Invalid sort field", "sort", listSecretSort)
+
+			return err
+		}
 
-		s, err := store.NewStoreFromConfig()
+		s, err := store.NewReadOnlyStoreFromConfig()
 		if err != nil {
 			logger.Error("Failed to initialize store", "error", err)
 
 			return err
 		}
 
-		secrets, err := s.ListSecrets()
+		secrets, err := s.ListSecretsPaged(store.ListSecretsOptions{
+			Sort:    sortBy,
+			Reverse: listSecretReverse,
+		})
 		if err != nil {
 			logger.Error("Failed to list secrets", "error", err)
 
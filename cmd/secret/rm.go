@@ -34,6 +34,8 @@ Example:
 			return err
 		}
 
+		defer s.CloseLogged()
+
 		err = s.RemoveSecret(key)
 		if err != nil {
 			logger.Error("Failed to remove secret", "key", key, "error", err)
@@ -0,0 +1,79 @@
+package secret
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var rotateSecretStdin bool
+
+// rotateCmd represents the rotate secret command.
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <KEY> [VALUE|-]",
+	Short: "Replace a secret's value and list the commands using it",
+	Long: `Replace the value of an existing secret, keeping its description, and list
+the commands that reference it so they can be checked with the new value.
+
+When the value is omitted, or is -, or --stdin is set, it is read from stdin.
+A single trailing newline is dropped.
+
+Example:
+  shed secret rotate github_token ghp_new123
+  pass show github | shed secret rotate github_token`,
+	Args: cobra.RangeArgs(addSecretMinArgs, addSecretMaxArgs),
+	RunE: func(c *cobra.Command, args []string) error {
+		key := args[0]
+
+		logger.Debug("Rotating secret", "key", key, "stdin", rotateSecretStdin)
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		dependents, err := rotateSecret(s, args, rotateSecretStdin, c.InOrStdin())
+		if err != nil {
+			if errors.Is(err, store.ErrSecretNotFound) {
+				logger.Error("Secret not found, add it with shed secret add", "key", key)
+
+				return err
+			}
+
+			logger.Error("Failed to rotate secret", "error", err)
+
+			return err
+		}
+
+		logger.Info("Secret rotated successfully", "key", key)
+
+		if len(dependents) > 0 {
+			logger.Warn("Check the commands using the rotated secret", "commands", strings.Join(dependents, ", "))
+		}
+
+		return nil
+	},
+}
+
+// rotateSecret replaces the value of the secret named by args[0], reading it
+// from stdin when it is not given as args[1], and returns the commands using it.
+func rotateSecret(s *store.Store, args []string, fromStdin bool, stdin io.Reader) ([]string, error) {
+	if len(args) < addSecretMaxArgs {
+		fromStdin = true
+	}
+
+	value, err := secretValue(args, fromStdin, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.RotateSecret(args[0], value)
+}
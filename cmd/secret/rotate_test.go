@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestRotateSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args  []string
+		stdin string
+	}{
+		"value argument": {args: []string{"token", "s3cr3t"}},
+		"from stdin":     {args: []string{"token"}, stdin: "s3cr3t\n"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			if _, err := s.AddSecret("token", "old", "api token"); err != nil {
+				t.Fatalf("unexpected error adding secret: %v", err)
+			}
+
+			if _, err := s.AddCommand("gh_issues", "gh issue list --token {{!token}}", ""); err != nil {
+				t.Fatalf("unexpected error adding command: %v", err)
+			}
+
+			dependents, err := rotateSecret(s, tc.args, false, bytes.NewBufferString(tc.stdin))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := []string{"gh_issues"}; !slices.Equal(dependents, want) {
+				t.Fatalf("expected dependents %v, got %v", want, dependents)
+			}
+
+			got, err := s.GetSecretByKey("token")
+			if err != nil {
+				t.Fatalf("unexpected error getting secret: %v", err)
+			}
+
+			if got.Value != "s3cr3t" {
+				t.Fatalf("expected value %q, got %q", "s3cr3t", got.Value)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package secret
 
-import (
-	"github.com/spf13/cobra"
-)
+import "github.com/spf13/cobra"
 
 // Cmd represents the parent secret command.
 var Cmd = &cobra.Command{
@@ -15,20 +13,37 @@ They can be referenced in commands using the {{!key}} syntax.
 
 Available commands:
   add     Add a new secret
+  set     Add a secret, or update it if it exists
+  rotate  Replace a secret's value and list the commands using it
   list    List all secrets
   edit    Edit an existing secret
-  rm      Remove a secret`,
+  rm      Remove a secret
+  audit   Report unused and missing secrets`,
 }
 
 // Init registers all secret subcommands with the parent command.
 func Init() *cobra.Command {
 	Cmd.AddCommand(addCmd)
+	Cmd.AddCommand(setCmd)
+	Cmd.AddCommand(rotateCmd)
 	Cmd.AddCommand(listCmd)
 	Cmd.AddCommand(editCmd)
 	Cmd.AddCommand(rmCmd)
+	Cmd.AddCommand(auditCmd)
 
 	addCmd.Flags().StringVarP(&addSecretDescription, "description", "d", "", "Description of the secret")
+	editCmd.ValidArgsFunction = completeSecretKeys
+	setCmd.ValidArgsFunction = completeSecretKeys
+	rotateCmd.ValidArgsFunction = completeSecretKeys
+	rmCmd.ValidArgsFunction = completeSecretKeys
+
+	addCmd.Flags().BoolVar(&addSecretStdin, "stdin", false, "Read the secret value from stdin")
+	setCmd.Flags().StringVarP(&setSecretDescription, "description", "d", "", "Description of the secret")
+	setCmd.Flags().BoolVar(&setSecretStdin, "stdin", false, "Read the secret value from stdin")
+	rotateCmd.Flags().BoolVar(&rotateSecretStdin, "stdin", false, "Read the new secret value from stdin")
 	editCmd.Flags().StringVarP(&editSecretDescription, "description", "d", "", "New description for the secret")
+	listCmd.Flags().StringVar(&listSecretSort, "sort", "", "Sort secrets by key, created, or updated")
+	listCmd.Flags().BoolVar(&listSecretReverse, "reverse", false, "Reverse the sort order")
 
 	return Cmd
 }
@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"errors"
+	"io"
+
+	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setSecretDescription string
+	setSecretStdin       bool
+)
+
+// setCmd represents the set secret command.
+var setCmd = &cobra.Command{
+	Use:   "set <KEY> [VALUE|-]",
+	Short: "Add a secret, or update it if it exists",
+	Long: `Store a secret value under a key, adding the secret if it does not exist
+and updating it if it does.
+
+When updating, the description is kept unless --description is given.
+
+When the value is omitted, or is -, or --stdin is set, it is read from stdin.
+A single trailing newline is dropped.
+
+Example:
+  shed secret set github_token ghp_abc123xyz --description "GitHub API token"
+  pass show db | shed secret set db_password
+  shed secret set db_password --stdin < password.txt`,
+	Args: cobra.RangeArgs(addSecretMinArgs, addSecretMaxArgs),
+	RunE: func(c *cobra.Command, args []string) error {
+		key := args[0]
+
+		logger.Debug("Setting secret", "key", key, "description", setSecretDescription, "stdin", setSecretStdin)
+
+		s, err := store.NewStoreFromConfig()
+		if err != nil {
+			logger.Error("Failed to initialize store", "error", err)
+
+			return err
+		}
+
+		defer s.CloseLogged()
+
+		secret, created, err := setSecret(s, args, setSecretStdin, c.InOrStdin(), setSecretDescription)
+		if err != nil {
+			if errors.Is(err, store.ErrInvalidCommandName) {
+				logger.Error("Invalid secret key", "key", key, "error", err)
+
+				return err
+			}
+
+			logger.Error("Failed to set secret", "error", err)
+
+			return err
+		}
+
+		msg := "Secret updated successfully"
+		if created {
+			msg = "Secret added successfully"
+		}
+
+		logger.Info(msg, "id", secret.ID, "key", secret.Key, "description", secret.Description)
+
+		return nil
+	},
+}
+
+// setSecret stores the secret named by args[0], reading the value from stdin
+// when it is not given as args[1]. It reports whether the secret was created.
+func setSecret(
+	s *store.Store,
+	args []string,
+	fromStdin bool,
+	stdin io.Reader,
+	description string,
+) (*store.Secret, bool, error) {
+	if len(args) < addSecretMaxArgs {
+		fromStdin = true
+	}
+
+	value, err := secretValue(args, fromStdin, stdin)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.SetSecret(args[0], value, description)
+}
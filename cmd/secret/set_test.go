@@ -0,0 +1,61 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store/storetest"
+)
+
+func TestSetSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existing    bool
+		args        []string
+		stdin       string
+		wantCreated bool
+	}{
+		"new secret":             {args: []string{"token", "s3cr3t"}, wantCreated: true},
+		"existing secret":        {existing: true, args: []string{"token", "s3cr3t"}},
+		"new secret from stdin":  {args: []string{"token"}, stdin: "s3cr3t\n", wantCreated: true},
+		"existing from stdin":    {existing: true, args: []string{"token"}, stdin: "s3cr3t\n"},
+		"existing from dash arg": {existing: true, args: []string{"token", "-"}, stdin: "s3cr3t"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := storetest.New(t)
+
+			if tc.existing {
+				if _, err := s.AddSecret("token", "old", "api token"); err != nil {
+					t.Fatalf("unexpected error adding secret: %v", err)
+				}
+			}
+
+			_, created, err := setSecret(s, tc.args, false, bytes.NewBufferString(tc.stdin), "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if created != tc.wantCreated {
+				t.Fatalf("expected created %v, got %v", tc.wantCreated, created)
+			}
+
+			got, err := s.GetSecretByKey("token")
+			if err != nil {
+				t.Fatalf("unexpected error getting secret: %v", err)
+			}
+
+			if got.Value != "s3cr3t" {
+				t.Fatalf("expected value %q, got %q", "s3cr3t", got.Value)
+			}
+
+			if tc.existing && got.Description != "api token" {
+				t.Fatalf("expected description to be kept, got %q", got.Description)
+			}
+		})
+	}
+}
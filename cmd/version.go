@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
 
-	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/sqlite3"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // versionCmd represents the version command.
@@ -13,12 +18,63 @@ var versionCmd = &cobra.Command{
 	Short: "Display version and build information",
 	Long: `Display the current version and build information for shed.
 
-Shows the version number and commit hash used to build this binary.
+Shows the version number and commit hash used to build this binary, the Go
+version and platform it was built for, the shed directory in use, and the
+migration version of its database next to the one this build expects. Include
+this output when reporting a bug.
 
 Example:
   shed version`,
 	Args: cobra.NoArgs,
-	Run: func(_ *cobra.Command, _ []string) {
-		logger.Info(fmt.Sprintf("shed version %s (commit: %s)\n", Version, Commit))
+	Run: func(c *cobra.Command, _ []string) {
+		writeVersion(c.OutOrStdout())
 	},
 }
+
+// writeVersion prints the build information and the state of the shed
+// directory in use to w.
+func writeVersion(w io.Writer) {
+	fmt.Fprintf(w, "shed version %s\n", Version)
+	fmt.Fprintf(w, "commit:    %s\n", Commit)
+	fmt.Fprintf(w, "go:        %s\n", runtime.Version())
+	fmt.Fprintf(w, "platform:  %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "shed dir:  %s\n", versionShedDir())
+	fmt.Fprintf(w, "database:  %s (this build: %d)\n", versionDBSchema(), sqlite3.SchemaVersion())
+}
+
+// versionShedDir returns the directory of the config file in use, or a note
+// when there is none.
+func versionShedDir() string {
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		return "not found"
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(configFile))
+	if err != nil {
+		return filepath.Dir(configFile)
+	}
+
+	return dir
+}
+
+// versionDBSchema describes the migration version of the configured database.
+// Problems are reported in the text instead of failing the command, so it
+// still prints the build information.
+func versionDBSchema() string {
+	dbPath, encryptionKey, err := store.ConfiguredDB()
+	if err != nil {
+		return "not configured"
+	}
+
+	version, dirty, err := sqlite3.ShedDBVersion(dbPath, encryptionKey)
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+
+	if dirty {
+		return fmt.Sprintf("schema %d, dirty", version)
+	}
+
+	return fmt.Sprintf("schema %d", version)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionCmd_PrintsCommit(t *testing.T) { // nolint:paralleltest
+	prevCommit, prevVersion := Commit, Version
+	t.Cleanup(func() { Commit, Version = prevCommit, prevVersion })
+
+	Commit = "abc1234"
+	Version = "v1.2.3"
+
+	var buf bytes.Buffer
+	versionCmd.SetOut(&buf)
+	t.Cleanup(func() { versionCmd.SetOut(nil) })
+
+	versionCmd.Run(versionCmd, nil)
+
+	output := buf.String()
+	for _, want := range []string{"shed version v1.2.3", "commit:    abc1234", runtime.Version(), runtime.GOOS + "/" + runtime.GOARCH} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected output to contain %q, got: %s", want, output)
+		}
+	}
+}
@@ -11,14 +11,15 @@ import (
 )
 
 const createCommand = `-- name: CreateCommand :one
-INSERT INTO commands (name, command, description, parameters)
-VALUES (?, ?, ?, ?)
-RETURNING id, name, command, description, parameters, created_at, updated_at
+INSERT INTO commands (name, command, raw_command, description, parameters)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, name, command, description, parameters, created_at, updated_at, raw_command, env
 `
 
 type CreateCommandParams struct {
 	Name        string
 	Command     string
+	RawCommand  string
 	Description string
 	Parameters  json.RawMessage
 }
@@ -27,6 +28,7 @@ func (q *Queries) CreateCommand(ctx context.Context, arg CreateCommandParams) (C
 	row := q.db.QueryRowContext(ctx, createCommand,
 		arg.Name,
 		arg.Command,
+		arg.RawCommand,
 		arg.Description,
 		arg.Parameters,
 	)
@@ -39,6 +41,8 @@ func (q *Queries) CreateCommand(ctx context.Context, arg CreateCommandParams) (C
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
@@ -64,7 +68,7 @@ func (q *Queries) DeleteCommandByName(ctx context.Context, name string) error {
 }
 
 const getCommandByCommand = `-- name: GetCommandByCommand :one
-SELECT id, name, command, description, parameters, created_at, updated_at FROM commands
+SELECT id, name, command, description, parameters, created_at, updated_at, raw_command, env FROM commands
 WHERE command = ?
 `
 
@@ -79,12 +83,14 @@ func (q *Queries) GetCommandByCommand(ctx context.Context, command string) (Comm
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
 
 const getCommandByID = `-- name: GetCommandByID :one
-SELECT id, name, command, description, parameters, created_at, updated_at FROM commands
+SELECT id, name, command, description, parameters, created_at, updated_at, raw_command, env FROM commands
 WHERE id = ?
 `
 
@@ -99,12 +105,14 @@ func (q *Queries) GetCommandByID(ctx context.Context, id int64) (Command, error)
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
 
 const getCommandByName = `-- name: GetCommandByName :one
-SELECT id, name, command, description, parameters, created_at, updated_at FROM commands
+SELECT id, name, command, description, parameters, created_at, updated_at, raw_command, env FROM commands
 WHERE name = ?
 `
 
@@ -119,12 +127,14 @@ func (q *Queries) GetCommandByName(ctx context.Context, name string) (Command, e
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
 
 const listCommands = `-- name: ListCommands :many
-SELECT id, name, command, description, parameters, created_at, updated_at FROM commands
+SELECT id, name, command, description, parameters, created_at, updated_at, raw_command, env FROM commands
 ORDER BY created_at DESC
 `
 
@@ -145,6 +155,47 @@ func (q *Queries) ListCommands(ctx context.Context) ([]Command, error) {
 			&i.Parameters,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RawCommand,
+			&i.Env,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommandsUpdatedSince = `-- name: ListCommandsUpdatedSince :many
+SELECT id, name, command, description, parameters, created_at, updated_at, raw_command, env FROM commands
+WHERE updated_at >= ?
+ORDER BY updated_at, id
+`
+
+func (q *Queries) ListCommandsUpdatedSince(ctx context.Context, updatedAt string) ([]Command, error) {
+	rows, err := q.db.QueryContext(ctx, listCommandsUpdatedSince, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Command
+	for rows.Next() {
+		var i Command
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Command,
+			&i.Description,
+			&i.Parameters,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RawCommand,
+			&i.Env,
 		); err != nil {
 			return nil, err
 		}
@@ -161,14 +212,15 @@ func (q *Queries) ListCommands(ctx context.Context) ([]Command, error) {
 
 const updateCommand = `-- name: UpdateCommand :one
 UPDATE commands
-SET name = ?, command = ?, parameters = ?, description = ?
+SET name = ?, command = ?, raw_command = ?, parameters = ?, description = ?
 WHERE id = ?
-RETURNING id, name, command, description, parameters, created_at, updated_at
+RETURNING id, name, command, description, parameters, created_at, updated_at, raw_command, env
 `
 
 type UpdateCommandParams struct {
 	Name        string
 	Command     string
+	RawCommand  string
 	Parameters  json.RawMessage
 	Description string
 	ID          int64
@@ -178,6 +230,7 @@ func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) (C
 	row := q.db.QueryRowContext(ctx, updateCommand,
 		arg.Name,
 		arg.Command,
+		arg.RawCommand,
 		arg.Parameters,
 		arg.Description,
 		arg.ID,
@@ -191,20 +244,23 @@ func (q *Queries) UpdateCommand(ctx context.Context, arg UpdateCommandParams) (C
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
 
 const updateCommandByName = `-- name: UpdateCommandByName :one
 UPDATE commands
-SET name = ?, command = ?, parameters = ?, description = ?
+SET name = ?, command = ?, raw_command = ?, parameters = ?, description = ?
 WHERE name = ?
-RETURNING id, name, command, description, parameters, created_at, updated_at
+RETURNING id, name, command, description, parameters, created_at, updated_at, raw_command, env
 `
 
 type UpdateCommandByNameParams struct {
 	Name        string
 	Command     string
+	RawCommand  string
 	Parameters  json.RawMessage
 	Description string
 	Name_2      string
@@ -214,6 +270,7 @@ func (q *Queries) UpdateCommandByName(ctx context.Context, arg UpdateCommandByNa
 	row := q.db.QueryRowContext(ctx, updateCommandByName,
 		arg.Name,
 		arg.Command,
+		arg.RawCommand,
 		arg.Parameters,
 		arg.Description,
 		arg.Name_2,
@@ -227,6 +284,66 @@ func (q *Queries) UpdateCommandByName(ctx context.Context, arg UpdateCommandByNa
 		&i.Parameters,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
+	)
+	return i, err
+}
+
+const updateCommandDescription = `-- name: UpdateCommandDescription :one
+UPDATE commands
+SET description = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING id, name, command, description, parameters, created_at, updated_at, raw_command, env
+`
+
+type UpdateCommandDescriptionParams struct {
+	Description string
+	ID          int64
+}
+
+func (q *Queries) UpdateCommandDescription(ctx context.Context, arg UpdateCommandDescriptionParams) (Command, error) {
+	row := q.db.QueryRowContext(ctx, updateCommandDescription, arg.Description, arg.ID)
+	var i Command
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Command,
+		&i.Description,
+		&i.Parameters,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
+	)
+	return i, err
+}
+
+const updateCommandEnv = `-- name: UpdateCommandEnv :one
+UPDATE commands
+SET env = ?
+WHERE id = ?
+RETURNING id, name, command, description, parameters, created_at, updated_at, raw_command, env
+`
+
+type UpdateCommandEnvParams struct {
+	Env json.RawMessage
+	ID  int64
+}
+
+func (q *Queries) UpdateCommandEnv(ctx context.Context, arg UpdateCommandEnvParams) (Command, error) {
+	row := q.db.QueryRowContext(ctx, updateCommandEnv, arg.Env, arg.ID)
+	var i Command
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Command,
+		&i.Description,
+		&i.Parameters,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RawCommand,
+		&i.Env,
 	)
 	return i, err
 }
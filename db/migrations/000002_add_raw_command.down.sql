@@ -0,0 +1,2 @@
+-- Drop raw command column
+ALTER TABLE commands DROP COLUMN raw_command;
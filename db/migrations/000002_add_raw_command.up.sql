@@ -0,0 +1,2 @@
+-- Preserve the command body exactly as the user entered it
+ALTER TABLE commands ADD COLUMN raw_command TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Drop command environment column
+ALTER TABLE commands DROP COLUMN env;
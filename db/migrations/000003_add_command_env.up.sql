@@ -0,0 +1,3 @@
+-- Environment variables injected when a command runs.
+-- The default is '{}' stored as a blob, matching how parameters are written.
+ALTER TABLE commands ADD COLUMN env JSONB NOT NULL DEFAULT X'7B7D';
@@ -0,0 +1,3 @@
+-- Drop the trash of removed commands
+DROP INDEX IF EXISTS idx_trash_deleted_at;
+DROP TABLE IF EXISTS trash;
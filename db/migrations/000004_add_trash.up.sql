@@ -0,0 +1,15 @@
+-- Removed commands are kept here so the last removal can be undone.
+-- JSON defaults are stored as blobs ('[]' and '{}'), matching how they are written.
+CREATE TABLE IF NOT EXISTS trash (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    command TEXT NOT NULL,
+    raw_command TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL,
+    parameters JSONB NOT NULL DEFAULT X'5B5D',
+    env JSONB NOT NULL DEFAULT X'7B7D',
+    created_at TEXT NOT NULL,
+    deleted_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trash_deleted_at ON trash(deleted_at);
@@ -0,0 +1,6 @@
+-- Restore the plain lookup indexes
+DROP INDEX IF EXISTS idx_commands_name;
+DROP INDEX IF EXISTS idx_secrets_key;
+
+CREATE INDEX IF NOT EXISTS idx_commands_name ON commands(name);
+CREATE INDEX IF NOT EXISTS idx_secrets_key ON secrets(key);
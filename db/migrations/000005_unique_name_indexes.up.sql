@@ -0,0 +1,7 @@
+-- Lookups by command name and secret key go through unique indexes, so a
+-- duplicate is rejected by the index as well as by the column constraint.
+DROP INDEX IF EXISTS idx_commands_name;
+DROP INDEX IF EXISTS idx_secrets_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commands_name ON commands(name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_secrets_key ON secrets(key);
@@ -0,0 +1,4 @@
+-- Drop command aliases
+DROP TRIGGER IF EXISTS delete_command_aliases;
+DROP INDEX IF EXISTS idx_aliases_command_id;
+DROP TABLE IF EXISTS aliases;
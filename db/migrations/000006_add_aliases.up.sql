@@ -0,0 +1,18 @@
+-- Aliases let a command be reached by more than one name.
+CREATE TABLE IF NOT EXISTS aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    alias TEXT NOT NULL UNIQUE,
+    command_id INTEGER NOT NULL REFERENCES commands(id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_aliases_command_id ON aliases(command_id);
+
+-- Foreign keys are not enforced on every connection, so removing a command
+-- drops its aliases here as well.
+CREATE TRIGGER IF NOT EXISTS delete_command_aliases
+AFTER DELETE ON commands
+FOR EACH ROW
+BEGIN
+    DELETE FROM aliases WHERE command_id = OLD.id;
+END;
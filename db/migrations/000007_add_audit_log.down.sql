@@ -0,0 +1,2 @@
+-- Drop the audit log
+DROP TABLE IF EXISTS audit_log;
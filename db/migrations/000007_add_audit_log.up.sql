@@ -0,0 +1,9 @@
+-- Every mutating store operation appends a row here, in the same transaction.
+-- Secrets are recorded by key only, never by value.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    op TEXT NOT NULL,
+    target TEXT NOT NULL,
+    at TEXT NOT NULL DEFAULT (datetime('now')),
+    detail TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,2 @@
+-- Drop trash aliases column
+ALTER TABLE trash DROP COLUMN aliases;
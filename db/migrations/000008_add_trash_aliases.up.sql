@@ -0,0 +1,3 @@
+-- Aliases of a removed command, so undoing the removal brings them back.
+-- The default is '[]' stored as a blob, matching how it is written.
+ALTER TABLE trash ADD COLUMN aliases JSONB NOT NULL DEFAULT X'5B5D';
@@ -8,6 +8,21 @@ import (
 	"encoding/json"
 )
 
+type Alias struct {
+	ID        int64
+	Alias     string
+	CommandID int64
+	CreatedAt string
+}
+
+type AuditLog struct {
+	ID     int64
+	Op     string
+	Target string
+	At     string
+	Detail string
+}
+
 type Command struct {
 	ID          int64
 	Name        string
@@ -16,6 +31,8 @@ type Command struct {
 	Parameters  json.RawMessage
 	CreatedAt   string
 	UpdatedAt   string
+	RawCommand  string
+	Env         json.RawMessage
 }
 
 type Secret struct {
@@ -26,3 +43,16 @@ type Secret struct {
 	CreatedAt   string
 	UpdatedAt   string
 }
+
+type Trash struct {
+	ID          int64
+	Name        string
+	Command     string
+	RawCommand  string
+	Description string
+	Parameters  json.RawMessage
+	Env         json.RawMessage
+	CreatedAt   string
+	DeletedAt   string
+	Aliases     json.RawMessage
+}
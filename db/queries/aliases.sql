@@ -0,0 +1,27 @@
+-- name: CreateAlias :one
+INSERT INTO aliases (alias, command_id)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: GetAlias :one
+SELECT * FROM aliases
+WHERE alias = ?;
+
+-- name: GetCommandByAlias :one
+SELECT commands.* FROM commands
+JOIN aliases ON aliases.command_id = commands.id
+WHERE aliases.alias = ?;
+
+-- name: DeleteAlias :execrows
+DELETE FROM aliases
+WHERE alias = ?;
+
+-- name: ListAliasesByCommandID :many
+SELECT alias FROM aliases
+WHERE command_id = ?
+ORDER BY alias;
+
+-- name: MoveAliases :exec
+UPDATE aliases
+SET command_id = ?
+WHERE command_id = ?;
@@ -0,0 +1,11 @@
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (op, target, detail)
+VALUES (?, ?, ?);
+
+-- name: ListAuditLog :many
+SELECT * FROM (
+    SELECT * FROM audit_log
+    ORDER BY id DESC
+    LIMIT ?
+)
+ORDER BY id;
@@ -1,6 +1,6 @@
 -- name: CreateCommand :one
-INSERT INTO commands (name, command, description, parameters)
-VALUES (?, ?, ?, ?)
+INSERT INTO commands (name, command, raw_command, description, parameters)
+VALUES (?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetCommandByID :one
@@ -19,15 +19,20 @@ WHERE command = ?;
 SELECT * FROM commands
 ORDER BY created_at DESC;
 
+-- name: ListCommandsUpdatedSince :many
+SELECT * FROM commands
+WHERE updated_at >= ?
+ORDER BY updated_at, id;
+
 -- name: UpdateCommand :one
 UPDATE commands
-SET name = ?, command = ?, parameters = ?, description = ?
+SET name = ?, command = ?, raw_command = ?, parameters = ?, description = ?
 WHERE id = ?
 RETURNING *;
 
 -- name: UpdateCommandByName :one
 UPDATE commands
-SET name = ?, command = ?, parameters = ?, description = ?
+SET name = ?, command = ?, raw_command = ?, parameters = ?, description = ?
 WHERE name = ?
 RETURNING *;
 
@@ -38,3 +43,15 @@ WHERE id = ?;
 -- name: DeleteCommandByName :exec
 DELETE FROM commands
 WHERE name = ?;
+
+-- name: UpdateCommandEnv :one
+UPDATE commands
+SET env = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateCommandDescription :one
+UPDATE commands
+SET description = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING *;
@@ -19,6 +19,27 @@ WHERE key IN (sqlc.slice('keys'));
 SELECT * FROM secrets
 ORDER BY created_at DESC;
 
+-- name: ListSecretsPage :many
+SELECT key, description, created_at, updated_at FROM (
+    SELECT id, key, description, created_at, updated_at,
+        CASE CAST(sqlc.arg(sort) AS TEXT)
+            WHEN 'key' THEN key
+            WHEN 'updated' THEN updated_at
+            ELSE created_at
+        END AS sort_value
+    FROM secrets
+)
+ORDER BY
+    CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN sort_value END DESC,
+    CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN id END DESC,
+    sort_value,
+    id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListSecretKeys :many
+SELECT key FROM secrets
+ORDER BY key;
+
 -- name: UpdateSecret :one
 UPDATE secrets
 SET key = ?, value = ?, description = ?
@@ -0,0 +1,17 @@
+-- name: CreateTrash :one
+INSERT INTO trash (name, command, raw_command, description, parameters, env, created_at, aliases)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetLastTrash :one
+SELECT * FROM trash
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: DeleteTrashByID :exec
+DELETE FROM trash
+WHERE id = ?;
+
+-- name: PurgeTrash :execrows
+DELETE FROM trash
+WHERE deleted_at < ?;
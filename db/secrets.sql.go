@@ -174,6 +174,99 @@ func (q *Queries) ListSecrets(ctx context.Context) ([]Secret, error) {
 	return items, nil
 }
 
+const listSecretKeys = `-- name: ListSecretKeys :many
+SELECT key FROM secrets
+ORDER BY key
+`
+
+func (q *Queries) ListSecretKeys(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSecretKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSecretsPage = `-- name: ListSecretsPage :many
+SELECT key, description, created_at, updated_at FROM (
+    SELECT id, key, description, created_at, updated_at,
+        CASE CAST(?1 AS TEXT)
+            WHEN 'key' THEN key
+            WHEN 'updated' THEN updated_at
+            ELSE created_at
+        END AS sort_value
+    FROM secrets
+)
+ORDER BY
+    CASE WHEN CAST(?2 AS BOOLEAN) THEN sort_value END DESC,
+    CASE WHEN CAST(?2 AS BOOLEAN) THEN id END DESC,
+    sort_value,
+    id
+LIMIT ?3 OFFSET ?4
+`
+
+type ListSecretsPageParams struct {
+	Sort       string
+	Descending bool
+	Limit      int64
+	Offset     int64
+}
+
+type ListSecretsPageRow struct {
+	Key         string
+	Description string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+func (q *Queries) ListSecretsPage(ctx context.Context, arg ListSecretsPageParams) ([]ListSecretsPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSecretsPage,
+		arg.Sort,
+		arg.Descending,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSecretsPageRow
+	for rows.Next() {
+		var i ListSecretsPageRow
+		if err := rows.Scan(
+			&i.Key,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateSecret = `-- name: UpdateSecret :one
 UPDATE secrets
 SET key = ?, value = ?, description = ?
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+var ErrUnsupportedShell = errors.New("unsupported shell")
+
+// EnvShells are the shells Env can print setup lines for.
+var EnvShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Env writes the lines that point SHED_DIR at dir and load shed's completions
+// for shell, ready to be evaluated by it. program is the name shed is run as.
+func Env(w io.Writer, dir, shell, program string) error {
+	if !slices.Contains(EnvShells, shell) {
+		return fmt.Errorf("%w: %q, expected one of %v", ErrUnsupportedShell, shell, EnvShells)
+	}
+
+	instruction, _ := getShedDirInstruction(dir, shell)
+
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", instruction, completionHook(shell, program)); err != nil {
+		return fmt.Errorf("failed to write environment: %w", err)
+	}
+
+	return nil
+}
+
+// completionHook returns the line that sources shed's generated completion
+// script in shell.
+func completionHook(shell, program string) string {
+	switch shell {
+	case "fish":
+		return program + " completion fish | source"
+	case "powershell":
+		return program + " completion powershell | Out-String | Invoke-Expression"
+	default:
+		return fmt.Sprintf("source <(%s completion %s)", program, shell)
+	}
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	t.Parallel()
+
+	dir := "/home/o'neil/shed dir"
+
+	tests := map[string]struct {
+		export string
+		hook   string
+	}{
+		"bash":       {export: `export SHED_DIR='/home/o'\''neil/shed dir'`, hook: "source <(shed completion bash)"},
+		"zsh":        {export: `export SHED_DIR='/home/o'\''neil/shed dir'`, hook: "source <(shed completion zsh)"},
+		"fish":       {export: `set -Ux SHED_DIR '/home/o\'neil/shed dir'`, hook: "shed completion fish | source"},
+		"powershell": {export: `$env:SHED_DIR = '/home/o''neil/shed dir'`, hook: "shed completion powershell | Out-String | Invoke-Expression"},
+	}
+
+	for shell, tc := range tests {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			if err := Env(&buf, dir, shell, "shed"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines, got %q", buf.String())
+			}
+
+			if lines[0] != tc.export {
+				t.Fatalf("expected %q, got %q", tc.export, lines[0])
+			}
+
+			if lines[1] != tc.hook {
+				t.Fatalf("expected %q, got %q", tc.hook, lines[1])
+			}
+		})
+	}
+}
+
+func TestEnv_UnsupportedShell(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	if err := Env(&buf, "/tmp/shed", "tcsh", "shed"); !errors.Is(err, ErrUnsupportedShell) {
+		t.Fatalf("expected %v, got %v", ErrUnsupportedShell, err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+}
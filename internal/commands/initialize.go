@@ -13,6 +13,7 @@ import (
 
 	"github.com/h3jfc/shed/internal/config"
 	"github.com/h3jfc/shed/internal/logger"
+	"github.com/h3jfc/shed/lib/brackets"
 )
 
 const retryAttempts = 3
@@ -28,7 +29,9 @@ var (
 	ErrInvalidChoice      = errors.New("invalid choice")
 )
 
-func Init(_ context.Context) error {
+// Init prompts for a shed location and creates the shed directory there, with a
+// config file in the given format.
+func Init(_ context.Context, format string) error {
 	dir, err := promptUserDirWithRetry(config.DefaultConfigPaths, retryAttempts)
 	if err != nil {
 		logger.Error("Error selecting location", "error", err)
@@ -36,18 +39,41 @@ func Init(_ context.Context) error {
 		return ErrLocationSelection
 	}
 
-	if err := config.CreateShedDirectory(dir); err != nil {
+	existing, err := config.InitShedDirectory(dir, format)
+	if err != nil {
 		logger.Error("Error creating shed directory and db", "error", err)
-		os.RemoveAll(dir) // cleanup on failure
+
+		// Only clean up what this run created, never an existing shed directory
+		if !existing {
+			os.RemoveAll(dir)
+		}
 
 		return ErrDirectoryCreation
 	}
 
+	if existing {
+		logger.Info("Shed directory already exists, database is up to date", "location", dir)
+	}
+
 	logShellInstructions(dir)
 
 	return nil
 }
 
+// Upgrade brings the existing shed directory at dir up to date by running any
+// pending database migrations. It never prompts for a password.
+func Upgrade(_ context.Context, dir string) error {
+	if err := config.UpgradeShedDirectory(dir); err != nil {
+		logger.Error("Error upgrading shed directory", "location", dir, "error", err)
+
+		return err
+	}
+
+	logger.Info("Shed is already initialized, database is up to date", "location", dir)
+
+	return nil
+}
+
 func promptUserDir(locations []string) (string, error) {
 	if len(locations) == 0 {
 		logger.Error("No configuration locations provided to promptUserForLocation")
@@ -183,20 +209,34 @@ func detectShellsByConfigFiles() []string {
 func getShedDirInstruction(dir, shell string) (instruction, configFile string) {
 	switch shell {
 	case "fish":
-		instruction = "set -Ux SHED_DIR " + dir
+		instruction = "set -Ux SHED_DIR " + quoteForShell(shell, dir)
 		configFile = "~/.config/fish/config.fish"
 	case "zsh":
-		instruction = fmt.Sprintf("export SHED_DIR=\"%s\"", dir)
+		instruction = "export SHED_DIR=" + quoteForShell(shell, dir)
 		configFile = "~/.zshrc"
 	case "powershell":
-		instruction = fmt.Sprintf("$env:SHED_DIR = \"%s\"", filepath.ToSlash(dir))
+		instruction = "$env:SHED_DIR = " + quoteForShell(shell, filepath.ToSlash(dir))
 		configFile = "$PROFILE"
 	case "bash":
 		fallthrough
 	default:
-		instruction = fmt.Sprintf("export SHED_DIR=\"%s\"", dir)
+		instruction = "export SHED_DIR=" + quoteForShell(shell, dir)
 		configFile = "~/.bashrc"
 	}
 
 	return instruction, configFile
 }
+
+// quoteForShell single-quotes s so shell takes it literally.
+func quoteForShell(shell, s string) string {
+	switch shell {
+	case "fish":
+		s = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+	case "powershell":
+		s = strings.ReplaceAll(s, `'`, `''`)
+	default:
+		return brackets.ShellQuoteForExport(s)
+	}
+
+	return "'" + s + "'"
+}
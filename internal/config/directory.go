@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/spf13/viper"
@@ -14,12 +15,29 @@ var (
 	ErrConfigInvalid   = errors.New("shed configuration is invalid")
 	ErrMultipleConfigs = errors.New("multiple shed configurations found")
 	ErrNoPathFound     = errors.New("no shed configuration path found")
+	ErrInvalidFormat   = errors.New("unsupported configuration format")
 )
 
 const (
 	defaultConfigName = "config.toml"
+	configBaseName    = "config"
+
+	// DefaultConfigFormat is the format used for new configuration files.
+	DefaultConfigFormat = "toml"
 )
 
+// ConfigFormats lists the supported configuration file formats in lookup order.
+var ConfigFormats = []string{"toml", "yaml", "yml"}
+
+// ValidateFormat checks that format is one of ConfigFormats.
+func ValidateFormat(format string) error {
+	if !slices.Contains(ConfigFormats, format) {
+		return fmt.Errorf("%w: %q, expected one of %v", ErrInvalidFormat, format, ConfigFormats)
+	}
+
+	return nil
+}
+
 // GetShedDir returns the .shed directory path.
 func GetShedDir() (string, error) {
 	// TODO fix
@@ -111,16 +129,16 @@ func validatePath(p string) bool {
 		return false
 	}
 
-	// Check if config.toml exists
-	configPath := filepath.Join(p, defaultConfigName)
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	// Check if a config file exists in any supported format
+	configPath, format, found := findConfigFile(p)
+	if !found {
 		return false
 	}
 
-	// Validate config.toml structure using viper
+	// Validate config file structure using viper
 	v := viper.New()
 	v.SetConfigFile(configPath)
-	v.SetConfigType("toml")
+	v.SetConfigType(format)
 
 	if err := v.ReadInConfig(); err != nil {
 		return false
@@ -136,3 +154,16 @@ func validatePath(p string) bool {
 
 	return password != ""
 }
+
+// findConfigFile returns the path and format of the first config file in dir,
+// checking ConfigFormats in order.
+func findConfigFile(dir string) (string, string, bool) {
+	for _, format := range ConfigFormats {
+		configPath := filepath.Join(dir, configBaseName+"."+format)
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath, format, true
+		}
+	}
+
+	return "", "", false
+}
@@ -295,6 +295,65 @@ password = ""
 	}
 }
 
+func TestValidatePath_ValidYAML(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"yaml", "yml"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			writeDatabaseFile(t, tmpDir)
+
+			configContent := `shed-db:
+  password: "test_password_123"
+  location: "/tmp/shed.db"
+`
+			configPath := filepath.Join(tmpDir, "config."+format)
+			if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+				t.Fatalf("Failed to create config file: %v", err)
+			}
+
+			if !validatePath(tmpDir) {
+				t.Errorf("validatePath returned false for valid config.%s", format)
+			}
+		})
+	}
+}
+
+func TestValidatePath_YAMLEmptyPassword(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	writeDatabaseFile(t, tmpDir)
+
+	configContent := `shed-db:
+  password: ""
+`
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	if validatePath(tmpDir) {
+		t.Error("validatePath returned true when YAML password is empty")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range ConfigFormats {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("ValidateFormat(%q) returned error: %v", format, err)
+		}
+	}
+
+	if err := ValidateFormat("json"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ValidateFormat(\"json\") to return ErrInvalidFormat, but got: %v", err)
+	}
+}
+
 // Helper function to create a valid test environment at the specified path.
 func setupValidPath(t *testing.T, path string) {
 	t.Helper()
@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/h3jfc/shed/lib/sqlite3"
+	"github.com/spf13/viper"
 	"golang.org/x/term"
 )
 
@@ -28,21 +29,86 @@ var (
 	ErrInvalidChoice     = errors.New("invalid choice")
 )
 
+// passwordPrompt asks for the password of a new database. Tests replace it.
+var passwordPrompt = promptForPassword
+
+// InitShedDirectory sets up a shed directory at path. When path already holds a
+// valid shed directory it is kept as is: its database is migrated to the
+// current schema with the password from its config file, without prompting,
+// and existing is true. Otherwise it is created with CreateShedDirectory.
+func InitShedDirectory(path, format string) (bool, error) {
+	if IsShedDir(path) {
+		return true, UpgradeShedDirectory(path)
+	}
+
+	return false, CreateShedDirectory(path, format)
+}
+
+// IsShedDir reports whether path holds a valid shed directory: a database and
+// a config file with a database password.
+func IsShedDir(path string) bool {
+	return validatePath(path)
+}
+
+// UpgradeShedDirectory runs any pending migrations on the database of the shed
+// directory at path, using the location and password from its config file.
+func UpgradeShedDirectory(path string) error {
+	dbPath, password, err := readDBConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := sqlite3.MigrateShedDB(dbPath, password); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return nil
+}
+
+// readDBConfig returns the database location and password from the config file
+// of the shed directory at path. The location defaults to the database in path.
+func readDBConfig(path string) (string, string, error) {
+	configPath, format, found := findConfigFile(path)
+	if !found {
+		return "", "", fmt.Errorf("%w: no config file in %s", ErrConfigInvalid, path)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType(format)
+
+	if err := v.ReadInConfig(); err != nil {
+		return "", "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dbPath := v.GetString("shed-db.location")
+	if dbPath == "" {
+		dbPath = filepath.Join(path, defaultDBName)
+	}
+
+	return dbPath, v.GetString("shed-db.password"), nil
+}
+
 // CreateShedDirectory creates the shed directory structure and initializes required files.
-func CreateShedDirectory(path string) error {
+// The config file is written in format, which must be one of ConfigFormats.
+func CreateShedDirectory(path, format string) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+
 	// Create the directory if it doesn't exist
 	if err := os.MkdirAll(path, defaultDirPerms); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", path, err)
 	}
 
 	// Prompt for database password
-	password, err := promptForPassword()
+	password, err := passwordPrompt()
 	if err != nil {
 		return fmt.Errorf("failed to get password: %w", err)
 	}
 
-	// Create config.toml with the password
-	if err := createConfigFile(path, password); err != nil {
+	// Create the config file with the password
+	if err := createConfigFile(path, password, format); err != nil {
 		return fmt.Errorf("failed to create config file: %w", err)
 	}
 
@@ -97,28 +163,55 @@ func readPassword() (string, error) {
 	return string(bytePassword), nil
 }
 
-// createConfigFile creates a config.toml file with the database password.
-func createConfigFile(dirPath, password string) error {
-	configPath := filepath.Join(dirPath, defaultConfigName)
+// createConfigFile creates a config file in the given format with the database password.
+func createConfigFile(dirPath, password, format string) error {
+	configPath := filepath.Join(dirPath, configBaseName+"."+format)
 	dbPath := filepath.Join(dirPath, defaultDBName)
 
-	// Use forward slashes for cross-platform compatibility in TOML
-	// (backslashes are escape characters in TOML double-quoted strings)
+	// Use forward slashes for cross-platform compatibility in TOML and YAML
+	// (backslashes are escape characters in double-quoted strings)
 	dbPathNormalized := filepath.ToSlash(dbPath)
 
-	configContent := fmt.Sprintf(`[shed-db]
+	configContent := configTemplate(format, password, dbPathNormalized)
+
+	if err := os.WriteFile(configPath, []byte(configContent), defaultFilePerms); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// configTemplate renders the initial config file contents for a format.
+func configTemplate(format, password, dbPath string) string {
+	if format != DefaultConfigFormat {
+		return fmt.Sprintf(`shed-db:
+  password: %q
+  location: %q
+
+settings:
+  # Add other configuration settings here
+
+  # Prefix marking a secret reference inside {{...}} (default "!")
+  # secret-prefix: "!"
+
+  # Drop a dangling trailing ; or | when saving commands (default false)
+  # trim-trailing-separator: true
+`, password, dbPath)
+	}
+
+	return fmt.Sprintf(`[shed-db]
 password = "%s"
 location = "%s"
 
 [settings]
 # Add other configuration settings here
-`, password, dbPathNormalized)
 
-	if err := os.WriteFile(configPath, []byte(configContent), defaultFilePerms); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
+# Prefix marking a secret reference inside {{...}} (default "!")
+# secret-prefix = "!"
 
-	return nil
+# Drop a dangling trailing ; or | when saving commands (default false)
+# trim-trailing-separator = true
+`, password, dbPath)
 }
 
 // createEmptyFile creates an empty file at the specified path.
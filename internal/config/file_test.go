@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+
+	"github.com/h3jfc/shed/lib/sqlite3"
 )
 
 func TestCreateConfigFile(t *testing.T) {
@@ -38,7 +40,7 @@ func TestCreateConfigFile(t *testing.T) {
 
 			tmpDir := t.TempDir()
 
-			err := createConfigFile(tmpDir, tt.password)
+			err := createConfigFile(tmpDir, tt.password, DefaultConfigFormat)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("createConfigFile() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -52,6 +54,25 @@ func TestCreateConfigFile(t *testing.T) {
 	}
 }
 
+func TestCreateConfigFile_YAML(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	writeDatabaseFile(t, tmpDir)
+
+	if err := createConfigFile(tmpDir, "test_password_123", "yaml"); err != nil {
+		t.Fatalf("createConfigFile() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "config.yaml")); err != nil {
+		t.Fatalf("config.yaml was not created: %v", err)
+	}
+
+	if !validatePath(tmpDir) {
+		t.Error("validatePath returned false for a generated YAML config")
+	}
+}
+
 func verifyConfigFile(t *testing.T, tmpDir, password string) {
 	t.Helper()
 
@@ -210,7 +231,7 @@ func getValidatePathTests() []struct {
 					t.Fatalf("failed to create db file: %v", err)
 				}
 
-				if err := createConfigFile(tmpDir, ""); err != nil {
+				if err := createConfigFile(tmpDir, "", DefaultConfigFormat); err != nil {
 					t.Fatalf("failed to create config file: %v", err)
 				}
 
@@ -309,7 +330,7 @@ func setupValidShedDir(t *testing.T) string {
 		t.Fatalf("failed to create db file: %v", err)
 	}
 
-	if err := createConfigFile(tmpDir, "test_password"); err != nil {
+	if err := createConfigFile(tmpDir, "test_password", DefaultConfigFormat); err != nil {
 		t.Fatalf("failed to create config file: %v", err)
 	}
 
@@ -322,7 +343,7 @@ func setupDirWithConfig(t *testing.T, password string) string {
 
 	tmpDir := t.TempDir()
 
-	if err := createConfigFile(tmpDir, password); err != nil {
+	if err := createConfigFile(tmpDir, password, DefaultConfigFormat); err != nil {
 		t.Fatalf("failed to create config file: %v", err)
 	}
 
@@ -377,3 +398,84 @@ func findSubstring(s, substr string) bool {
 
 	return false
 }
+
+//nolint:paralleltest
+func TestInitShedDirectory_Existing(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, defaultDBName)
+
+	if err := createConfigFile(dir, "test_password", DefaultConfigFormat); err != nil {
+		t.Fatalf("Expected createConfigFile() to succeed, got error: %v", err)
+	}
+
+	// An encrypted database from before any migrations ran
+	conn, err := sqlite3.DB(dbPath, "test_password")
+	if err != nil {
+		t.Fatalf("Expected sqlite3.DB() to succeed, got error: %v", err)
+	}
+
+	if _, err := conn.Exec("CREATE TABLE placeholder (id INTEGER)"); err != nil {
+		t.Fatalf("Expected creating a table to succeed, got error: %v", err)
+	}
+
+	conn.Close()
+
+	passwordPrompt = func() (string, error) {
+		t.Fatal("Expected InitShedDirectory() not to prompt for a password")
+
+		return "", nil
+	}
+
+	t.Cleanup(func() { passwordPrompt = promptForPassword })
+
+	existing, err := InitShedDirectory(dir, DefaultConfigFormat)
+	if err != nil {
+		t.Fatalf("Expected InitShedDirectory() to succeed, got error: %v", err)
+	}
+
+	if !existing {
+		t.Error("Expected InitShedDirectory() to report an existing directory")
+	}
+
+	conn, err = sqlite3.DB(dbPath, "test_password")
+	if err != nil {
+		t.Fatalf("Expected sqlite3.DB() to succeed, got error: %v", err)
+	}
+	defer conn.Close()
+
+	var name string
+	if err := conn.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'commands'").Scan(&name); err != nil {
+		t.Errorf("Expected InitShedDirectory() to migrate the database, got error: %v", err)
+	}
+}
+
+//nolint:paralleltest
+func TestInitShedDirectory_Empty(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shed")
+
+	prompted := false
+	passwordPrompt = func() (string, error) {
+		prompted = true
+
+		return "test_password", nil
+	}
+
+	t.Cleanup(func() { passwordPrompt = promptForPassword })
+
+	existing, err := InitShedDirectory(dir, DefaultConfigFormat)
+	if err != nil {
+		t.Fatalf("Expected InitShedDirectory() to succeed, got error: %v", err)
+	}
+
+	if existing {
+		t.Error("Expected InitShedDirectory() to report a new directory")
+	}
+
+	if !prompted {
+		t.Error("Expected InitShedDirectory() to prompt for a password")
+	}
+
+	if !IsShedDir(dir) {
+		t.Errorf("Expected %s to be a valid shed directory", dir)
+	}
+}
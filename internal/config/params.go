@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+const paramsDirName = "params"
+
+var ErrInvalidParamsFile = errors.New("invalid default params file")
+
+// ParamsFilePath returns where the default value params for a command live:
+// <shed-dir>/params/<name>.json.
+func ParamsFilePath(shedDir, name string) string {
+	return filepath.Join(shedDir, paramsDirName, name+".json")
+}
+
+// LoadDefaultParams reads the default value params for a command. A missing
+// file is not an error and returns no params.
+func LoadDefaultParams(shedDir, name string) (map[string]string, error) {
+	p := ParamsFilePath(shedDir, name)
+
+	bb, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read params file %s: %w", p, err)
+	}
+
+	var params map[string]string
+	if err := json.Unmarshal(bb, &params); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidParamsFile, p, err)
+	}
+
+	return params, nil
+}
+
+// SaveDefaultParams writes values as the default value params for a command,
+// in the {"name":"value"} form LoadDefaultParams reads. Secrets are never
+// written; their values stay in the store.
+func SaveDefaultParams(shedDir, name string, values brackets.ValuedParameters) error {
+	values = slices.DeleteFunc(slices.Clone(values), func(v brackets.ValuedParameter) bool {
+		return strings.HasPrefix(v.Name, brackets.SecretPrefix())
+	})
+
+	bb, err := brackets.MarshalValuedParametersObject(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(shedDir, paramsDirName), 0o700); err != nil {
+		return fmt.Errorf("failed to create params directory: %w", err)
+	}
+
+	p := ParamsFilePath(shedDir, name)
+	if err := os.WriteFile(p, bb, 0o600); err != nil {
+		return fmt.Errorf("failed to write params file %s: %w", p, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+func TestParamsFilePath(t *testing.T) {
+	t.Parallel()
+
+	want := filepath.Join("shed", "params", "deploy.json")
+	if got := ParamsFilePath("shed", "deploy"); got != want {
+		t.Errorf("Expected ParamsFilePath() to return %q, but got %q", want, got)
+	}
+}
+
+func TestLoadDefaultParams_FileFound(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	writeParamsFile(t, tmpDir, "deploy", `{"environment":"staging","version":"1.0.0"}`)
+
+	params, err := LoadDefaultParams(tmpDir, "deploy")
+	if err != nil {
+		t.Fatalf("Expected LoadDefaultParams() to succeed, but got error: %v", err)
+	}
+
+	want := map[string]string{"environment": "staging", "version": "1.0.0"}
+	if !maps.Equal(params, want) {
+		t.Errorf("Expected LoadDefaultParams() to return %v, but got %v", want, params)
+	}
+}
+
+func TestLoadDefaultParams_NoFile(t *testing.T) {
+	t.Parallel()
+
+	params, err := LoadDefaultParams(t.TempDir(), "deploy")
+	if err != nil {
+		t.Fatalf("Expected LoadDefaultParams() to succeed, but got error: %v", err)
+	}
+
+	if len(params) != 0 {
+		t.Errorf("Expected LoadDefaultParams() to return no params, but got %v", params)
+	}
+}
+
+func TestLoadDefaultParams_InvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	writeParamsFile(t, tmpDir, "deploy", `{"environment":`)
+
+	_, err := LoadDefaultParams(tmpDir, "deploy")
+	if !errors.Is(err, ErrInvalidParamsFile) {
+		t.Errorf("Expected LoadDefaultParams() to return ErrInvalidParamsFile, but got: %v", err)
+	}
+}
+
+func writeParamsFile(t *testing.T, shedDir, name, content string) {
+	t.Helper()
+
+	p := ParamsFilePath(shedDir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("Failed to create params directory: %v", err)
+	}
+
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create params file: %v", err)
+	}
+}
+
+func TestSaveDefaultParams(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	values := brackets.ValuedParameters{
+		{Name: "version", Value: "1.0.0"},
+		{Name: "environment", Value: "staging"},
+		{Name: "!token", Value: "s3cr3t"},
+	}
+
+	if err := SaveDefaultParams(tmpDir, "deploy", values); err != nil {
+		t.Fatalf("Expected SaveDefaultParams() to succeed, but got error: %v", err)
+	}
+
+	bb, err := os.ReadFile(ParamsFilePath(tmpDir, "deploy"))
+	if err != nil {
+		t.Fatalf("Expected the params file to exist, but got error: %v", err)
+	}
+
+	if want := `{"environment":"staging","version":"1.0.0"}`; string(bb) != want {
+		t.Errorf("Expected SaveDefaultParams() to write %s, but got %s", want, bb)
+	}
+
+	params, err := LoadDefaultParams(tmpDir, "deploy")
+	if err != nil {
+		t.Fatalf("Expected LoadDefaultParams() to succeed, but got error: %v", err)
+	}
+
+	want := map[string]string{"environment": "staging", "version": "1.0.0"}
+	if !maps.Equal(params, want) {
+		t.Errorf("Expected LoadDefaultParams() to return %v, but got %v", want, params)
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrUnknownProfile     = errors.New("unknown profile")
+	ErrInvalidProfileName = errors.New("invalid profile name")
+	ErrProfileExists      = errors.New("profile already exists")
+)
+
+const (
+	// ProfilePrefix selects a profile instead of a path in --shed-dir, as in
+	// --shed-dir profile:work.
+	ProfilePrefix = "profile:"
+
+	profilesDirName = "profiles"
+)
+
+// ProfileDir returns the directory of the named profile, kept under profiles/
+// in baseDir. The directory must already exist.
+func ProfileDir(baseDir, name string) (string, error) {
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+
+	if baseDir == "" {
+		return "", fmt.Errorf("%w: %s, no shed directory found", ErrUnknownProfile, name)
+	}
+
+	p := filepath.Join(baseDir, profilesDirName, name)
+
+	info, err := os.Stat(p)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: %s, expected a directory at %s", ErrUnknownProfile, name, p)
+	}
+
+	return p, nil
+}
+
+// ResolveShedDir returns the shed directory named by a --shed-dir value. A
+// value starting with ProfilePrefix is resolved to that profile's directory
+// under the default shed directory; anything else is a path and is returned
+// as is.
+func ResolveShedDir(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, ProfilePrefix)
+	if !ok {
+		return value, nil
+	}
+
+	return ProfileDir(findDefaultDir(), name)
+}
+
+// Profile is a profile's directory, and the location and password of its
+// database.
+type Profile struct {
+	Dir      string
+	DBPath   string
+	Password string
+}
+
+// OpenProfile returns the named existing profile under the default shed
+// directory. See openProfile.
+func OpenProfile(name string) (*Profile, error) {
+	return openProfile(findDefaultDir(), name)
+}
+
+// openProfile returns the named profile under baseDir, with the location and
+// password of its database read from its config file.
+func openProfile(baseDir, name string) (*Profile, error) {
+	dir, err := ProfileDir(baseDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPath, password, err := readDBConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Profile{Dir: dir, DBPath: dbPath, Password: password}, nil
+}
+
+// CreateProfile creates the named profile under the default shed directory. See
+// createProfile.
+func CreateProfile(name, format string) (*Profile, error) {
+	return createProfile(findDefaultDir(), name, format)
+}
+
+// createProfile creates the directory of the named profile under baseDir, with
+// a config file in format pointing at a database in it, and prompts for that
+// database's password. The database itself is left for the caller to create.
+// The profile must not exist yet.
+func createProfile(baseDir, name, format string) (*Profile, error) {
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+
+	if baseDir == "" {
+		return nil, fmt.Errorf("%w: %s, no shed directory found", ErrUnknownProfile, name)
+	}
+
+	dir := filepath.Join(baseDir, profilesDirName, name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("%w: %s, at %s", ErrProfileExists, name, dir)
+	}
+
+	password, err := passwordPrompt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, defaultDirPerms); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	if err := createConfigFile(dir, password, format); err != nil {
+		os.RemoveAll(dir)
+
+		return nil, fmt.Errorf("failed to create config file: %w", err)
+	}
+
+	return &Profile{Dir: dir, DBPath: filepath.Join(dir, defaultDBName), Password: password}, nil
+}
+
+func validateProfileName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidProfileName, name)
+	}
+
+	return nil
+}
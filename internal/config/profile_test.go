@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveShedDir_Path(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "shed")
+
+	got, err := ResolveShedDir(p)
+	if err != nil {
+		t.Fatalf("Expected ResolveShedDir() to succeed, but got error: %v", err)
+	}
+
+	if got != p {
+		t.Errorf("Expected ResolveShedDir() to return %q, but got %q", p, got)
+	}
+}
+
+func TestProfileDir_OK(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	want := filepath.Join(base, "profiles", "work")
+
+	if err := os.MkdirAll(want, 0o755); err != nil {
+		t.Fatalf("Failed to create profile directory: %v", err)
+	}
+
+	got, err := ProfileDir(base, "work")
+	if err != nil {
+		t.Fatalf("Expected ProfileDir() to succeed, but got error: %v", err)
+	}
+
+	if got != want {
+		t.Errorf("Expected ProfileDir() to return %q, but got %q", want, got)
+	}
+}
+
+func TestProfileDir_Err(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(base, "profiles"), 0o755); err != nil {
+		t.Fatalf("Failed to create profiles directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(base, "profiles", "file"), nil, 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := map[string]struct {
+		base string
+		name string
+		want error
+	}{
+		"unknown profile": {base: base, name: "work", want: ErrUnknownProfile},
+		"not a directory": {base: base, name: "file", want: ErrUnknownProfile},
+		"no shed dir":     {base: "", name: "work", want: ErrUnknownProfile},
+		"empty name":      {base: base, name: "", want: ErrInvalidProfileName},
+		"parent dir":      {base: base, name: "..", want: ErrInvalidProfileName},
+		"nested path":     {base: base, name: "a/b", want: ErrInvalidProfileName},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ProfileDir(tt.base, tt.name); !errors.Is(err, tt.want) {
+				t.Errorf("Expected ProfileDir() to return %v, but got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestCreateProfile_OK(t *testing.T) {
+	passwordPrompt = func() (string, error) { return "profile_password", nil }
+
+	t.Cleanup(func() { passwordPrompt = promptForPassword })
+
+	base := t.TempDir()
+
+	p, err := createProfile(base, "work", DefaultConfigFormat)
+	if err != nil {
+		t.Fatalf("Expected createProfile() to succeed, but got error: %v", err)
+	}
+
+	wantDir := filepath.Join(base, "profiles", "work")
+	if p.Dir != wantDir || p.DBPath != filepath.Join(wantDir, "shed.db") || p.Password != "profile_password" {
+		t.Errorf("Expected profile in %q with its password, but got %+v", wantDir, p)
+	}
+
+	bb, err := os.ReadFile(filepath.Join(wantDir, "config.toml"))
+	if err != nil {
+		t.Fatalf("Expected a config file, but got error: %v", err)
+	}
+
+	if !strings.Contains(string(bb), `password = "profile_password"`) {
+		t.Errorf("Expected the config file to hold the password, but got:\n%s", bb)
+	}
+
+	if got, err := ProfileDir(base, "work"); err != nil || got != wantDir {
+		t.Errorf("Expected ProfileDir() to find the new profile, but got %q, %v", got, err)
+	}
+}
+
+//nolint:paralleltest
+func TestCreateProfile_Err(t *testing.T) {
+	passwordPrompt = func() (string, error) { return "profile_password", nil }
+
+	t.Cleanup(func() { passwordPrompt = promptForPassword })
+
+	base := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(base, "profiles", "work"), 0o755); err != nil {
+		t.Fatalf("Failed to create profile directory: %v", err)
+	}
+
+	tests := map[string]struct {
+		base   string
+		name   string
+		format string
+		want   error
+	}{
+		"existing profile": {base: base, name: "work", format: DefaultConfigFormat, want: ErrProfileExists},
+		"no shed dir":      {base: "", name: "home", format: DefaultConfigFormat, want: ErrUnknownProfile},
+		"nested path":      {base: base, name: "a/b", format: DefaultConfigFormat, want: ErrInvalidProfileName},
+		"bad format":       {base: base, name: "home", format: "ini", want: ErrInvalidFormat},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := createProfile(tt.base, tt.name, tt.format); !errors.Is(err, tt.want) {
+				t.Errorf("Expected createProfile() to return %v, but got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestOpenProfile_OK(t *testing.T) {
+	passwordPrompt = func() (string, error) { return "profile_password", nil }
+
+	t.Cleanup(func() { passwordPrompt = promptForPassword })
+
+	base := t.TempDir()
+
+	want, err := createProfile(base, "work", DefaultConfigFormat)
+	if err != nil {
+		t.Fatalf("Expected createProfile() to succeed, but got error: %v", err)
+	}
+
+	got, err := openProfile(base, "work")
+	if err != nil {
+		t.Fatalf("Expected openProfile() to succeed, but got error: %v", err)
+	}
+
+	if *got != *want {
+		t.Errorf("Expected openProfile() to return %+v, but got %+v", want, got)
+	}
+}
+
+func TestOpenProfile_Err(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(base, "profiles", "empty"), 0o755); err != nil {
+		t.Fatalf("Failed to create profile directory: %v", err)
+	}
+
+	tests := map[string]struct {
+		name string
+		want error
+	}{
+		"unknown profile": {name: "work", want: ErrUnknownProfile},
+		"no config file":  {name: "empty", want: ErrConfigInvalid},
+		"invalid name":    {name: "..", want: ErrInvalidProfileName},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := openProfile(base, tt.name); !errors.Is(err, tt.want) {
+				t.Errorf("Expected error %v, but got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h3jfc/shed/internal/logger"
+)
+
+const runsDirName = "runs"
+
+var (
+	ErrRunNotFound      = errors.New("run not found")
+	ErrInvalidRunRecord = errors.New("invalid run record")
+)
+
+// RunRecord describes a command started in the background by shed run --async.
+type RunRecord struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	PID       int       `json:"pid"`
+	Log       string    `json:"log"`
+	Exit      string    `json:"exit,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// RunsDir returns where background runs keep their records and logs:
+// <shed-dir>/runs.
+func RunsDir(shedDir string) string {
+	return filepath.Join(shedDir, runsDirName)
+}
+
+// NewRunRecord returns a record for a new background run of the named command,
+// with a fresh ID and its log and exit code files under RunsDir. The runs directory is created
+// if needed.
+func NewRunRecord(shedDir, name string) (RunRecord, error) {
+	if err := os.MkdirAll(RunsDir(shedDir), 0o700); err != nil {
+		return RunRecord{}, fmt.Errorf("failed to create runs directory: %w", err)
+	}
+
+	now := time.Now()
+	id := strconv.FormatInt(now.UnixNano(), 36)
+
+	return RunRecord{
+		ID:        id,
+		Name:      name,
+		Log:       filepath.Join(RunsDir(shedDir), id+".log"),
+		Exit:      filepath.Join(RunsDir(shedDir), id+".exit"),
+		StartedAt: now,
+	}, nil
+}
+
+// ExitCode returns the exit code recorded for the run once its command has
+// finished. ok is false while none is recorded: the command is still running,
+// was killed before it could record one, or ran where exit codes are not
+// recorded.
+func (r RunRecord) ExitCode() (code int, ok bool, err error) {
+	if r.Exit == "" {
+		return 0, false, nil
+	}
+
+	bb, err := os.ReadFile(r.Exit)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read exit code of run %s: %w", r.ID, err)
+	}
+
+	code, err = strconv.Atoi(strings.TrimSpace(string(bb)))
+	if err != nil {
+		return 0, false, fmt.Errorf("%w %s: bad exit code: %w", ErrInvalidRunRecord, r.Exit, err)
+	}
+
+	return code, true, nil
+}
+
+// SaveRunRecord writes r next to its log as <id>.json, through a temporary
+// file so a reader never sees a partial write.
+func SaveRunRecord(shedDir string, r RunRecord) error {
+	bb, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run record: %w", err)
+	}
+
+	p := filepath.Join(RunsDir(shedDir), r.ID+".json")
+	tmp := p + ".tmp"
+
+	if err := os.WriteFile(tmp, bb, 0o600); err != nil {
+		return fmt.Errorf("failed to write run record %s: %w", p, err)
+	}
+
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write run record %s: %w", p, err)
+	}
+
+	return nil
+}
+
+// GetRunRecord reads the record of the run with the given ID.
+func GetRunRecord(shedDir, id string) (*RunRecord, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("%w: %q", ErrRunNotFound, id)
+	}
+
+	p := filepath.Join(RunsDir(shedDir), id+".json")
+
+	bb, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrRunNotFound, id)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run record %s: %w", p, err)
+	}
+
+	var r RunRecord
+	if err := json.Unmarshal(bb, &r); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidRunRecord, p, err)
+	}
+
+	return &r, nil
+}
+
+// ListRunRecords returns every recorded background run, most recent first. A
+// missing runs directory means no runs, and records that cannot be parsed are
+// skipped with a warning.
+func ListRunRecords(shedDir string) ([]RunRecord, error) {
+	entries, err := os.ReadDir(RunsDir(shedDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs directory: %w", err)
+	}
+
+	var records []RunRecord
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		r, err := GetRunRecord(shedDir, id)
+		if errors.Is(err, ErrInvalidRunRecord) {
+			logger.Warn("Skipping invalid run record", "id", id, "error", err)
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, *r)
+	}
+
+	slices.SortFunc(records, func(a, b RunRecord) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+
+	return records, nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunRecords_SaveAndList(t *testing.T) {
+	t.Parallel()
+
+	shedDir := t.TempDir()
+
+	first, err := NewRunRecord(shedDir, "build")
+	if err != nil {
+		t.Fatalf("Expected NewRunRecord() to succeed, but got error: %v", err)
+	}
+
+	first.PID = 41
+
+	second, err := NewRunRecord(shedDir, "deploy")
+	if err != nil {
+		t.Fatalf("Expected NewRunRecord() to succeed, but got error: %v", err)
+	}
+
+	second.PID = 42
+	second.StartedAt = first.StartedAt.Add(time.Second)
+
+	for _, r := range []RunRecord{first, second} {
+		if err := SaveRunRecord(shedDir, r); err != nil {
+			t.Fatalf("Expected SaveRunRecord() to succeed, but got error: %v", err)
+		}
+	}
+
+	records, err := ListRunRecords(shedDir)
+	if err != nil {
+		t.Fatalf("Expected ListRunRecords() to succeed, but got error: %v", err)
+	}
+
+	if len(records) != 2 || records[0].ID != second.ID || records[1].ID != first.ID {
+		t.Fatalf("Expected ListRunRecords() to return newest first, but got %v", records)
+	}
+
+	if records[1].Log != first.Log || records[1].PID != 41 {
+		t.Errorf("Expected record %v, but got %v", first, records[1])
+	}
+}
+
+func TestListRunRecords_SkipsInvalid(t *testing.T) {
+	t.Parallel()
+
+	shedDir := t.TempDir()
+
+	r, err := NewRunRecord(shedDir, "build")
+	if err != nil {
+		t.Fatalf("Expected NewRunRecord() to succeed, but got error: %v", err)
+	}
+
+	if err := SaveRunRecord(shedDir, r); err != nil {
+		t.Fatalf("Expected SaveRunRecord() to succeed, but got error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(RunsDir(shedDir), "partial.json"), []byte(`{"id": "par`), 0o600); err != nil {
+		t.Fatalf("Failed to write invalid run record: %v", err)
+	}
+
+	records, err := ListRunRecords(shedDir)
+	if err != nil {
+		t.Fatalf("Expected ListRunRecords() to succeed, but got error: %v", err)
+	}
+
+	if len(records) != 1 || records[0].ID != r.ID {
+		t.Errorf("Expected only record %v, but got %v", r, records)
+	}
+
+	if _, err := GetRunRecord(shedDir, "partial"); !errors.Is(err, ErrInvalidRunRecord) {
+		t.Errorf("Expected GetRunRecord() to return %v, but got %v", ErrInvalidRunRecord, err)
+	}
+}
+
+func TestSaveRunRecord_NoTempFile(t *testing.T) {
+	t.Parallel()
+
+	shedDir := t.TempDir()
+
+	r, err := NewRunRecord(shedDir, "build")
+	if err != nil {
+		t.Fatalf("Expected NewRunRecord() to succeed, but got error: %v", err)
+	}
+
+	for range 2 {
+		if err := SaveRunRecord(shedDir, r); err != nil {
+			t.Fatalf("Expected SaveRunRecord() to succeed, but got error: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(RunsDir(shedDir))
+	if err != nil {
+		t.Fatalf("Failed to read runs directory: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != r.ID+".json" {
+		t.Errorf("Expected only %s.json in the runs directory, but got %v", r.ID, entries)
+	}
+}
+
+func TestListRunRecords_NoRuns(t *testing.T) {
+	t.Parallel()
+
+	records, err := ListRunRecords(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected ListRunRecords() to succeed, but got error: %v", err)
+	}
+
+	if len(records) != 0 {
+		t.Errorf("Expected no records, but got %v", records)
+	}
+}
+
+func TestGetRunRecord_ErrRunNotFound(t *testing.T) {
+	t.Parallel()
+
+	shedDir := t.TempDir()
+
+	for _, id := range []string{"missing", "", "../config"} {
+		if _, err := GetRunRecord(shedDir, id); !errors.Is(err, ErrRunNotFound) {
+			t.Errorf("Expected GetRunRecord(%q) to return %v, but got %v", id, ErrRunNotFound, err)
+		}
+	}
+}
+
+func TestRunRecord_ExitCode(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRunRecord(t.TempDir(), "build")
+	if err != nil {
+		t.Fatalf("Expected NewRunRecord() to succeed, but got error: %v", err)
+	}
+
+	if _, ok, err := r.ExitCode(); ok || err != nil {
+		t.Fatalf("Expected no exit code before the run finished, but got %v, %v", ok, err)
+	}
+
+	if err := os.WriteFile(r.Exit, []byte("2\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write exit file: %v", err)
+	}
+
+	code, ok, err := r.ExitCode()
+	if err != nil || !ok || code != 2 {
+		t.Fatalf("Expected exit code 2, but got %d, %v, %v", code, ok, err)
+	}
+
+	if err := os.WriteFile(r.Exit, []byte("nope"), 0o600); err != nil {
+		t.Fatalf("Failed to write exit file: %v", err)
+	}
+
+	if _, _, err := r.ExitCode(); !errors.Is(err, ErrInvalidRunRecord) {
+		t.Errorf("Expected %v, but got %v", ErrInvalidRunRecord, err)
+	}
+}
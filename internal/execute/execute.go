@@ -32,15 +32,40 @@
 //	err := execute.Run("cd /tmp && pwd")
 //	err := execute.Run("echo $HOME")
 //
+// Commands can also be run from a specific directory with extra environment
+// variables layered on top of the process environment:
+//
+//	err := execute.RunInDir("aws s3 ls", "/tmp", map[string]string{"AWS_PROFILE": "dev"})
+//
+// The output of a command can be captured instead of logged:
+//
+//	out, err := execute.RunWithResult("date +%s", "", nil)
+//
+// Output can be capped so a runaway command cannot flood the log or memory.
+// The command still runs to completion; output past the cap is dropped:
+//
+//	err := execute.RunWithLimit("yes", 1<<20)
+//
+// A command can be bounded in time with a context. When it is done the command
+// and everything it started are killed:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	err := execute.RunContext(ctx, "make test", "", nil, 0)
+//
 // The function blocks until the command completes. Stdout is logged at Info level,
 // stderr is logged at Error level.
 package execute
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/h3jfc/shed/internal/logger"
@@ -48,6 +73,9 @@ import (
 
 const (
 	numWaitGroups = 2
+
+	// TruncatedMarker ends the last line of output cut short by a byte limit.
+	TruncatedMarker = "...(truncated)"
 )
 
 // Run executes a command through the system shell and logs output.
@@ -67,12 +95,150 @@ const (
 //
 //	err := execute.Run("ls -la | grep '.go'")
 func Run(command string) error {
+	return RunInDir(command, "", nil)
+}
+
+// RunInDir executes a command like Run, from the given working directory and with
+// extraEnv merged over the process environment. Variables in extraEnv win over
+// process variables of the same name. An empty dir uses the current directory.
+//
+// Example:
+//
+//	err := execute.RunInDir("aws s3 ls", "", map[string]string{"AWS_PROFILE": "dev"})
+func RunInDir(command, dir string, extraEnv map[string]string) error {
+	return run(context.Background(), command, dir, extraEnv, 0, logger.Info)
+}
+
+// RunWithLimit executes a command like Run, logging at most maxBytes of stdout
+// and of stderr each. Output past the limit is dropped after a TruncatedMarker,
+// but the command still runs to completion. A maxBytes of 0 means no limit.
+//
+// Example:
+//
+//	err := execute.RunWithLimit("cat huge.log", 1<<20)
+func RunWithLimit(command string, maxBytes int64) error {
+	return RunInDirWithLimit(command, "", nil, maxBytes)
+}
+
+// RunInDirWithLimit combines RunInDir and RunWithLimit.
+func RunInDirWithLimit(command, dir string, extraEnv map[string]string, maxBytes int64) error {
+	return run(context.Background(), command, dir, extraEnv, maxBytes, logger.Info)
+}
+
+// RunContext is like RunInDirWithLimit but stops the command when ctx is done.
+// When ctx can be done the command runs in a process group of its own, which
+// is killed as a whole, so children of the shell stop too. The error then
+// wraps ctx.Err(), such as context.DeadlineExceeded.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	err := execute.RunContext(ctx, "make test", "", nil, 0)
+func RunContext(ctx context.Context, command, dir string, extraEnv map[string]string, maxBytes int64) error {
+	return run(ctx, command, dir, extraEnv, maxBytes, logger.Info)
+}
+
+// RunWithResult executes a command like RunInDir but returns its stdout instead
+// of logging it. Stderr is still logged at Error level. The output collected so
+// far is returned along with any error.
+//
+// Example:
+//
+//	token, err := execute.RunWithResult("gh auth token", "", nil)
+func RunWithResult(command, dir string, extraEnv map[string]string) (string, error) {
+	return RunWithResultLimit(command, dir, extraEnv, 0)
+}
+
+// RunWithResultLimit is like RunWithResult but keeps at most maxBytes of
+// stdout, ending truncated output with TruncatedMarker. A maxBytes of 0 means
+// no limit.
+func RunWithResultLimit(command, dir string, extraEnv map[string]string, maxBytes int64) (string, error) {
+	return RunWithResultContext(context.Background(), command, dir, extraEnv, maxBytes)
+}
+
+// RunWithResultContext is like RunWithResultLimit but stops the command when
+// ctx is done, as RunContext does.
+func RunWithResultContext(
+	ctx context.Context,
+	command, dir string,
+	extraEnv map[string]string,
+	maxBytes int64,
+) (string, error) {
+	var out strings.Builder
+
+	err := run(ctx, command, dir, extraEnv, maxBytes, func(line string, _ ...any) {
+		out.WriteString(line)
+		out.WriteString("\n")
+	})
+
+	return out.String(), err
+}
+
+// Start launches a command through the system shell like RunInDir but does not
+// wait for it. Stdout and stderr are appended to the file at logPath, which is
+// created if needed. The command is detached from shed: it runs in a session of
+// its own on Unix, or a detached process group on Windows, so closing the
+// terminal does not stop it. When the command exits, its exit code is written
+// to exitPath on Unix; on Windows no exit code is recorded. It returns the
+// process ID of the command, which outlives shed.
+//
+// Example:
+//
+//	pid, err := execute.Start("make release", "", nil, "/tmp/release.log", "/tmp/release.exit")
+func Start(command, dir string, extraEnv map[string]string, logPath, exitPath string) (int, error) {
+	// #nosec G304 -- logPath is chosen by the caller, not user input
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	// The child keeps its own handle to the file
+	defer f.Close()
+
+	cmd := detachedCommand(GetShellConfig(), command, exitPath)
+	cmd.Dir = dir
+	cmd.Stdout = f
+	cmd.Stderr = f
+
+	if len(extraEnv) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), extraEnv)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	pid := cmd.Process.Pid
+
+	if err := cmd.Process.Release(); err != nil {
+		return pid, fmt.Errorf("failed to release command: %w", err)
+	}
+
+	return pid, nil
+}
+
+// run executes a command through the system shell, passing each stdout line
+// to onStdout and logging stderr at Error level, each capped at maxBytes.
+func run(
+	ctx context.Context,
+	command, dir string,
+	extraEnv map[string]string,
+	maxBytes int64,
+	onStdout func(string, ...any),
+) error {
 	// Get shell configuration (cached after first call)
 	shellConfig := GetShellConfig()
 
 	// Create command with proper shell invocation
 	// #nosec G204 -- Command execution is the intended functionality of this package
-	cmd := exec.Command(shellConfig.Path, append(shellConfig.Args, command)...)
+	cmd := exec.CommandContext(ctx, shellConfig.Path, append(shellConfig.Args, command)...)
+	cmd.Dir = dir
+
+	killGroupOnCancel(ctx, cmd)
+
+	if len(extraEnv) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), extraEnv)
+	}
 
 	// Get pipes for stdout and stderr
 	stdout, err := cmd.StdoutPipe()
@@ -95,18 +261,18 @@ func Run(command string) error {
 
 	wg.Add(numWaitGroups)
 
-	// Stream stdout to logger.Info
+	// Stream stdout to onStdout
 	go func() {
 		defer wg.Done()
 
-		streamToLogger(stdout, logger.Info)
+		streamToLogger(stdout, limitLines(onStdout, maxBytes))
 	}()
 
 	// Stream stderr to logger.Error
 	go func() {
 		defer wg.Done()
 
-		streamToLogger(stderr, logger.Error)
+		streamToLogger(stderr, limitLines(logger.Error, maxBytes))
 	}()
 
 	// Wait for all output to be read
@@ -114,17 +280,91 @@ func Run(command string) error {
 
 	// Wait for the command to finish and check for errors
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("command failed: %w", err)
+		return waitError(ctx, err)
 	}
 
 	return nil
 }
 
+// waitError wraps the error from waiting on a command, adding the reason the
+// command was stopped when ctx is done.
+func waitError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("command stopped: %w: %w", ctxErr, err)
+	}
+
+	return fmt.Errorf("command failed: %w", err)
+}
+
+// mergeEnv overlays extra on top of base, a list of KEY=VALUE pairs.
+// Keys present in extra replace those in base.
+func mergeEnv(base []string, extra map[string]string) []string {
+	env := make([]string, 0, len(base)+len(extra))
+
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := extra[key]; overridden {
+			continue
+		}
+
+		env = append(env, kv)
+	}
+
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		env = append(env, k+"="+extra[k])
+	}
+
+	return env
+}
+
 // streamToLogger reads from an io.Reader line by line and logs each line
-// using the provided log function.
+// using the provided log function. Whatever the scanner cannot read, such as a
+// line too long for its buffer, is drained so the command never blocks on a
+// full pipe.
 func streamToLogger(reader io.Reader, logFunc func(string, ...any)) {
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		logFunc(scanner.Text())
 	}
+
+	_, _ = io.Copy(io.Discard, reader)
+}
+
+// limitLines wraps logFunc so that only the first maxBytes bytes of output,
+// counting a newline per line, reach it. The line crossing the limit is cut
+// and marked with TruncatedMarker, and later lines are dropped. A maxBytes of
+// 0 or less returns logFunc unchanged.
+func limitLines(logFunc func(string, ...any), maxBytes int64) func(string, ...any) {
+	if maxBytes <= 0 {
+		return logFunc
+	}
+
+	var (
+		written   int64
+		truncated bool
+	)
+
+	return func(line string, args ...any) {
+		if truncated {
+			return
+		}
+
+		n := int64(len(line)) + 1
+		if written+n <= maxBytes {
+			written += n
+			logFunc(line, args...)
+
+			return
+		}
+
+		truncated = true
+		logFunc(line[:max(maxBytes-written, 0)]+TruncatedMarker, args...)
+	}
 }
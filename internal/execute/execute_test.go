@@ -1,8 +1,15 @@
 package execute
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/h3jfc/shed/internal/logger"
 )
@@ -218,3 +225,379 @@ func TestRun_LongRunningCommand(t *testing.T) {
 		t.Errorf("Run() expected no error for long-running command, got: %v", err)
 	}
 }
+
+func TestRunInDir_ExtraEnv(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	var command string
+	if runtime.GOOS == windowsOS {
+		command = "if ($env:SHED_TEST_ENV -ne 'from-command' -or -not $env:PATH) { exit 1 }"
+	} else {
+		command = `test "$SHED_TEST_ENV" = "from-command" && test -n "$PATH"`
+	}
+
+	err := RunInDir(command, "", map[string]string{"SHED_TEST_ENV": "from-command"})
+	if err != nil {
+		t.Errorf("RunInDir() expected extra env and process env to be visible, got: %v", err)
+	}
+}
+
+func TestRunInDir_Dir(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	dir := t.TempDir()
+
+	var command string
+	if runtime.GOOS == windowsOS {
+		command = "New-Item -ItemType File -Name marker.txt | Out-Null"
+	} else {
+		command = "touch marker.txt"
+	}
+
+	if err := RunInDir(command, dir, nil); err != nil {
+		t.Fatalf("RunInDir() expected no error, got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "marker.txt")); err != nil {
+		t.Errorf("RunInDir() expected command to run in %s, got: %v", dir, err)
+	}
+}
+
+func TestRunWithResult_Success(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	var command string
+	if runtime.GOOS == windowsOS {
+		command = "Write-Output 'line1'; Write-Output 'line2'"
+	} else {
+		command = "echo 'line1' && echo 'line2'"
+	}
+
+	out, err := RunWithResult(command, "", nil)
+	if err != nil {
+		t.Fatalf("RunWithResult() expected no error, got: %v", err)
+	}
+
+	if out != "line1\nline2\n" {
+		t.Errorf("RunWithResult() expected output %q, got: %q", "line1\nline2\n", out)
+	}
+}
+
+func TestRunWithResult_CommandFailure(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	var command string
+	if runtime.GOOS == windowsOS {
+		command = "Write-Output 'partial'; exit 1"
+	} else {
+		command = "echo 'partial' && exit 1"
+	}
+
+	out, err := RunWithResult(command, "", nil)
+	if err == nil {
+		t.Fatal("RunWithResult() expected error for failing command, got nil")
+	}
+
+	if out != "partial\n" {
+		t.Errorf("RunWithResult() expected partial output %q, got: %q", "partial\n", out)
+	}
+}
+
+func TestRunWithResultLimit_Truncates(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	if runtime.GOOS == windowsOS {
+		t.Skip("uses a POSIX shell loop")
+	}
+
+	// Prints 1000 lines of 10 bytes, then a marker proving the command ran on.
+	command := "i=0; while [ $i -lt 1000 ]; do echo 123456789; i=$((i+1)); done; echo done > done.txt"
+	dir := t.TempDir()
+
+	out, err := RunWithResultLimit(command, dir, nil, 25)
+	if err != nil {
+		t.Fatalf("RunWithResultLimit() expected no error, got: %v", err)
+	}
+
+	want := "123456789\n123456789\n12345" + TruncatedMarker + "\n"
+	if out != want {
+		t.Errorf("RunWithResultLimit() expected output %q, got: %q", want, out)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "done.txt")); err != nil {
+		t.Errorf("RunWithResultLimit() expected command to run to completion, got: %v", err)
+	}
+}
+
+func TestRunContext_Timeout(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	if runtime.GOOS == windowsOS {
+		t.Skip("uses POSIX job control")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The background sleep holds stdout open, so only killing the whole
+	// process group lets the command finish.
+	start := time.Now()
+	err := RunContext(ctx, "sleep 30 & sleep 30", "", nil, 0)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunContext() expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("RunContext() expected command to be stopped promptly, took %v", elapsed)
+	}
+}
+
+func TestRunContext_NoDeadline(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	out, err := RunWithResultContext(context.Background(), "echo hello", "", nil, 0)
+	if err != nil {
+		t.Fatalf("RunWithResultContext() expected no error, got: %v", err)
+	}
+
+	if strings.TrimSpace(out) != "hello" {
+		t.Errorf("RunWithResultContext() expected output %q, got: %q", "hello", out)
+	}
+}
+
+func TestRunWithLimit_Success(t *testing.T) {
+	t.Parallel()
+
+	// Initialize logger for testing
+	logger.New(logger.ModeFromString("message-level"))
+
+	var command string
+	if runtime.GOOS == windowsOS {
+		command = "1..1000 | ForEach-Object { Write-Output 'line' }"
+	} else {
+		command = "i=0; while [ $i -lt 1000 ]; do echo line; i=$((i+1)); done"
+	}
+
+	if err := RunWithLimit(command, 16); err != nil {
+		t.Errorf("RunWithLimit() expected no error, got: %v", err)
+	}
+}
+
+func TestLimitLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		maxBytes int64
+		lines    []string
+		want     []string
+	}{
+		"no limit": {
+			maxBytes: 0,
+			lines:    []string{"one", "two"},
+			want:     []string{"one", "two"},
+		},
+		"under limit": {
+			maxBytes: 8,
+			lines:    []string{"one", "two"},
+			want:     []string{"one", "two"},
+		},
+		"cut mid line": {
+			maxBytes: 6,
+			lines:    []string{"one", "two", "three"},
+			want:     []string{"one", "tw" + TruncatedMarker},
+		},
+		"cut at line boundary": {
+			maxBytes: 4,
+			lines:    []string{"one", "two"},
+			want:     []string{"one", TruncatedMarker},
+		},
+		"single long line": {
+			maxBytes: 3,
+			lines:    []string{"abcdefgh"},
+			want:     []string{"abc" + TruncatedMarker},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+
+			logFunc := limitLines(func(line string, _ ...any) {
+				got = append(got, line)
+			}, tc.maxBytes)
+
+			for _, line := range tc.lines {
+				logFunc(line)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("limitLines() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	t.Parallel()
+
+	base := []string{"PATH=/usr/bin", "AWS_PROFILE=default", "HOME=/home/user"}
+	extra := map[string]string{"AWS_PROFILE": "dev", "REGION": "us-east-1"}
+
+	got := mergeEnv(base, extra)
+	want := []string{"PATH=/usr/bin", "HOME=/home/user", "AWS_PROFILE=dev", "REGION=us-east-1"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("mergeEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestStart_ReturnsPromptly(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == windowsOS {
+		t.Skip("uses a POSIX shell")
+	}
+
+	logPath := filepath.Join(t.TempDir(), "run.log")
+	exitPath := filepath.Join(t.TempDir(), "run.exit")
+
+	start := time.Now()
+
+	pid, err := Start("echo started; sleep 2; echo finished", "", nil, logPath, exitPath)
+	if err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Start() took %v, expected it not to wait for the command", elapsed)
+	}
+
+	if pid <= 0 {
+		t.Errorf("Start() pid = %d, want a positive process ID", pid)
+	}
+
+	if !ProcessRunning(pid) {
+		t.Errorf("ProcessRunning(%d) = false, want the started command to be running", pid)
+	}
+
+	if _, err := os.Stat(exitPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Start() exit file exists before the command finished: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		bb, _ := os.ReadFile(logPath)
+		if strings.Contains(string(bb), "started") {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Start() log = %q, want it to contain %q", bb, "started")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStart_RecordsExitCode(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == windowsOS {
+		t.Skip("exit codes are only recorded on Unix")
+	}
+
+	dir := t.TempDir()
+	exitPath := filepath.Join(dir, "run.exit")
+
+	if _, err := Start("exit 3", "", nil, filepath.Join(dir, "run.log"), exitPath); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		bb, err := os.ReadFile(exitPath)
+		if err == nil {
+			if got := string(bb); got != "3\n" {
+				t.Fatalf("Start() exit file = %q, want %q", got, "3\n")
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Start() did not write the exit file: %v", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcessRunning_InvalidPID(t *testing.T) {
+	t.Parallel()
+
+	if ProcessRunning(0) {
+		t.Error("ProcessRunning(0) = true, want false")
+	}
+}
+
+func TestSetShellArgs_PassedToShell(t *testing.T) { // nolint:paralleltest
+	if runtime.GOOS == windowsOS {
+		t.Skip("uses a POSIX shell script as the fake shell")
+	}
+
+	// The fake shell prints each argument it is invoked with on its own line
+	shell := filepath.Join(t.TempDir(), "fakeshell")
+	if err := os.WriteFile(shell, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\"\n"), 0o700); err != nil {
+		t.Fatalf("failed to write fake shell: %v", err)
+	}
+
+	SetShellConfig(ShellConfig{Name: "fakeshell", Path: shell, Args: []string{"-c"}})
+	t.Cleanup(ResetShellConfig)
+
+	if err := SetShellArgs([]string{"-l", "-c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := RunWithResult("echo hi", "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "-l\n-c\necho hi\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSetShellArgs_Empty(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(ResetShellConfig)
+
+	for _, args := range [][]string{nil, {}, {"-c", ""}} {
+		if err := SetShellArgs(args); !errors.Is(err, ErrEmptyShellArgs) {
+			t.Fatalf("expected %v for %q, got %v", ErrEmptyShellArgs, args, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package execute
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// killGroupOnCancel makes cancelling ctx kill the whole process group of cmd,
+// not only the shell, so commands it started cannot keep running or keep its
+// output open. Unless cmd starts a session of its own, it is put in a new
+// process group. A ctx that can never be done leaves cmd alone, in shed's
+// group, so Ctrl-C and terminal prompts reach it as usual.
+func killGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	// A new session is a new process group too
+	if !cmd.SysProcAttr.Setsid {
+		cmd.SysProcAttr.Setpgid = true
+	}
+
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// exitWrapper runs the command given as its remaining arguments, then writes
+// its exit code to the file named by its first, through a temporary file so a
+// reader never sees a partial write.
+const exitWrapper = `exit_path=$1; shift; "$@"; code=$?; ` +
+	`printf '%d\n' "$code" > "$exit_path.tmp" && mv "$exit_path.tmp" "$exit_path"; exit "$code"`
+
+// detachedCommand returns a command that runs command through shell in a new
+// session, with no controlling terminal, so the hangup sent when shed's
+// terminal closes never reaches it. A POSIX sh wraps the shell to record its
+// exit code at exitPath.
+func detachedCommand(shell ShellConfig, command, exitPath string) *exec.Cmd {
+	args := append([]string{"-c", exitWrapper, "shed-run", exitPath, shell.Path}, shell.Args...)
+	args = append(args, command)
+
+	// #nosec G204 -- Command execution is the intended functionality of this package
+	cmd := exec.Command("/bin/sh", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	return cmd
+}
+
+// ProcessRunning reports whether a command started by Start with the given
+// process ID is still running. The process must still lead the session Start
+// gave it, so a process ID reused by an unrelated process does not count.
+func ProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	sid, err := unix.Getsid(pid)
+
+	return err == nil && sid == pid
+}
@@ -0,0 +1,54 @@
+//go:build windows
+
+package execute
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// killGroupOnCancel leaves cmd to the default cancellation on Windows, which
+// kills the shell process.
+func killGroupOnCancel(context.Context, *exec.Cmd) {}
+
+// detachedProcess starts a console process without the console of its parent.
+const detachedProcess = 0x00000008
+
+// stillActive is the exit code Windows reports for a running process.
+const stillActive = 259
+
+// detachedCommand returns a command that runs command through shell detached
+// from shed's console and process group, so closing the console does not stop
+// it. Windows has no shell that can be relied on to wrap it, so exitPath is not
+// written.
+func detachedCommand(shell ShellConfig, command, _ string) *exec.Cmd {
+	// #nosec G204 -- Command execution is the intended functionality of this package
+	cmd := exec.Command(shell.Path, append(shell.Args, command)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+	}
+
+	return cmd
+}
+
+// ProcessRunning reports whether the process with the given ID is still
+// running.
+func ProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h) //nolint:errcheck
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+
+	return code == stillActive
+}
@@ -0,0 +1,67 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// interactiveWaitDelay bounds how long a stopped interactive command may keep
+// its output open, such as through a child the shell left running.
+const interactiveWaitDelay = time.Second
+
+// RunInteractive executes a command like RunInDir but connects it directly to
+// shed's stdin, stdout and stderr, so programs that read input, such as ssh or
+// a read prompt, work as they do in a shell. Output is not logged, so it can
+// be neither captured nor limited.
+//
+// Example:
+//
+//	err := execute.RunInteractive("read -r -p 'Name: ' name && echo hi $name", "", nil)
+func RunInteractive(command, dir string, extraEnv map[string]string) error {
+	return RunInteractiveContext(context.Background(), command, dir, extraEnv)
+}
+
+// RunInteractiveContext is like RunInteractive but stops the command when ctx
+// is done. Unlike RunContext, the command stays in shed's process group, where
+// it may read from the terminal, so only the shell is killed.
+func RunInteractiveContext(ctx context.Context, command, dir string, extraEnv map[string]string) error {
+	return runInteractive(ctx, command, dir, extraEnv, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// runInteractive runs command through the system shell with the given stdin,
+// stdout and stderr.
+func runInteractive(
+	ctx context.Context,
+	command, dir string,
+	extraEnv map[string]string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) error {
+	shellConfig := GetShellConfig()
+
+	// #nosec G204 -- Command execution is the intended functionality of this package
+	cmd := exec.CommandContext(ctx, shellConfig.Path, append(shellConfig.Args, command)...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = interactiveWaitDelay
+
+	if len(extraEnv) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), extraEnv)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return waitError(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+//go:build !windows
+
+package execute
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunInteractive_ReadsStdin(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+
+	err := runInteractive(context.Background(), "read -r line && echo \"got $line\" && echo done >&2", "", nil,
+		strings.NewReader("hello world\n"), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Expected runInteractive() to succeed, got error: %v", err)
+	}
+
+	if got := stdout.String(); got != "got hello world\n" {
+		t.Errorf("Expected runInteractive() stdout %q, got %q", "got hello world\n", got)
+	}
+
+	if got := stderr.String(); got != "done\n" {
+		t.Errorf("Expected runInteractive() stderr %q, got %q", "done\n", got)
+	}
+}
+
+func TestRunInteractive_Env(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+
+	err := runInteractive(context.Background(), "echo $SHED_INTERACTIVE_TEST", "",
+		map[string]string{"SHED_INTERACTIVE_TEST": "hello"}, strings.NewReader(""), &stdout, &stdout)
+	if err != nil {
+		t.Fatalf("Expected runInteractive() to succeed, got error: %v", err)
+	}
+
+	if got := strings.TrimSpace(stdout.String()); got != "hello" {
+		t.Errorf("Expected runInteractive() output %q, got %q", "hello", got)
+	}
+}
+
+func TestRunInteractive_Failure(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	if err := runInteractive(context.Background(), "exit 3", "", nil, strings.NewReader(""), &out, &out); err == nil {
+		t.Error("Expected runInteractive() to return an error for a failing command")
+	}
+}
+
+func TestRunInteractive_Timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+
+	start := time.Now()
+
+	// A stdin that never delivers a line
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	defer r.Close()
+	defer w.Close()
+
+	err = runInteractive(ctx, "read -r line", "", nil, r, &out, &out)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected runInteractive() to return context.DeadlineExceeded, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected runInteractive() to stop the command promptly, took %v", elapsed)
+	}
+}
@@ -0,0 +1,37 @@
+package execute
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+var ErrPTYUnsupported = errors.New("running commands in a pseudo-terminal is not supported on this platform")
+
+// RunPTY executes a command through the system shell like Run, but attached to
+// a pseudo-terminal, so programs that check for a terminal keep their colors,
+// progress bars, and interactive behavior. Output is written straight to stdout
+// rather than logged.
+//
+// When stdin is a terminal its size is passed on, it is put in raw mode for the
+// duration of the command, and keystrokes are forwarded to the command.
+//
+// It returns ErrPTYUnsupported on platforms other than Linux and macOS.
+//
+// Example:
+//
+//	err := execute.RunPTY("ls --color=auto")
+func RunPTY(command string) error {
+	return RunPTYInDir(command, "", nil)
+}
+
+// RunPTYInDir combines RunPTY and RunInDir.
+func RunPTYInDir(command, dir string, extraEnv map[string]string) error {
+	return RunPTYContext(context.Background(), command, dir, extraEnv)
+}
+
+// RunPTYContext is like RunPTYInDir but stops the command, and everything it
+// started, when ctx is done, as RunContext does.
+func RunPTYContext(ctx context.Context, command, dir string, extraEnv map[string]string) error {
+	return runPTY(ctx, command, dir, extraEnv, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,62 @@
+//go:build darwin
+
+package execute
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptyNameLen is the size of the buffer TIOCPTYGNAME fills, 128 bytes on macOS.
+const ptyNameLen = 128
+
+// openPTY opens a new pseudo-terminal and returns its master and slave ends.
+// It does what posix_openpt, grantpt, unlockpt and ptsname do in C.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+
+	fd := int(master.Fd())
+
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYGRANT, 0); err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to grant pseudo-terminal: %w", err)
+	}
+
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYUNLK, 0); err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to unlock pseudo-terminal: %w", err)
+	}
+
+	var name [ptyNameLen]byte
+
+	// #nosec G103 -- TIOCPTYGNAME writes the slave path into name
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL, uintptr(fd), uintptr(unix.TIOCPTYGNAME), uintptr(unsafe.Pointer(&name[0])),
+	)
+	if errno != 0 {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to get pseudo-terminal name: %w", errno)
+	}
+
+	slavePath, _, _ := bytes.Cut(name[:], []byte{0})
+
+	slave, err := os.OpenFile(string(slavePath), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to open %s: %w", slavePath, err)
+	}
+
+	return master, slave, nil
+}
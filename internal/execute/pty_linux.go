@@ -0,0 +1,46 @@
+//go:build linux
+
+package execute
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal and returns its master and slave ends.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+
+	fd := int(master.Fd())
+
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to unlock pseudo-terminal: %w", err)
+	}
+
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to get pseudo-terminal number: %w", err)
+	}
+
+	slavePath := "/dev/pts/" + strconv.FormatUint(uint64(n), 10)
+
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+
+		return nil, nil, fmt.Errorf("failed to open %s: %w", slavePath, err)
+	}
+
+	return master, slave, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package execute
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// runPTY is not available on this platform.
+func runPTY(_ context.Context, _, _ string, _ map[string]string, _ *os.File, _ io.Writer) error {
+	return ErrPTYUnsupported
+}
@@ -0,0 +1,92 @@
+//go:build linux || darwin
+
+package execute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// runPTY runs command with a new pseudo-terminal as its stdin, stdout, and
+// stderr, copying everything it prints to stdout. When stdin is a terminal it
+// is forwarded to the command in raw mode. A nil stdin forwards nothing.
+func runPTY(ctx context.Context, command, dir string, extraEnv map[string]string, stdin *os.File, stdout io.Writer) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	defer master.Close()
+
+	shellConfig := GetShellConfig()
+
+	// #nosec G204 -- Command execution is the intended functionality of this package
+	cmd := exec.CommandContext(ctx, shellConfig.Path, append(shellConfig.Args, command)...)
+	cmd.Dir = dir
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	// Make the pseudo-terminal the controlling terminal of a new session
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	killGroupOnCancel(ctx, cmd)
+
+	if len(extraEnv) > 0 {
+		cmd.Env = mergeEnv(os.Environ(), extraEnv)
+	}
+
+	if stdin != nil && term.IsTerminal(int(stdin.Fd())) {
+		restore := forwardTerminal(stdin, master)
+		defer restore()
+	}
+
+	err = cmd.Start()
+
+	// The child holds its own copy; ours must be closed for reads to end
+	slave.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	// Reading the master fails with EIO once nothing has the terminal open
+	if _, err := io.Copy(stdout, master); err != nil && !errors.Is(err, syscall.EIO) {
+		return fmt.Errorf("failed to read command output: %w", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return waitError(ctx, err)
+	}
+
+	return nil
+}
+
+// forwardTerminal copies the size of the stdin terminal to master, switches
+// stdin to raw mode, and forwards keystrokes. The returned func restores stdin.
+func forwardTerminal(stdin, master *os.File) func() {
+	fd := int(stdin.Fd())
+
+	if ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil {
+		_ = unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws)
+	}
+
+	go func() {
+		_, _ = io.Copy(master, stdin)
+	}()
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return func() {}
+	}
+
+	return func() {
+		_ = term.Restore(fd, state)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build linux || darwin
+
+package execute
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunPTY_IsATTY(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	err := runPTY(context.Background(), "[ -t 0 ] && [ -t 1 ] && [ -t 2 ] && echo tty || echo notty", "", nil, nil, &out)
+	if err != nil {
+		t.Fatalf("Expected runPTY() to succeed, got error: %v", err)
+	}
+
+	if got := strings.TrimSpace(out.String()); got != "tty" {
+		t.Errorf("Expected runPTY() output %q, got %q", "tty", got)
+	}
+}
+
+func TestRunPTY_Env(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	err := runPTY(context.Background(), "echo $SHED_PTY_TEST", "", map[string]string{"SHED_PTY_TEST": "hello"}, nil, &out)
+	if err != nil {
+		t.Fatalf("Expected runPTY() to succeed, got error: %v", err)
+	}
+
+	if got := strings.TrimSpace(out.String()); got != "hello" {
+		t.Errorf("Expected runPTY() output %q, got %q", "hello", got)
+	}
+}
+
+func TestRunPTY_Failure(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	if err := runPTY(context.Background(), "exit 3", "", nil, nil, &out); err == nil {
+		t.Error("Expected runPTY() to return an error for a failing command")
+	}
+}
@@ -1,6 +1,10 @@
 package execute
 
-import "sync"
+import (
+	"errors"
+	"slices"
+	"sync"
+)
 
 // ShellConfig represents the configuration for executing commands through a shell.
 // It contains the shell name, path, and arguments needed to execute commands.
@@ -17,20 +21,26 @@ type ShellConfig struct {
 	Args []string // Shell arguments for command execution (e.g., ["-c"] for bash)
 }
 
+var ErrEmptyShellArgs = errors.New("shell args must be non-empty")
+
 var (
 	cachedShell   ShellConfig
 	shellMutex    sync.RWMutex
 	shellDetected bool
+
+	// shellArgs, when set, replaces the Args of the detected or set shell.
+	shellArgs []string
 )
 
 // GetShellConfig returns the shell configuration for the current platform.
-// The shell is detected once and cached for subsequent calls.
+// The shell is detected once and cached for subsequent calls. Args set with
+// SetShellArgs replace the shell's own.
 func GetShellConfig() ShellConfig {
 	// Fast path: read lock for cached value
 	shellMutex.RLock()
 
 	if shellDetected {
-		config := cachedShell
+		config := withShellArgs(cachedShell)
 
 		shellMutex.RUnlock()
 
@@ -49,7 +59,33 @@ func GetShellConfig() ShellConfig {
 		shellDetected = true
 	}
 
-	return cachedShell
+	return withShellArgs(cachedShell)
+}
+
+// withShellArgs returns config with its Args replaced by shellArgs, when set.
+// The caller must hold shellMutex.
+func withShellArgs(config ShellConfig) ShellConfig {
+	if shellArgs != nil {
+		config.Args = slices.Clone(shellArgs)
+	}
+
+	return config
+}
+
+// SetShellArgs replaces the arguments every shell is invoked with, such as
+// ["-lc"] for a login shell. The command is appended after them. Neither the
+// list nor any argument may be empty.
+func SetShellArgs(args []string) error {
+	if len(args) == 0 || slices.Contains(args, "") {
+		return ErrEmptyShellArgs
+	}
+
+	shellMutex.Lock()
+	defer shellMutex.Unlock()
+
+	shellArgs = slices.Clone(args)
+
+	return nil
 }
 
 // SetShellConfig allows manual configuration of the shell.
@@ -62,8 +98,8 @@ func SetShellConfig(config ShellConfig) {
 	shellDetected = true
 }
 
-// ResetShellConfig clears the cached shell configuration,
-// forcing re-detection on the next GetShellConfig call.
+// ResetShellConfig clears the cached shell configuration and any args set
+// with SetShellArgs, forcing re-detection on the next GetShellConfig call.
 // This is primarily useful for testing.
 func ResetShellConfig() {
 	shellMutex.Lock()
@@ -71,6 +107,7 @@ func ResetShellConfig() {
 
 	cachedShell = ShellConfig{}
 	shellDetected = false
+	shellArgs = nil
 }
 
 // detectShellPlatform is implemented in platform-specific files:
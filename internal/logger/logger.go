@@ -20,6 +20,9 @@ const (
 	ModeVerbose      LogMode = "verbose"
 	ModeMessageLevel LogMode = "message-level"
 	ModeMessageOnly  LogMode = "message-only"
+	// ModeJSON writes each record as a JSON object on its own line, for tools
+	// that parse shed's output.
+	ModeJSON LogMode = "json"
 )
 
 // ANSI color codes.
@@ -46,14 +49,23 @@ type CustomHandler struct {
 	mode  LogMode
 	attrs []slog.Attr
 	group string
+	// json writes records in ModeJSON. It is built once and carries the
+	// attributes and groups added with WithAttrs and WithGroup.
+	json slog.Handler
 }
 
 // NewCustomHandler creates a new handler with the specified mode.
 func NewCustomHandler(w io.Writer, mode LogMode) *CustomHandler {
-	return &CustomHandler{
+	h := &CustomHandler{
 		w:    w,
 		mode: mode,
 	}
+
+	if mode == ModeJSON {
+		h.json = slog.NewJSONHandler(w, nil)
+	}
+
+	return h
 }
 
 func (h *CustomHandler) Enabled(_ context.Context, level slog.Level) bool {
@@ -65,7 +77,11 @@ func (h *CustomHandler) Enabled(_ context.Context, level slog.Level) bool {
 	return level >= slog.LevelInfo
 }
 
-func (h *CustomHandler) Handle(_ context.Context, r slog.Record) error {
+func (h *CustomHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.json != nil {
+		return h.json.Handle(ctx, r)
+	}
+
 	var output string
 
 	switch h.mode {
@@ -201,21 +217,33 @@ func (h *CustomHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	copy(newAttrs, h.attrs)
 	copy(newAttrs[len(h.attrs):], attrs)
 
-	return &CustomHandler{
+	nh := &CustomHandler{
 		w:     h.w,
 		mode:  h.mode,
 		attrs: newAttrs,
 		group: h.group,
 	}
+
+	if h.json != nil {
+		nh.json = h.json.WithAttrs(attrs)
+	}
+
+	return nh
 }
 
 func (h *CustomHandler) WithGroup(name string) slog.Handler {
-	return &CustomHandler{
+	nh := &CustomHandler{
 		w:     h.w,
 		mode:  h.mode,
 		attrs: h.attrs,
 		group: name,
 	}
+
+	if h.json != nil {
+		nh.json = h.json.WithGroup(name)
+	}
+
+	return nh
 }
 
 func ModeFromString(s string) LogMode {
@@ -226,12 +254,15 @@ func ModeFromString(s string) LogMode {
 		return ModeMessageLevel
 	case "message-only":
 		return ModeMessageOnly
+	case "json":
+		return ModeJSON
 	default:
 		return ModeMessageLevel
 	}
 }
 
-// SetWriter sets the output writer (must be called before Get).
+// SetWriter sets the output writer, replacing any added with AddWriter (must be
+// called before Get).
 func SetWriter(w io.Writer) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -239,6 +270,24 @@ func SetWriter(w io.Writer) {
 	writer = w
 }
 
+// AddWriter adds w as a further output writer alongside the current ones, so
+// every message is written to each (must be called before Get). Output is the
+// same for all writers, colors included.
+func AddWriter(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	writer = io.MultiWriter(writer, w)
+}
+
+// Mode returns the current logger mode.
+func Mode() LogMode {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return mode
+}
+
 // SetMode sets the logger mode (must be called before Get).
 func SetMode(m LogMode) {
 	mu.Lock()
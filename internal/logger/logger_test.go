@@ -3,6 +3,7 @@ package logger
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"log/slog"
 	"strings"
 	"sync"
@@ -35,6 +36,42 @@ func TestNew_WritesToConfiguredWriter(t *testing.T) { // nolint:paralleltest
 	}
 }
 
+func TestAddWriter_WritesToAllWriters(t *testing.T) { // nolint:paralleltest
+	Reset()
+
+	var first, second bytes.Buffer
+	SetWriter(&first)
+	AddWriter(&second)
+
+	logger := New(ModeMessageLevel)
+	logger.Info("test message")
+
+	for name, buf := range map[string]*bytes.Buffer{"first": &first, "second": &second} {
+		if !strings.Contains(buf.String(), "test message") {
+			t.Errorf("Expected %s writer to contain 'test message', got: %s", name, buf.String())
+		}
+	}
+}
+
+func TestSetWriter_ReplacesAddedWriters(t *testing.T) { // nolint:paralleltest
+	Reset()
+
+	var added, replacement bytes.Buffer
+	AddWriter(&added)
+	SetWriter(&replacement)
+
+	logger := New(ModeMessageLevel)
+	logger.Info("test message")
+
+	if added.Len() != 0 {
+		t.Errorf("Expected replaced writer to stay empty, got: %s", added.String())
+	}
+
+	if !strings.Contains(replacement.String(), "test message") {
+		t.Errorf("Expected output to contain 'test message', got: %s", replacement.String())
+	}
+}
+
 func TestModeVerbose_ShowsDebugMessages(t *testing.T) { // nolint:paralleltest
 	Reset()
 
@@ -174,6 +211,53 @@ func TestModeMessageOnly_ShowsOnlyMessage(t *testing.T) { // nolint:paralleltest
 	}
 }
 
+func TestModeJSON_WritesJSONObjects(t *testing.T) { // nolint:paralleltest
+	Reset()
+
+	var buf bytes.Buffer
+	SetWriter(&buf)
+
+	logger := New(ModeJSON)
+	logger.Error("run failed", "stage", "execute", "exit_code", 3)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("JSON mode should write a JSON object, got: %s (%v)", buf.String(), err)
+	}
+
+	if got["msg"] != "run failed" || got["level"] != "ERROR" {
+		t.Errorf("JSON mode should include msg and level, got: %s", buf.String())
+	}
+
+	if got["stage"] != "execute" || got["exit_code"] != float64(3) {
+		t.Errorf("JSON mode should include attributes, got: %s", buf.String())
+	}
+}
+
+func TestModeJSON_WithGroup(t *testing.T) { // nolint:paralleltest
+	Reset()
+
+	var buf bytes.Buffer
+	SetWriter(&buf)
+
+	logger := New(ModeJSON).With("name", "deploy").WithGroup("run")
+	logger.Error("run failed", "stage", "execute")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("JSON mode should write a JSON object, got: %s (%v)", buf.String(), err)
+	}
+
+	if got["name"] != "deploy" {
+		t.Errorf("JSON mode should keep attributes added before the group, got: %s", buf.String())
+	}
+
+	run, ok := got["run"].(map[string]any)
+	if !ok || run["stage"] != "execute" {
+		t.Errorf("JSON mode should nest grouped attributes, got: %s", buf.String())
+	}
+}
+
 func TestLoggerWithAttributes(t *testing.T) { // nolint:paralleltest
 	Reset()
 
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/h3jfc/shed/db"
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+var ErrAliasNotFound = errors.New("alias not found")
+
+// Alias is another name a command can be reached by.
+type Alias struct {
+	ID        int64
+	Alias     string
+	CommandID int64
+	CreatedAt string
+}
+
+// AddAlias makes the command named commandName reachable as alias as well. The
+// alias must be a valid name that no command or other alias already uses.
+func (s *Store) AddAlias(alias, commandName string) (*Alias, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateName(alias); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.CommandExists(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, fmt.Errorf("command with name %q already exists: %w", alias, ErrAlreadyExists)
+	}
+
+	cmd, err := s.GetCommandByName(commandName)
+	if err != nil {
+		return nil, err
+	}
+
+	var a db.Alias
+
+	err = s.withTx(func(tx *Store) error {
+		a, err = tx.queries.CreateAlias(context.Background(), db.CreateAliasParams{
+			Alias:     alias,
+			CommandID: cmd.ID,
+		})
+		if sqlite3.IsUniqueViolation(err) {
+			return fmt.Errorf("alias %q already exists: %w", alias, ErrAlreadyExists)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to create alias: %w", err)
+		}
+
+		return tx.audit(AuditOpAddAlias, alias, fmt.Sprintf("of %q", cmd.Name))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Alias{ID: a.ID, Alias: a.Alias, CommandID: a.CommandID, CreatedAt: a.CreatedAt}, nil
+}
+
+// RemoveAlias removes alias. The command it pointed to is left alone.
+func (s *Store) RemoveAlias(alias string) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *Store) error {
+		n, err := tx.queries.DeleteAlias(context.Background(), alias)
+		if err != nil {
+			return fmt.Errorf("failed to remove alias: %w", err)
+		}
+
+		if n == 0 {
+			return fmt.Errorf("%w: %q", ErrAliasNotFound, alias)
+		}
+
+		return tx.audit(AuditOpRemoveAlias, alias, "")
+	})
+}
+
+// ResolveName returns the name of the command reached by name, which is either
+// a command name or an alias.
+func (s *Store) ResolveName(name string) (string, error) {
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.Name, nil
+}
+
+// getCommandByAlias looks up the command an alias points to.
+func (s *Store) getCommandByAlias(alias string) (db.Command, error) {
+	return s.queries.GetCommandByAlias(context.Background(), alias)
+}
+
+// checkNotAlias returns ErrAlreadyExists when name is taken by an alias, so a
+// command cannot be created or renamed onto it.
+func (s *Store) checkNotAlias(name string) error {
+	_, err := s.queries.GetAlias(context.Background(), name)
+	if err == nil {
+		return fmt.Errorf("alias with name %q already exists: %w", name, ErrAlreadyExists)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check aliases: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddAlias_Resolve(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	added, err := s.AddCommand("kubectl_get", "kubectl get {{resource}}", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("k", "kubectl_get"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	cmd, err := s.GetCommandByName("k")
+	if err != nil {
+		t.Fatalf("expected alias to resolve, got %v", err)
+	}
+
+	if cmd.ID != added.ID {
+		t.Fatalf("expected command %v, got %v", added.ID, cmd.ID)
+	}
+
+	name, err := s.ResolveName("k")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if name != "kubectl_get" {
+		t.Fatalf("expected %v, got %v", "kubectl_get", name)
+	}
+}
+
+func TestAddAlias_CollidesWithCommand(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"deploy", "build"} {
+		if _, err := s.AddCommand(name, "echo "+name, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	if _, err := s.AddAlias("build", "deploy"); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected %v, got %v", ErrAlreadyExists, err)
+	}
+
+	if _, err := s.AddAlias("d", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if _, err := s.AddCommand("d", "echo d", ""); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected %v, got %v", ErrAlreadyExists, err)
+	}
+
+	if _, err := s.AddAlias("d", "build"); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected %v, got %v", ErrAlreadyExists, err)
+	}
+}
+
+func TestAddAlias_Invalid(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddAlias("k", "missing"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected %v, got %v", ErrCommandNotFound, err)
+	}
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("1bad", "deploy"); !errors.Is(err, ErrInvalidCommandName) {
+		t.Fatalf("expected %v, got %v", ErrInvalidCommandName, err)
+	}
+}
+
+func TestRemoveAlias(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("d", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if err := s.RemoveAlias("d"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := s.GetCommandByName("d"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected %v, got %v", ErrCommandNotFound, err)
+	}
+
+	if _, err := s.GetCommandByName("deploy"); err != nil {
+		t.Fatalf("expected command to remain, got %v", err)
+	}
+
+	if err := s.RemoveAlias("d"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("expected %v, got %v", ErrAliasNotFound, err)
+	}
+}
+
+func TestRemoveCommand_DropsAliases(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("d", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if _, err := s.RemoveCommand("deploy"); err != nil {
+		t.Fatalf("unexpected error removing command: %v", err)
+	}
+
+	if _, err := s.AddCommand("d", "echo d", ""); err != nil {
+		t.Fatalf("expected the alias to be gone with its command, got %v", err)
+	}
+}
+
+func TestRemoveCommand_ByAlias(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]func(s *Store, name string) (*Command, error){
+		"remove":      (*Store).RemoveCommand,
+		"soft delete": (*Store).SoftDeleteCommand,
+	}
+
+	for name, remove := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := prepNewStore(t)
+
+			if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+				t.Fatalf("unexpected error adding command: %v", err)
+			}
+
+			if _, err := s.AddAlias("d", "deploy"); err != nil {
+				t.Fatalf("unexpected error adding alias: %v", err)
+			}
+
+			removed, err := remove(s, "d")
+			if err != nil {
+				t.Fatalf("unexpected error removing command: %v", err)
+			}
+
+			if removed.Name != "deploy" {
+				t.Fatalf("expected %q to be removed, got %q", "deploy", removed.Name)
+			}
+
+			if exists, err := s.CommandExists("deploy"); err != nil || exists {
+				t.Fatalf("expected the command row to be deleted, got %v, %v", exists, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/h3jfc/shed/db"
+)
+
+// Audited operations, recorded as the Op of an AuditEntry.
+const (
+	AuditOpAddCommand     = "command.add"
+	AuditOpUpdateCommand  = "command.update"
+	AuditOpRemoveCommand  = "command.remove"
+	AuditOpRestoreCommand = "command.restore"
+	AuditOpAddSecret      = "secret.add"
+	AuditOpUpdateSecret   = "secret.update"
+	AuditOpRotateSecret   = "secret.rotate"
+	AuditOpRemoveSecret   = "secret.remove"
+	AuditOpAddAlias       = "alias.add"
+	AuditOpRemoveAlias    = "alias.remove"
+	AuditOpPurgeTrash     = "trash.purge"
+)
+
+// Details of entries written for commands and secrets copied from another
+// store.
+const (
+	auditDetailCloned   = "cloned"
+	auditDetailMovedIn  = "moved from another store"
+	auditDetailMovedOut = "moved to another store"
+)
+
+// AuditEntry is one recorded mutation. Target is the command name, secret key
+// or alias it applied to; secret values are never recorded.
+type AuditEntry = db.AuditLog
+
+// AuditLog returns the latest limit audit entries, oldest first. A limit of 0
+// or less returns every entry.
+func (s *Store) AuditLog(limit int) ([]AuditEntry, error) {
+	n := int64(limit)
+	if limit <= 0 {
+		// SQLite treats a negative LIMIT as no limit
+		n = -1
+	}
+
+	entries, err := s.queries.ListAuditLog(context.Background(), n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// audit appends an entry to the audit log. Call it on the store passed to a
+// withTx function, so the entry is written with the change it records.
+func (s *Store) audit(op, target, detail string) error {
+	err := s.queries.CreateAuditEntry(context.Background(), db.CreateAuditEntryParams{
+		Op:     op,
+		Target: target,
+		Detail: detail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog_RecordsMutationsInOrder(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	const secretValue = "hunter2-value"
+
+	if _, err := s.AddSecret("api_token", secretValue, "the api token"); err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	cmd, err := s.AddCommand("deploy", "deploy --token {{!api_token}}", "deploys")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.UpdateCommand(cmd.ID, "ship", cmd.Command, cmd.Description, cmd.Parameters, "{}"); err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	if _, err := s.UpdateSecret("api_token", secretValue+"-new", "the api token"); err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+
+	if _, err := s.RotateSecret("api_token", secretValue+"-rotated"); err != nil {
+		t.Fatalf("unexpected error rotating secret: %v", err)
+	}
+
+	if _, err := s.RemoveCommand("ship"); err != nil {
+		t.Fatalf("unexpected error removing command: %v", err)
+	}
+
+	if err := s.RemoveSecret("api_token"); err != nil {
+		t.Fatalf("unexpected error removing secret: %v", err)
+	}
+
+	entries, err := s.AuditLog(0)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	want := []AuditEntry{
+		{Op: AuditOpAddSecret, Target: "api_token"},
+		{Op: AuditOpAddCommand, Target: "deploy"},
+		{Op: AuditOpUpdateCommand, Target: "ship", Detail: `renamed from "deploy"`},
+		{Op: AuditOpUpdateSecret, Target: "api_token", Detail: "value"},
+		{Op: AuditOpRotateSecret, Target: "api_token"},
+		{Op: AuditOpRemoveCommand, Target: "ship"},
+		{Op: AuditOpRemoveSecret, Target: "api_token"},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("expected %v audit entries, got %v: %v", len(want), len(entries), entries)
+	}
+
+	for i, entry := range entries {
+		if entry.Op != want[i].Op || entry.Target != want[i].Target || entry.Detail != want[i].Detail {
+			t.Fatalf("expected entry %v to be %v, got %v", i, want[i], entry)
+		}
+
+		if entry.At == "" {
+			t.Fatalf("expected entry %v to have a timestamp", i)
+		}
+
+		if strings.Contains(entry.Target+entry.Detail, secretValue) {
+			t.Fatalf("expected no secret value in the audit log, got %v", entry)
+		}
+	}
+
+	// A limit keeps the latest entries, still oldest first
+	latest, err := s.AuditLog(2)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(latest) != 2 || latest[0].Op != AuditOpRemoveCommand || latest[1].Op != AuditOpRemoveSecret {
+		t.Fatalf("expected the last 2 entries, got %v", latest)
+	}
+}
+
+func TestAuditLog_SoftDeleteAndRestore(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.SoftDeleteCommand("greet"); err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	if _, err := s.RestoreLastDeleted(); err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	entries, err := s.AuditLog(2)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(entries) != 2 ||
+		entries[0].Op != AuditOpRemoveCommand || entries[0].Detail != "moved to trash" ||
+		entries[1].Op != AuditOpRestoreCommand {
+		t.Fatalf("expected a trash removal then a restore, got %v", entries)
+	}
+}
+
+func TestAuditLog_FailedMutationNotRecorded(t *testing.T) {
+	t.Parallel()
+	s := prepFileStore(t, prepDBFile(t))
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddCommand("greet", "echo again", ""); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected error %v, got %v", ErrAlreadyExists, err)
+	}
+
+	entries, err := s.AuditLog(0)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Op != AuditOpAddCommand {
+		t.Fatalf("expected only the successful add to be recorded, got %v", entries)
+	}
+}
+
+func TestAuditLog_AliasesAndPurge(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("d", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if err := s.RemoveAlias("d"); err != nil {
+		t.Fatalf("unexpected error removing alias: %v", err)
+	}
+
+	_, err := s.dbtx.ExecContext(context.Background(),
+		`INSERT INTO trash (name, command, description, created_at, deleted_at)
+		VALUES ('old', 'echo old', '', '2000-01-01 00:00:00', '2000-01-02 00:00:00')`)
+	if err != nil {
+		t.Fatalf("unexpected error seeding trash: %v", err)
+	}
+
+	if _, err := s.PurgeTrash(24 * time.Hour); err != nil {
+		t.Fatalf("unexpected error purging trash: %v", err)
+	}
+
+	// Nothing left to purge, so nothing is recorded
+	if _, err := s.PurgeTrash(24 * time.Hour); err != nil {
+		t.Fatalf("unexpected error purging trash: %v", err)
+	}
+
+	entries, err := s.AuditLog(3)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(entries) != 3 ||
+		entries[0].Op != AuditOpAddAlias || entries[0].Target != "d" || entries[0].Detail != `of "deploy"` ||
+		entries[1].Op != AuditOpRemoveAlias || entries[1].Target != "d" ||
+		entries[2].Op != AuditOpPurgeTrash {
+		t.Fatalf("expected alias add, alias remove and purge entries, got %v", entries)
+	}
+}
+
+func TestAuditLog_Move(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if err := MoveCommand(src, dst, "deploy", false); err != nil {
+		t.Fatalf("unexpected error moving command: %v", err)
+	}
+
+	for name, tt := range map[string]struct {
+		s    *Store
+		want AuditEntry
+	}{
+		"source":      {s: src, want: AuditEntry{Op: AuditOpRemoveCommand, Target: "deploy", Detail: auditDetailMovedOut}},
+		"destination": {s: dst, want: AuditEntry{Op: AuditOpAddCommand, Target: "deploy", Detail: auditDetailMovedIn}},
+	} {
+		entries, err := tt.s.AuditLog(1)
+		if err != nil {
+			t.Fatalf("unexpected error reading %s audit log: %v", name, err)
+		}
+
+		if len(entries) != 1 || entries[0].Op != tt.want.Op ||
+			entries[0].Target != tt.want.Target || entries[0].Detail != tt.want.Detail {
+			t.Fatalf("expected %s entry %v, got %v", name, tt.want, entries)
+		}
+	}
+}
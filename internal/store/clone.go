@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/h3jfc/shed/db"
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+var ErrCloneDestExists = errors.New("clone destination already exists")
+
+// CloneTo copies every command, with its parameters and environment, and every
+// secret into a new database at destPath encrypted with destPassword. Rows are
+// read from s and written through a connection of their own, so the copy is
+// encrypted with the new password rather than being a copy of the file.
+// Aliases, the trash and the audit log are not copied; the audit log of the
+// clone starts with an entry for each copied command and secret. destPath must
+// not exist, and is removed again when the clone fails.
+func (s *Store) CloneTo(destPath, destPassword string) error {
+	if destPassword == "" {
+		return ErrDBPasswordUnset
+	}
+
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("%w: %s", ErrCloneDestExists, destPath)
+	}
+
+	cmds, err := s.ListCommands()
+	if err != nil {
+		return err
+	}
+
+	secrets, err := s.ListSecrets()
+	if err != nil {
+		return err
+	}
+
+	if err := cloneInto(destPath, destPassword, cmds, secrets); err != nil {
+		removeDBFiles(destPath)
+
+		return err
+	}
+
+	return nil
+}
+
+// cloneInto creates and migrates the database at dbPath and writes cmds and
+// secrets to it in one transaction.
+func cloneInto(dbPath, password string, cmds []Command, secrets []Secret) error {
+	if err := sqlite3.MigrateShedDB(dbPath, password); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+
+	conn, err := sqlite3.DB(dbPath, password)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	dst := NewStore(conn)
+
+	err = dst.withTx(func(tx *Store) error {
+		for _, c := range cmds {
+			if err := tx.cloneCommand(c); err != nil {
+				return err
+			}
+		}
+
+		for _, secret := range secrets {
+			_, err := tx.queries.CreateSecret(context.Background(), db.CreateSecretParams{
+				Key:         secret.Key,
+				Value:       secret.Value,
+				Description: secret.Description,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to copy secret %q: %w", secret.Key, err)
+			}
+
+			if err := tx.audit(AuditOpAddSecret, secret.Key, auditDetailCloned); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return errors.Join(err, dst.Close())
+}
+
+// cloneCommand writes c, with its environment, as a new command.
+func (s *Store) cloneCommand(c Command) error {
+	created, err := s.createCommand(c.Name, c.Command, c.RawCommand, c.Description, c.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to copy command %q: %w", c.Name, err)
+	}
+
+	if len(c.Env) > 0 {
+		if _, err := s.updateEnv(created.ID, c.Env); err != nil {
+			return fmt.Errorf("failed to copy env of command %q: %w", c.Name, err)
+		}
+	}
+
+	return s.audit(AuditOpAddCommand, c.Name, auditDetailCloned)
+}
+
+// removeDBFiles removes the database at dbPath along with its write-ahead log
+// and shared-memory files.
+func removeDBFiles(dbPath string) {
+	for _, p := range []string{dbPath, dbPath + "-wal", dbPath + "-shm"} {
+		_ = os.Remove(p)
+	}
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+const clonePassword = "clone_password"
+
+func TestCloneTo_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "deploy {{env|target}} {{!api_key}}", "deploys the app"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.SetCommandEnv("deploy", map[string]string{"REGION": "eu"}); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddSecret("api_key", "s3cr3t", "the api key"); err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	destPath := filepath.Join(t.TempDir(), "clone.db")
+
+	if err := s.CloneTo(destPath, clonePassword); err != nil {
+		t.Fatalf("unexpected error cloning store: %v", err)
+	}
+
+	clone := prepClonedStore(t, destPath, clonePassword)
+
+	cmds, err := clone.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %v", cmds)
+	}
+
+	cmd, err := clone.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if cmd.Command != "deploy {{env|target}} {{!api_key}}" || cmd.Description != "deploys the app" {
+		t.Fatalf("expected command to be cloned, got %v", cmd)
+	}
+
+	if len(cmd.Parameters) != 1 || cmd.Parameters[0].Description != "target" {
+		t.Fatalf("expected parameters to be cloned, got %v", cmd.Parameters)
+	}
+
+	if !maps.Equal(cmd.Env, map[string]string{"REGION": "eu"}) {
+		t.Fatalf("expected env to be cloned, got %v", cmd.Env)
+	}
+
+	secret, err := clone.GetSecretByKey("api_key")
+	if err != nil {
+		t.Fatalf("unexpected error getting secret: %v", err)
+	}
+
+	if secret.Value != "s3cr3t" || secret.Description != "the api key" {
+		t.Fatalf("expected secret to be cloned, got %v", secret)
+	}
+}
+
+func TestCloneTo_Empty(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	destPath := filepath.Join(t.TempDir(), "clone.db")
+
+	if err := s.CloneTo(destPath, clonePassword); err != nil {
+		t.Fatalf("unexpected error cloning store: %v", err)
+	}
+
+	cmds, err := prepClonedStore(t, destPath, clonePassword).ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmds) != 0 {
+		t.Fatalf("expected no commands, got %v", cmds)
+	}
+}
+
+func TestCloneTo_Err(t *testing.T) {
+	t.Parallel()
+
+	existing := filepath.Join(t.TempDir(), "existing.db")
+	if err := os.WriteFile(existing, []byte("keep"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := map[string]struct {
+		path     string
+		password string
+		want     error
+	}{
+		"destination exists": {path: existing, password: clonePassword, want: ErrCloneDestExists},
+		"empty password":     {path: filepath.Join(t.TempDir(), "clone.db"), password: "", want: ErrDBPasswordUnset},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := prepNewStore(t)
+
+			if err := s.CloneTo(tt.path, tt.password); !errors.Is(err, tt.want) {
+				t.Fatalf("expected error %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Cleanup(func() {
+		bb, err := os.ReadFile(existing)
+		if err != nil || string(bb) != "keep" {
+			t.Errorf("expected existing destination to be left alone, got %q, %v", bb, err)
+		}
+	})
+}
+
+// prepClonedStore opens the database at dbPath with password.
+func prepClonedStore(t *testing.T, dbPath, password string) *Store {
+	t.Helper()
+
+	conn, err := sqlite3.DB(dbPath, password)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return NewStore(conn)
+}
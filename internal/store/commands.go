@@ -3,23 +3,26 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/h3jfc/shed/db"
 	"github.com/h3jfc/shed/internal/logger"
 	"github.com/h3jfc/shed/lib/brackets"
-	"github.com/h3jfc/shed/lib/itertools"
 	"github.com/h3jfc/shed/lib/sqlite3"
 	"github.com/spf13/viper"
 )
 
 const (
 	nameMaxLength = 32
-	nameDetails   = "command names may only contain letters, numbers, hyphens, and underscores"
-	nameLength    = "it must be between 1 and 32 characters long"
+	nameDetails   = "names must start with a letter and contain only letters, numbers, underscores " +
+		"and, when allowed by settings, hyphens or dots"
 )
 
 var (
@@ -29,35 +32,113 @@ var (
 	ErrParsingValueParams = errors.New("failed to parse value parameters")
 	ErrCommandNotFound    = errors.New("command not found")
 	ErrNameTooLong        = errors.New("command name is too long, it must be 40 characters or less")
+	ErrInvalidEnvName     = errors.New("invalid environment variable name")
+	ErrAmbiguousPrefix    = errors.New("ambiguous command prefix")
+	ErrReadOnly           = errors.New("store is read-only")
+	ErrDBPathUnset        = errors.New("database location is not set, set shed-db.location in the shed config file")
+	ErrDBPasswordUnset    = errors.New("database password is not set, set shed-db.password in the shed config file")
+	ErrCheckpointBusy     = errors.New("checkpoint could not complete, the database is in use")
+
+	ErrInvalidNameMaxLength = errors.New("name max length must be at least 1")
 )
 
 type Store struct {
-	queries *db.Queries
-	dbtx    db.DBTX
+	queries  *db.Queries
+	dbtx     db.DBTX
+	readOnly bool
+}
+
+// txMu serializes write transactions within the process. Connections share
+// one cache, where a second writer fails at once with "database table is
+// locked" rather than waiting out the busy timeout.
+var txMu sync.Mutex
+
+// withTx runs fn with a store whose queries share one transaction, committed
+// when fn succeeds and rolled back otherwise. A store that is already running
+// in a transaction runs fn on itself.
+func (s *Store) withTx(fn func(tx *Store) error) error {
+	conn, ok := s.dbtx.(*sql.DB)
+	if !ok {
+		return fn(s)
+	}
+
+	txMu.Lock()
+	defer txMu.Unlock()
+
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(&Store{queries: s.queries.WithTx(tx), dbtx: tx, readOnly: s.readOnly}); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
 func NewStoreFromConfig() (*Store, error) {
 	logger.Debug("initializing store from config")
 
+	return newStoreFromConfig(sqlite3.DB, false)
+}
+
+// NewReadOnlyStoreFromConfig opens the configured database for reading only.
+// Every write through the returned store fails with ErrReadOnly.
+func NewReadOnlyStoreFromConfig() (*Store, error) {
+	logger.Debug("initializing read-only store from config")
+
+	return newStoreFromConfig(sqlite3.DBReadOnly, true)
+}
+
+// ConfiguredDB returns the database location and password from the config. It
+// returns ErrDBPathUnset or ErrDBPasswordUnset, both also matching ErrNotFound,
+// when either is missing. The error names the config file in use, if any.
+func ConfiguredDB() (string, string, error) {
 	dbPath := viper.GetString("shed-db.location")
 	encryptionKey := viper.GetString("shed-db.password")
 
 	if dbPath == "" {
-		return nil, fmt.Errorf("database path is not set: %w", ErrNotFound)
+		return "", "", configError(ErrDBPathUnset)
 	}
 
 	if encryptionKey == "" {
-		return nil, fmt.Errorf("database encryption key is not set: %w", ErrNotFound)
+		return "", "", configError(ErrDBPasswordUnset)
+	}
+
+	return dbPath, encryptionKey, nil
+}
+
+// configError wraps err with ErrNotFound and the path of the config file in
+// use, when there is one.
+func configError(err error) error {
+	if file := viper.ConfigFileUsed(); file != "" {
+		return fmt.Errorf("%w (%s): %w", err, file, ErrNotFound)
+	}
+
+	return fmt.Errorf("%w: %w", err, ErrNotFound)
+}
+
+func newStoreFromConfig(open func(dbPath, encryptionKey string) (*sql.DB, error), readOnly bool) (*Store, error) {
+	dbPath, encryptionKey, err := ConfiguredDB()
+	if err != nil {
+		return nil, err
 	}
 
-	dbtx, err := sqlite3.DB(dbPath, encryptionKey)
+	dbtx, err := open(dbPath, encryptionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w, %w", ErrNotFound, err)
 	}
 
 	queries := db.New(dbtx)
 
-	return &Store{queries: queries, dbtx: dbtx}, nil
+	return &Store{queries: queries, dbtx: dbtx, readOnly: readOnly}, nil
 }
 
 func NewStore(dbtx db.DBTX) *Store {
@@ -66,18 +147,94 @@ func NewStore(dbtx db.DBTX) *Store {
 	return &Store{queries: queries, dbtx: dbtx}
 }
 
+// NewReadOnlyStore wraps dbtx in a store that refuses every write with
+// ErrReadOnly. Pair it with a handle from sqlite3.DBReadOnly.
+func NewReadOnlyStore(dbtx db.DBTX) *Store {
+	s := NewStore(dbtx)
+	s.readOnly = true
+
+	return s
+}
+
+// checkWritable returns ErrReadOnly for a read-only store.
+func (s *Store) checkWritable() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	return nil
+}
+
+// Checkpoint copies every change in the write-ahead log into the main database
+// file and truncates the log, so the main file alone holds all committed data,
+// as a backup copying only that file needs. It does nothing on a read-only
+// store, and returns ErrCheckpointBusy when another connection keeps it from
+// completing.
+func (s *Store) Checkpoint() error {
+	if s.readOnly {
+		return nil
+	}
+
+	var busy, logFrames, checkpointed int
+
+	err := s.dbtx.QueryRowContext(context.Background(), "PRAGMA wal_checkpoint(TRUNCATE)").
+		Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+
+	if busy != 0 {
+		return ErrCheckpointBusy
+	}
+
+	return nil
+}
+
+// Close checkpoints the write-ahead log, then closes the database, so nothing
+// is left only in the log when shed exits. A store running in a transaction is
+// left alone for the owner of the transaction to finish.
+func (s *Store) Close() error {
+	conn, ok := s.dbtx.(*sql.DB)
+	if !ok {
+		return nil
+	}
+
+	checkpointErr := s.Checkpoint()
+
+	if err := conn.Close(); err != nil {
+		return errors.Join(checkpointErr, fmt.Errorf("failed to close database: %w", err))
+	}
+
+	return checkpointErr
+}
+
+// CloseLogged closes the store like Close, for deferring once a command is done
+// with it. A failure is only logged, as the command has already succeeded or
+// failed by then.
+func (s *Store) CloseLogged() {
+	if err := s.Close(); err != nil {
+		logger.Warn("Failed to close store", "error", err)
+	}
+}
+
 type Command struct {
 	ID          int64
 	Name        string
 	Command     string
+	RawCommand  string
 	Description string
 	Parameters  brackets.Parameters
+	Env         map[string]string
 	CreatedAt   string
 	UpdatedAt   string
 }
 
 func (s *Store) AddCommand(name, command, description string) (*Command, error) {
-	if err := validateName(name); err != nil {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateName(name); err != nil {
 		return nil, err
 	}
 
@@ -86,14 +243,22 @@ func (s *Store) AddCommand(name, command, description string) (*Command, error)
 		return nil, fmt.Errorf("failed to parse command for parameters: %w", err)
 	}
 
-	_, err = s.GetCommandByName(name)
-	if err == nil {
-		return nil, fmt.Errorf("command with name %q already exists: %w", name, ErrAlreadyExists)
+	if description == "" {
+		description = DefaultDescription(name, b.Command)
 	}
 
-	cmd, err := s.createCommand(name, b.Command, description, *b.Parameters)
+	var cmd *Command
+
+	err = s.withTx(func(tx *Store) error {
+		cmd, err = tx.createCommand(name, b.Command, command, description, *b.Parameters)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(AuditOpAddCommand, name, "")
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create command: %w", err)
+		return nil, err
 	}
 
 	s.VerifySecretsExist(b)
@@ -101,6 +266,25 @@ func (s *Store) AddCommand(name, command, description string) (*Command, error)
 	return cmd, nil
 }
 
+// AddCommandFrom adds a new command seeded from an existing one. The source body
+// and description are used unless command or description are provided.
+func (s *Store) AddCommandFrom(srcName, name, command, description string) (*Command, error) {
+	src, err := s.GetCommandByName(srcName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source command: %w", err)
+	}
+
+	if command == "" {
+		command = src.rawBody()
+	}
+
+	if description == "" {
+		description = src.Description
+	}
+
+	return s.AddCommand(name, command, description)
+}
+
 func (s *Store) VerifySecretsExist(b *brackets.Brackets) {
 	for _, secret := range *b.Secrets {
 		_, err := s.GetSecretByKey(secret.Key)
@@ -137,16 +321,35 @@ func (s *Store) CopyCommand(srcName, destName, jsonValueParams string) (*Command
 	return cmd, nil
 }
 
-func (s *Store) RemoveCommand(name string) error {
-	if _, err := s.GetCommandByName(name); err != nil {
-		return fmt.Errorf("command %q does not exist: %w", name, ErrCommandNotFound)
+// RemoveCommand deletes a command by name and returns the removed record.
+func (s *Store) RemoveCommand(name string) (*Command, error) {
+	return s.removeCommand(name, "")
+}
+
+// removeCommand deletes a command by name, recording detail in the audit log.
+func (s *Store) removeCommand(name, detail string) (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
 	}
 
-	if err := s.queries.DeleteCommandByName(context.Background(), name); err != nil {
-		return fmt.Errorf("failed to delete command: %w", err)
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("command %q does not exist: %w", name, ErrCommandNotFound)
 	}
 
-	return nil
+	err = s.withTx(func(tx *Store) error {
+		// name may be an alias, so delete the command it resolved to
+		if err := tx.queries.DeleteCommandByID(context.Background(), cmd.ID); err != nil {
+			return fmt.Errorf("failed to delete command: %w", err)
+		}
+
+		return tx.audit(AuditOpRemoveCommand, cmd.Name, detail)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
 }
 
 func (s *Store) UpdateCommand(
@@ -155,7 +358,11 @@ func (s *Store) UpdateCommand(
 	params brackets.Parameters,
 	jsonValueParams string,
 ) (*Command, error) {
-	if err := validateName(name); err != nil {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateName(name); err != nil {
 		return nil, err
 	}
 
@@ -169,6 +376,11 @@ func (s *Store) UpdateCommand(
 		return nil, fmt.Errorf("failed to hydrate command from json: %w", err)
 	}
 
+	raw, err := brackets.HydrateStringFromJSON(command, jsonValueParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hydrate raw command from json: %w", err)
+	}
+
 	prev, err := s.GetCommand(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing command: %w", err)
@@ -181,9 +393,23 @@ func (s *Store) UpdateCommand(
 
 	priority.ThreeWayMerge(&prev.Parameters, &params)
 
-	cmd, err := s.updateCommand(id, name, c, description, priority)
+	detail := ""
+	if prev.Name != name {
+		detail = fmt.Sprintf("renamed from %q", prev.Name)
+	}
+
+	var cmd *Command
+
+	err = s.withTx(func(tx *Store) error {
+		cmd, err = tx.updateCommand(id, name, c, raw, description, priority)
+		if err != nil {
+			return fmt.Errorf("failed to update command: %w", err)
+		}
+
+		return tx.audit(AuditOpUpdateCommand, name, detail)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update command: %w", err)
+		return nil, err
 	}
 
 	s.VerifySecretsExist(b)
@@ -191,8 +417,85 @@ func (s *Store) UpdateCommand(
 	return cmd, nil
 }
 
+// ResyncParameters rebuilds the stored parameters of a command from its body.
+// When the command column no longer matches the raw body, as after an edit
+// made outside shed, the command column wins and replaces the raw body too.
+// Parameters found in the body but missing from the stored JSON are added,
+// stale ones are dropped, and the longer description wins for the rest. A
+// command already in sync is returned without being written.
+func (s *Store) ResyncParameters(name string) (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := cmd.rawBody()
+
+	b, err := brackets.Parse(raw)
+	if err != nil || b.Command != cmd.Command {
+		// The command column was edited directly, so the raw body is stale
+		// and the current command is the source
+		raw = cmd.Command
+
+		b, err = brackets.Parse(raw)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse command for parameters: %w", err)
+	}
+
+	params := *b.Parameters
+	params.ThreeWayMerge(nil, &cmd.Parameters)
+
+	if params.Signature() == cmd.Parameters.Signature() && raw == cmd.rawBody() {
+		return cmd, nil
+	}
+
+	var c *Command
+
+	err = s.withTx(func(tx *Store) error {
+		c, err = tx.updateCommand(cmd.ID, cmd.Name, cmd.Command, raw, cmd.Description, params)
+		if err != nil {
+			return fmt.Errorf("failed to resync parameters: %w", err)
+		}
+
+		return tx.audit(AuditOpUpdateCommand, cmd.Name, "parameters")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// CommandExists reports whether a command is stored under name. Aliases do not
+// count. A missing command is (false, nil); only a failing query is an error.
+func (s *Store) CommandExists(name string) (bool, error) {
+	_, err := s.queries.GetCommandByName(context.Background(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check command %q: %w", name, err)
+	}
+
+	return true, nil
+}
+
 func (s *Store) GetCommandByName(name string) (*Command, error) {
 	cmd, err := s.queries.GetCommandByName(context.Background(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Not a command name, but it may be an alias of one
+		if aliased, aliasErr := s.getCommandByAlias(name); aliasErr == nil {
+			return ToCommand(aliased)
+		}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrCommandNotFound, err)
 	}
@@ -200,6 +503,58 @@ func (s *Store) GetCommandByName(name string) (*Command, error) {
 	return ToCommand(cmd)
 }
 
+// GetCommandByPrefix returns the command named prefix, or else the single
+// command whose name starts with prefix. When several commands match, the
+// error lists them as candidates. Errors other than a missing name are
+// returned as is, rather than read as no exact match.
+func (s *Store) GetCommandByPrefix(prefix string) (*Command, error) {
+	cmd, err := s.GetCommandByName(prefix)
+	if err == nil {
+		return cmd, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	cc, err := s.ListCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := slices.DeleteFunc(cc, func(c Command) bool {
+		return !strings.HasPrefix(c.Name, prefix)
+	})
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: no command starts with %q", ErrCommandNotFound, prefix)
+	case 1:
+		return &matches[0], nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, c := range matches {
+			names = append(names, c.Name)
+		}
+
+		slices.Sort(names)
+
+		return nil, fmt.Errorf("%w: %q matches %s", ErrAmbiguousPrefix, prefix, strings.Join(names, ", "))
+	}
+}
+
+// GetCommandRaw returns the command body exactly as it was entered by the user,
+// before normalization. Commands stored before raw bodies were tracked fall back
+// to the normalized body.
+func (s *Store) GetCommandRaw(name string) (string, error) {
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.rawBody(), nil
+}
+
 func (s *Store) GetCommand(id int64) (*Command, error) {
 	cmd, err := s.queries.GetCommandByID(context.Background(), id)
 	if err != nil {
@@ -218,14 +573,215 @@ func (s *Store) ListCommands() ([]Command, error) {
 	return ToCommands(cc)
 }
 
-// validateName checks if a command name is valid.
+// ListCommandsSince returns the commands updated at or after updatedAfter,
+// oldest update first, for incremental export. Timestamps are stored to the
+// second, so a command updated in the same second as updatedAfter is included
+// rather than risk missing it.
+func (s *Store) ListCommandsSince(updatedAfter time.Time) ([]Command, error) {
+	cutoff := updatedAfter.UTC().Format(sqliteTimeLayout)
+
+	cc, err := s.queries.ListCommandsUpdatedSince(context.Background(), cutoff)
+	if err != nil {
+		return []Command{}, fmt.Errorf("failed to list commands updated since %s: %w", cutoff, err)
+	}
+
+	return ToCommands(cc)
+}
+
+// SetDescription replaces only the description of the named command, leaving
+// its body and parameters untouched, and bumps its UpdatedAt.
+func (s *Store) SetDescription(name, description string) (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var c db.Command
+
+	err = s.withTx(func(tx *Store) error {
+		c, err = tx.queries.UpdateCommandDescription(context.Background(), db.UpdateCommandDescriptionParams{
+			Description: description,
+			ID:          cmd.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update command description: %w", err)
+		}
+
+		return tx.audit(AuditOpUpdateCommand, cmd.Name, "description")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ToCommand(c)
+}
+
+// SetCommandEnv replaces the environment variables stored for a command.
+// They are set, on top of the process environment, whenever the command runs.
+func (s *Store) SetCommandEnv(name string, env map[string]string) (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateEnv(env); err != nil {
+		return nil, err
+	}
+
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var c db.Command
+
+	err = s.withTx(func(tx *Store) error {
+		c, err = tx.updateEnv(cmd.ID, env)
+		if err != nil {
+			return err
+		}
+
+		// Env values can hold credentials, so only the change is recorded
+		return tx.audit(AuditOpUpdateCommand, cmd.Name, "env")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ToCommand(c)
+}
+
+// ValidateEnv returns ErrInvalidEnvName for the first name in env that cannot
+// be an environment variable, so env can be checked before anything is
+// written.
+func ValidateEnv(env map[string]string) error {
+	for k := range env {
+		if k == "" || strings.ContainsAny(k, "= ") {
+			return fmt.Errorf("%w: %q", ErrInvalidEnvName, k)
+		}
+	}
+
+	return nil
+}
+
+// updateEnv replaces the env of the command with the given ID. A nil env
+// clears it.
+func (s *Store) updateEnv(id int64, env map[string]string) (db.Command, error) {
+	if env == nil {
+		env = map[string]string{}
+	}
+
+	bb, err := json.Marshal(env)
+	if err != nil {
+		return db.Command{}, fmt.Errorf("failed to marshal env to json: %w", err)
+	}
+
+	c, err := s.queries.UpdateCommandEnv(context.Background(), db.UpdateCommandEnvParams{
+		Env: bb,
+		ID:  id,
+	})
+	if err != nil {
+		return db.Command{}, fmt.Errorf("failed to update command env: %w", err)
+	}
+
+	return c, nil
+}
+
+// GetCommandEnv returns the environment variables stored for a command.
+func (s *Store) GetCommandEnv(name string) (map[string]string, error) {
+	cmd, err := s.GetCommandByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd.Env, nil
+}
+
+var (
+	descriptionTemplate   string
+	descriptionTemplateMu sync.RWMutex
+)
+
+// SetDefaultDescriptionTemplate sets the description AddCommand gives commands
+// added without one. In tmpl, {name} is replaced by the command name and
+// {body} by the normalized command, as in "Runs: {body}". An empty tmpl, the
+// default, leaves such descriptions empty.
+func SetDefaultDescriptionTemplate(tmpl string) {
+	descriptionTemplateMu.Lock()
+	defer descriptionTemplateMu.Unlock()
+
+	descriptionTemplate = tmpl
+}
+
+// DefaultDescription expands the default description template for a command.
+// It returns "" when no template is set.
+func DefaultDescription(name, body string) string {
+	descriptionTemplateMu.RLock()
+	tmpl := descriptionTemplate
+	descriptionTemplateMu.RUnlock()
+
+	return strings.NewReplacer("{name}", name, "{body}", body).Replace(tmpl)
+}
+
+// NameRules controls which command names ValidateName accepts. Names always
+// start with a letter and may contain letters, numbers and underscores.
+type NameRules struct {
+	AllowHyphens bool
+	AllowDots    bool
+	MaxLength    int
+}
+
+// DefaultNameRules are the strict rules used unless settings override them.
+var DefaultNameRules = NameRules{MaxLength: nameMaxLength}
+
+var (
+	nameRules   = DefaultNameRules
+	nameRulesMu sync.RWMutex
+)
+
+// SetNameRules changes the rules ValidateName applies to command names. It
+// applies to every subsequent validation in the process.
+func SetNameRules(rules NameRules) error {
+	if rules.MaxLength < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidNameMaxLength, rules.MaxLength)
+	}
+
+	nameRulesMu.Lock()
+	defer nameRulesMu.Unlock()
+
+	nameRules = rules
+
+	return nil
+}
+
+// ValidateName checks a command name against the configured NameRules.
+func ValidateName(name string) error {
+	nameRulesMu.RLock()
+	rules := nameRules
+	nameRulesMu.RUnlock()
+
+	return validateNameWith(name, rules)
+}
+
+// validateName checks a secret key against DefaultNameRules. Secret keys are
+// referenced as {{!key}} in templates, where hyphens and dots are not allowed,
+// so they never follow the configured rules.
+func validateName(name string) error {
+	return validateNameWith(name, DefaultNameRules)
+}
+
+// validateNameWith checks if a name is valid under rules.
 // Valid names must:
 // - Start with a letter (a-z, A-Z)
-// - Contain only alphanumeric characters and underscores
+// - Contain only alphanumeric characters and underscores, plus hyphens or
+// dots when rules allow them
 // - Not be empty
 // - Not exceed the maximum length.
-func validateName(name string) error {
-	if err := validateNameLength(name); err != nil {
+func validateNameWith(name string, rules NameRules) error {
+	if err := validateNameLength(name, rules.MaxLength); err != nil {
 		return err
 	}
 
@@ -233,12 +789,12 @@ func validateName(name string) error {
 		return err
 	}
 
-	return validateNameChars(name)
+	return validateNameChars(name, rules)
 }
 
-func validateNameLength(name string) error {
-	if len(name) == 0 || len(name) > nameMaxLength {
-		return fmt.Errorf("%w: %s", ErrInvalidCommandName, nameLength)
+func validateNameLength(name string, maxLength int) error {
+	if len(name) == 0 || len(name) > maxLength {
+		return fmt.Errorf("%w: it must be between 1 and %d characters long", ErrInvalidCommandName, maxLength)
 	}
 
 	return nil
@@ -253,9 +809,9 @@ func validateNameFirstChar(name string) error {
 	return nil
 }
 
-func validateNameChars(name string) error {
+func validateNameChars(name string, rules NameRules) error {
 	for i := 1; i < len(name); i++ {
-		if !isValidNameChar(name[i]) {
+		if !isValidNameChar(name[i], rules) {
 			return fmt.Errorf("%w: %s", ErrInvalidCommandName, nameDetails)
 		}
 	}
@@ -263,12 +819,23 @@ func validateNameChars(name string) error {
 	return nil
 }
 
-func isValidNameChar(c byte) bool {
+func isValidNameChar(c byte, rules NameRules) bool {
 	isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 	isDigit := c >= '0' && c <= '9'
 	isUnderscore := c == '_'
+	isHyphen := rules.AllowHyphens && c == '-'
+	isDot := rules.AllowDots && c == '.'
+
+	return isLetter || isDigit || isUnderscore || isHyphen || isDot
+}
 
-	return isLetter || isDigit || isUnderscore
+// rawBody returns the raw command body, falling back to the normalized body.
+func (c *Command) rawBody() string {
+	if c.RawCommand == "" {
+		return c.Command
+	}
+
+	return c.RawCommand
 }
 
 func ToParameters(raw json.RawMessage) (brackets.Parameters, error) {
@@ -280,47 +847,77 @@ func ToParameters(raw json.RawMessage) (brackets.Parameters, error) {
 	return params, nil
 }
 
+func ToEnv(raw json.RawMessage) (map[string]string, error) {
+	env := map[string]string{}
+	if len(raw) == 0 {
+		return env, nil
+	}
+
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal env: %w", err)
+	}
+
+	return env, nil
+}
+
 func ToCommand(c db.Command) (*Command, error) {
+	cmd, err := toCommand(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmd, nil
+}
+
+// toCommand converts c by value, so ToCommands can fill its result in place
+// without a heap allocation per command.
+func toCommand(c db.Command) (Command, error) {
 	params, err := ToParameters(c.Parameters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert to command: %w", err)
+		return Command{}, fmt.Errorf("failed to convert to command: %w", err)
+	}
+
+	env, err := ToEnv(c.Env)
+	if err != nil {
+		return Command{}, fmt.Errorf("failed to convert to command: %w", err)
 	}
 
-	return &Command{
+	return Command{
 		ID:          c.ID,
 		Name:        c.Name,
 		Command:     c.Command,
+		RawCommand:  c.RawCommand,
 		Description: c.Description,
 		Parameters:  params,
+		Env:         env,
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 	}, nil
 }
 
 func ToCommands(cc []db.Command) ([]Command, error) {
-	mapped := itertools.Map2(slices.Values(cc), func(c db.Command) (Command, error) {
-		cmd, err := ToCommand(c)
-		if err != nil {
-			return Command{}, fmt.Errorf("failed to convert to command: %w", err)
-		}
+	out := make([]Command, len(cc))
 
-		return *cmd, nil
-	})
-
-	out := make([]Command, 0, len(cc))
-
-	for c, err := range mapped {
+	for i := range cc {
+		cmd, err := toCommand(cc[i])
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert commands: %w", err)
 		}
 
-		out = append(out, c)
+		out[i] = cmd
 	}
 
 	return out, nil
 }
 
-func (s *Store) createCommand(name, command, description string, params brackets.Parameters) (*Command, error) {
+func (s *Store) createCommand(
+	name, command, rawCommand, description string,
+	params brackets.Parameters,
+) (*Command, error) {
+	if err := s.checkNotAlias(name); err != nil {
+		return nil, err
+	}
+
 	bb, err := json.Marshal(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal parameters to json: %w", err)
@@ -329,11 +926,18 @@ func (s *Store) createCommand(name, command, description string, params brackets
 	args := db.CreateCommandParams{
 		Name:        name,
 		Command:     command,
+		RawCommand:  rawCommand,
 		Description: description,
 		Parameters:  bb,
 	}
 
+	// The UNIQUE constraint on name is the existence check, so two concurrent
+	// inserts of the same name cannot both succeed.
 	c, err := s.queries.CreateCommand(context.Background(), args)
+	if sqlite3.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("command with name %q already exists: %w", name, ErrAlreadyExists)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to create command: %w", err)
 	}
@@ -343,9 +947,13 @@ func (s *Store) createCommand(name, command, description string, params brackets
 
 func (s *Store) updateCommand(
 	id int64,
-	name, command, description string,
+	name, command, rawCommand, description string,
 	params brackets.Parameters,
 ) (*Command, error) {
+	if err := s.checkNotAlias(name); err != nil {
+		return nil, err
+	}
+
 	bb, err := json.Marshal(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal parameters to json: %w", err)
@@ -355,13 +963,20 @@ func (s *Store) updateCommand(
 		ID:          id,
 		Name:        name,
 		Command:     command,
+		RawCommand:  rawCommand,
 		Description: description,
 		Parameters:  bb,
 	}
 
+	// As in createCommand, the UNIQUE constraint catches a rename onto the name
+	// of another command
 	c, err := s.queries.UpdateCommand(context.Background(), args)
+	if sqlite3.IsUniqueViolation(err) {
+		return nil, fmt.Errorf("command with name %q already exists: %w", name, ErrAlreadyExists)
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("failed to create command: %w", err)
+		return nil, fmt.Errorf("failed to update command: %w", err)
 	}
 
 	return ToCommand(c)
@@ -1,10 +1,25 @@
 package store
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/h3jfc/shed/db"
 	"github.com/h3jfc/shed/lib/brackets"
+	"github.com/h3jfc/shed/lib/sqlite3"
+	"github.com/spf13/viper"
 )
 
 const (
@@ -75,6 +90,164 @@ func TestAddCommand_OKMaxLength(t *testing.T) { // nolint:funlen
 	}
 }
 
+func TestValidateNameWith(t *testing.T) {
+	t.Parallel()
+
+	relaxed := NameRules{AllowHyphens: true, AllowDots: true, MaxLength: 64}
+
+	tests := map[string]struct {
+		name  string
+		rules NameRules
+		ok    bool
+	}{
+		"default underscore":       {name: "my_command", rules: DefaultNameRules, ok: true},
+		"default hyphen":           {name: "my-command", rules: DefaultNameRules},
+		"default dot":              {name: "deploy.prod", rules: DefaultNameRules},
+		"default max length":       {name: validName32Char, rules: DefaultNameRules, ok: true},
+		"default too long":         {name: invalidName33CharTooLong, rules: DefaultNameRules},
+		"hyphens allowed":          {name: "my-command", rules: NameRules{AllowHyphens: true, MaxLength: 32}, ok: true},
+		"hyphens allowed, dot":     {name: "deploy.prod", rules: NameRules{AllowHyphens: true, MaxLength: 32}},
+		"dots allowed":             {name: "deploy.prod", rules: NameRules{AllowDots: true, MaxLength: 32}, ok: true},
+		"dots allowed, hyphen":     {name: "my-command", rules: NameRules{AllowDots: true, MaxLength: 32}},
+		"both allowed":             {name: "deploy.prod-eu", rules: relaxed, ok: true},
+		"first char hyphen":        {name: "-command", rules: relaxed},
+		"first char dot":           {name: ".command", rules: relaxed},
+		"first char digit":         {name: "1command", rules: relaxed},
+		"longer max length":        {name: invalidName33CharTooLong, rules: relaxed, ok: true},
+		"shorter max length":       {name: "command", rules: NameRules{MaxLength: 4}},
+		"shorter max length, fits": {name: "cmd", rules: NameRules{MaxLength: 4}, ok: true},
+		"empty":                    {name: "", rules: relaxed},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateNameWith(tc.name, tc.rules)
+			if tc.ok && err != nil {
+				t.Fatalf("expected %q to be valid, got %v", tc.name, err)
+			}
+
+			if !tc.ok && !errors.Is(err, ErrInvalidCommandName) {
+				t.Fatalf("expected error %v for %q, got %v", ErrInvalidCommandName, tc.name, err)
+			}
+		})
+	}
+}
+
+// Changes package-level state, so it must not run in parallel with other tests.
+func TestAddCommand_DefaultDescriptionTemplate(t *testing.T) { //nolint:paralleltest
+	s := prepNewStore(t)
+
+	// No template configured: the description stays empty
+	plain, err := s.AddCommand("plain", "ls -la", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if plain.Description != "" {
+		t.Fatalf("expected an empty description, got %q", plain.Description)
+	}
+
+	SetDefaultDescriptionTemplate("{name} runs: {body}")
+	t.Cleanup(func() { SetDefaultDescriptionTemplate("") })
+
+	generated, err := s.AddCommand("listing", "ls   -la {{path}}", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if want := "listing runs: ls -la {{path}}"; generated.Description != want {
+		t.Fatalf("expected description %q, got %q", want, generated.Description)
+	}
+
+	// A supplied description is kept
+	kept, err := s.AddCommand("greet", "echo hello", "says hello")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if kept.Description != "says hello" {
+		t.Fatalf("expected description %q, got %q", "says hello", kept.Description)
+	}
+}
+
+func TestSetNameRules_ErrInvalidMaxLength(t *testing.T) {
+	t.Parallel()
+
+	if err := SetNameRules(NameRules{MaxLength: 0}); !errors.Is(err, ErrInvalidNameMaxLength) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidNameMaxLength, err)
+	}
+}
+
+// Changes package-level state, so it must not run in parallel with other tests.
+func TestAddCommand_ConfiguredNameRules(t *testing.T) { //nolint:paralleltest
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy.prod", "echo deploy", ""); !errors.Is(err, ErrInvalidCommandName) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidCommandName, err)
+	}
+
+	if err := SetNameRules(NameRules{AllowHyphens: true, AllowDots: true, MaxLength: 40}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := SetNameRules(DefaultNameRules); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if _, err := s.AddCommand("deploy.prod-eu", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	// Secret keys keep the strict rules, since templates cannot reference them otherwise.
+	if _, err := s.AddSecret("api-key", "value", ""); !errors.Is(err, ErrInvalidCommandName) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidCommandName, err)
+	}
+}
+
+func TestAddCommand_ConcurrentSameName(t *testing.T) {
+	t.Parallel()
+
+	dbPath := prepDBFile(t)
+	stores := []*Store{prepFileStore(t, dbPath), prepFileStore(t, dbPath)}
+
+	var wg sync.WaitGroup
+
+	start := make(chan struct{})
+	errs := make([]error, len(stores))
+
+	for i, s := range stores {
+		wg.Go(func() {
+			<-start
+
+			_, errs[i] = s.AddCommand("race", "echo race", "")
+		})
+	}
+
+	close(start)
+	wg.Wait()
+
+	var ok, exists int
+
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrAlreadyExists):
+			exists++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if ok != 1 || exists != 1 {
+		t.Fatalf("expected 1 success and 1 %v, got %v and %v", ErrAlreadyExists, ok, exists)
+	}
+}
+
 func TestAddCommand_ErrAlreadyExists(t *testing.T) { // nolint:funlen
 	t.Parallel()
 	s := prepNewStore(t)
@@ -144,6 +317,16 @@ func TestAddCommand_Err(t *testing.T) { // nolint:funlen
 			command:     "ls",
 			want:        ErrInvalidCommandName,
 		},
+		"parameter-contains-space": {
+			commandName: "greet",
+			command:     "echo {{ name with space }}",
+			want:        brackets.ErrContainsSpaces,
+		},
+		"parameter-starts-with-digit": {
+			commandName: "greet",
+			command:     "echo {{1name}}",
+			want:        brackets.ErrStartsWithInvalidChar,
+		},
 	}
 
 	for name, tc := range tests {
@@ -159,10 +342,85 @@ func TestAddCommand_Err(t *testing.T) { // nolint:funlen
 			if !errors.Is(err, tc.want) {
 				t.Fatalf("expected error %v, got %v", tc.want, err)
 			}
+
+			// Nothing is stored for a command that fails to parse
+			if _, err := s.GetCommandByName(tc.commandName); err == nil {
+				t.Fatalf("expected command %q not to be stored", tc.commandName)
+			}
 		})
 	}
 }
 
+func TestAddCommandFrom_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("list_files", "ls -la {{path|description}}", "lists files command"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	cmd, err := s.AddCommandFrom("list_files", "list_more_files", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error adding command from source: %v", err)
+	}
+
+	if cmd.Name != "list_more_files" {
+		t.Fatalf("expected command name %v, got %v", "list_more_files", cmd.Name)
+	}
+
+	if cmd.Command != "ls -la {{path|description}}" {
+		t.Fatalf("expected command %v, got %v", "ls -la {{path|description}}", cmd.Command)
+	}
+
+	if cmd.Description != "lists files command" {
+		t.Fatalf("expected description %v, got %v", "lists files command", cmd.Description)
+	}
+
+	if len(cmd.Parameters) != 1 || cmd.Parameters[0].Name != "path" {
+		t.Fatalf("expected parameter %v, got %v", "path", cmd.Parameters)
+	}
+
+	if _, err := s.GetCommandByName("list_files"); err != nil {
+		t.Fatalf("expected source command to remain, got %v", err)
+	}
+}
+
+func TestAddCommandFrom_OKOverrides(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("list_files", "ls -la {{path|description}}", "lists files command"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	cmd, err := s.AddCommandFrom("list_files", "list_all_files", "ls -lah {{path}}", "lists all files")
+	if err != nil {
+		t.Fatalf("unexpected error adding command from source: %v", err)
+	}
+
+	if cmd.Command != "ls -lah {{path}}" {
+		t.Fatalf("expected command %v, got %v", "ls -lah {{path}}", cmd.Command)
+	}
+
+	if cmd.Description != "lists all files" {
+		t.Fatalf("expected description %v, got %v", "lists all files", cmd.Description)
+	}
+}
+
+func TestAddCommandFrom_ErrSourceNotFound(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.AddCommandFrom("does_not_exist", "new_command", "", "")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+
+	if _, err := s.GetCommandByName("new_command"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected new command not to be created, got %v", err)
+	}
+}
+
 func TestCopyCommand_Err(t *testing.T) { // nolint:funlen
 	t.Parallel()
 
@@ -371,13 +629,22 @@ func TestRemoveCommand_OK(t *testing.T) { // nolint:funlen
 	t.Parallel()
 	s := prepNewStore(t)
 
-	if _, err := s.AddCommand("list_files", "ls -la {{path|description}}", "list files"); err != nil {
+	added, err := s.AddCommand("list_files", "ls -la {{path|description}}", "list files")
+	if err != nil {
 		t.Fatalf("unexpected error adding command: %v", err)
 	}
 
-	err := s.RemoveCommand("list_files")
+	removed, err := s.RemoveCommand("list_files")
 	if err != nil {
-		t.Fatalf("unexpected error copying command: %v", err)
+		t.Fatalf("unexpected error removing command: %v", err)
+	}
+
+	if !reflect.DeepEqual(removed, added) {
+		t.Fatalf("expected removed command %v, got %v", added, removed)
+	}
+
+	if _, err := s.GetCommandByName("list_files"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
 	}
 }
 
@@ -385,10 +652,14 @@ func TestRemoveCommand_ErrCommandNotFound(t *testing.T) { // nolint:funlen
 	t.Parallel()
 	s := prepNewStore(t)
 
-	err := s.RemoveCommand("does_not_exist")
+	removed, err := s.RemoveCommand("does_not_exist")
 	if !errors.Is(err, ErrCommandNotFound) {
 		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
 	}
+
+	if removed != nil {
+		t.Fatalf("expected no removed command, got %v", removed)
+	}
 }
 
 func TestUpdateCommand_OK(t *testing.T) { // nolint:funlen
@@ -463,6 +734,35 @@ func TestUpdateCommand_ChangeName(t *testing.T) { // nolint:funlen
 	}
 }
 
+func TestUpdateCommand_ErrAlreadyExistsOnRename(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("list_files", "ls -la", "list files")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddCommand("show_files", "ls", "show files"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err = s.UpdateCommand(cmd.ID, "show_files", "ls -la", "list files", cmd.Parameters, "{}")
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected error %v, got %v", ErrAlreadyExists, err)
+	}
+
+	// Keeping its own name is not a collision
+	updatedCmd, err := s.UpdateCommand(cmd.ID, "list_files", "ls -lah", "list files", cmd.Parameters, "{}")
+	if err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	if updatedCmd.Command != "ls -lah" {
+		t.Fatalf("expected command %v, got %v", "ls -lah", updatedCmd.Command)
+	}
+}
+
 func TestUpdateCommand_OKMaxLength(t *testing.T) { // nolint:funlen
 	t.Parallel()
 	s := prepNewStore(t)
@@ -906,89 +1206,815 @@ func TestGetCommandByName_ErrCommandNotFound(t *testing.T) { // nolint:funlen
 	}
 }
 
-func TestListCommands_OK(t *testing.T) { // nolint:funlen,cyclop
+func TestGetCommandByPrefix_OK(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
 
-	// Create multiple commands
-	_, err := s.AddCommand("list_files", "ls -la {{path|directory path}}", "list files in directory")
-	if err != nil {
-		t.Fatalf("unexpected error adding command: %v", err)
+	for _, name := range []string{"deploy", "describe_all", "list"} {
+		if _, err := s.AddCommand(name, "echo "+name, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
 	}
 
-	_, err = s.AddCommand("show_date", "date +%Y-%m-%d", "display current date")
+	cmd, err := s.GetCommandByPrefix("dep")
 	if err != nil {
-		t.Fatalf("unexpected error adding command: %v", err)
+		t.Fatalf("unexpected error getting command by prefix: %v", err)
 	}
 
-	_, err = s.AddCommand("grep_logs", "grep {{pattern|search pattern}} {{file|log file}}", "search logs for pattern")
-	if err != nil {
-		t.Fatalf("unexpected error adding command: %v", err)
+	if cmd.Name != "deploy" {
+		t.Fatalf("expected command name %v, got %v", "deploy", cmd.Name)
 	}
+}
 
-	// List all commands
-	commands, err := s.ListCommands()
-	if err != nil {
-		t.Fatalf("unexpected error listing commands: %v", err)
+func TestGetCommandByPrefix_OKExactPreferred(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"deploy", "deploy_all"} {
+		if _, err := s.AddCommand(name, "echo "+name, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
 	}
 
-	if len(commands) != 3 {
-		t.Fatalf("expected 3 commands, got %v", len(commands))
+	cmd, err := s.GetCommandByPrefix("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command by prefix: %v", err)
 	}
 
-	// Verify command names exist
-	names := make(map[string]bool)
-	for _, cmd := range commands {
-		names[cmd.Name] = true
+	if cmd.Name != "deploy" {
+		t.Fatalf("expected command name %v, got %v", "deploy", cmd.Name)
 	}
+}
 
-	expectedNames := []string{"list_files", "show_date", "grep_logs"}
-	for _, name := range expectedNames {
-		if !names[name] {
-			t.Fatalf("expected command %v to be in list", name)
+func TestGetCommandByPrefix_ErrAmbiguous(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"deploy", "describe_all"} {
+		if _, err := s.AddCommand(name, "echo "+name, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
 		}
 	}
 
-	// Verify grep_logs has correct parameters
-	var grepCmd *Command
+	_, err := s.GetCommandByPrefix("de")
+	if !errors.Is(err, ErrAmbiguousPrefix) {
+		t.Fatalf("expected error %v, got %v", ErrAmbiguousPrefix, err)
+	}
 
-	for i := range commands {
-		if commands[i].Name == "grep_logs" {
-			grepCmd = &commands[i]
+	if !strings.Contains(err.Error(), "deploy, describe_all") {
+		t.Fatalf("expected error to list candidates, got %v", err)
+	}
+}
 
-			break
-		}
+func TestGetCommandByPrefix_ErrCommandNotFound(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
 	}
 
-	if grepCmd == nil {
-		t.Fatalf("expected to find grep_logs command")
+	_, err := s.GetCommandByPrefix("xyz")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
 	}
+}
 
-	if len(grepCmd.Parameters) != 2 {
-		t.Fatalf("expected 2 parameters for grep_logs, got %v", len(grepCmd.Parameters))
+func TestGetCommandByPrefix_ErrDatabase(t *testing.T) {
+	t.Parallel()
+	s := prepFileStore(t, prepDBFile(t))
+
+	if _, err := s.AddCommand("deploy", "echo deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
 	}
 
-	// Parameters should be sorted by name
-	if grepCmd.Parameters[0].Name != "file" {
-		t.Fatalf("expected first parameter name %v, got %v", "file", grepCmd.Parameters[0].Name)
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error closing store: %v", err)
 	}
 
-	if grepCmd.Parameters[1].Name != "pattern" {
-		t.Fatalf("expected second parameter name %v, got %v", "pattern", grepCmd.Parameters[1].Name)
+	_, err := s.GetCommandByPrefix("deploy")
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the database error, got %v", err)
 	}
 }
 
-func TestListCommands_Empty(t *testing.T) { // nolint:funlen
+func TestGetCommandRaw_OK(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
 
-	// List commands when none exist
-	commands, err := s.ListCommands()
+	raw := "  ls   -la    {{ path | directory path }}  "
+
+	cmd, err := s.AddCommand("list_files", raw, "list files in directory")
 	if err != nil {
-		t.Fatalf("unexpected error listing commands: %v", err)
+		t.Fatalf("unexpected error adding command: %v", err)
 	}
 
-	if len(commands) != 0 {
-		t.Fatalf("expected 0 commands, got %v", len(commands))
+	if cmd.Command != "ls -la {{path|directory path}}" {
+		t.Fatalf("expected normalized command %q, got %q", "ls -la {{path|directory path}}", cmd.Command)
+	}
+
+	got, err := s.GetCommandRaw("list_files")
+	if err != nil {
+		t.Fatalf("unexpected error getting raw command: %v", err)
+	}
+
+	if got != raw {
+		t.Fatalf("expected raw command %q, got %q", raw, got)
+	}
+}
+
+func TestGetCommandRaw_UpdatePreservesRaw(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("list_files", "ls -la", "list files in directory")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	raw := "ls   -lah  {{path}}  {{ sort }}"
+
+	updated, err := s.UpdateCommand(cmd.ID, cmd.Name, raw, cmd.Description, cmd.Parameters, `{"sort":"-S"}`)
+	if err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	if updated.Command != "ls -lah {{path}} -S" {
+		t.Fatalf("expected normalized command %q, got %q", "ls -lah {{path}} -S", updated.Command)
+	}
+
+	got, err := s.GetCommandRaw("list_files")
+	if err != nil {
+		t.Fatalf("unexpected error getting raw command: %v", err)
+	}
+
+	if got != "ls   -lah  {{path}}  -S" {
+		t.Fatalf("expected raw command %q, got %q", "ls   -lah  {{path}}  -S", got)
+	}
+}
+
+func TestGetCommandRaw_ErrCommandNotFound(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.GetCommandRaw("does_not_exist")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestSetCommandEnv_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("list_buckets", "aws s3 ls", "list buckets")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if len(cmd.Env) != 0 {
+		t.Fatalf("expected empty env, got %v", cmd.Env)
+	}
+
+	env := map[string]string{"AWS_PROFILE": "dev", "AWS_REGION": "us-east-1"}
+
+	updated, err := s.SetCommandEnv("list_buckets", env)
+	if err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	if !maps.Equal(updated.Env, env) {
+		t.Fatalf("expected env %v, got %v", env, updated.Env)
+	}
+
+	got, err := s.GetCommandEnv("list_buckets")
+	if err != nil {
+		t.Fatalf("unexpected error getting env: %v", err)
+	}
+
+	if !maps.Equal(got, env) {
+		t.Fatalf("expected env %v, got %v", env, got)
+	}
+
+	// Replacing the env drops variables that are no longer set
+	if _, err := s.SetCommandEnv("list_buckets", map[string]string{"AWS_PROFILE": "prod"}); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	got, err = s.GetCommandEnv("list_buckets")
+	if err != nil {
+		t.Fatalf("unexpected error getting env: %v", err)
+	}
+
+	if !maps.Equal(got, map[string]string{"AWS_PROFILE": "prod"}) {
+		t.Fatalf("expected env %v, got %v", map[string]string{"AWS_PROFILE": "prod"}, got)
+	}
+}
+
+func TestSetCommandEnv_Err(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("list_buckets", "aws s3 ls", "list buckets"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err := s.SetCommandEnv("list_buckets", map[string]string{"BAD=NAME": "x"})
+	if !errors.Is(err, ErrInvalidEnvName) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidEnvName, err)
+	}
+
+	_, err = s.SetCommandEnv("does_not_exist", map[string]string{"AWS_PROFILE": "dev"})
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		env     map[string]string
+		wantErr bool
+	}{
+		"valid":          {env: map[string]string{"AWS_PROFILE": "dev", "EMPTY": ""}},
+		"nil":            {env: nil},
+		"empty name":     {env: map[string]string{"": "x"}, wantErr: true},
+		"equals sign":    {env: map[string]string{"BAD=NAME": "x"}, wantErr: true},
+		"contains space": {env: map[string]string{"BAD NAME": "x"}, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateEnv(tt.env)
+			if tt.wantErr != errors.Is(err, ErrInvalidEnvName) {
+				t.Fatalf("expected error %v: %v, got %v", ErrInvalidEnvName, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCommandExists(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "deploy", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("dp", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	tests := map[string]struct {
+		name string
+		want bool
+	}{
+		"exists":    {name: "deploy", want: true},
+		"not found": {name: "missing", want: false},
+		"alias":     {name: "dp", want: false},
+	}
+
+	for name, tc := range tests {
+		got, err := s.CommandExists(tc.name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+}
+
+func TestCommandExists_DBError(t *testing.T) {
+	t.Parallel()
+
+	conn, err := sqlite3.DB(prepDBFile(t), testPassword)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	s := NewStore(conn)
+
+	exists, err := s.CommandExists("deploy")
+	if err == nil || exists {
+		t.Fatalf("expected an error for a closed database, got %v, %v", exists, err)
+	}
+
+	// A failed existence check must not be read as "does not exist"
+	if _, err := s.ImportCommands([]Command{{Name: "deploy", Command: "deploy"}}, ImportOptions{}); err == nil {
+		t.Fatal("expected import to fail on a closed database")
+	}
+}
+
+func TestSetDescription(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	before, err := s.AddCommand("deploy", "deploy {{env|target}} \\\n  --fast", "old description")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	cmd, err := s.SetDescription("deploy", "new description")
+	if err != nil {
+		t.Fatalf("unexpected error setting description: %v", err)
+	}
+
+	if cmd.Description != "new description" {
+		t.Fatalf("expected %v, got %v", "new description", cmd.Description)
+	}
+
+	got, err := s.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if got.Description != "new description" {
+		t.Fatalf("expected %v, got %v", "new description", got.Description)
+	}
+
+	if got.Command != before.Command || got.RawCommand != before.RawCommand {
+		t.Fatalf("expected body %q, got %q", before.RawCommand, got.RawCommand)
+	}
+
+	if !reflect.DeepEqual(got.Parameters, before.Parameters) {
+		t.Fatalf("expected %v, got %v", before.Parameters, got.Parameters)
+	}
+
+	if got.UpdatedAt < before.UpdatedAt {
+		t.Fatalf("expected updated at no earlier than %v, got %v", before.UpdatedAt, got.UpdatedAt)
+	}
+
+	if _, err := s.SetDescription("missing", "x"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestResyncParameters_AddsMissing(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("greet", "echo {{greeting|what to say}} {{name}}", "greets someone")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	// Drift the stored parameters away from the body
+	_, err = s.dbtx.ExecContext(context.Background(),
+		`UPDATE commands SET parameters = CAST(? AS BLOB) WHERE id = ?`,
+		`[{"name":"name","description":"who to greet"}]`, cmd.ID)
+	if err != nil {
+		t.Fatalf("unexpected error drifting parameters: %v", err)
+	}
+
+	resynced, err := s.ResyncParameters("greet")
+	if err != nil {
+		t.Fatalf("unexpected error resyncing parameters: %v", err)
+	}
+
+	expected := map[string]string{"greeting": "what to say", "name": "who to greet"}
+	if !maps.Equal(resynced.Parameters.ToMap(), expected) {
+		t.Fatalf("expected parameters %v, got %v", expected, resynced.Parameters.ToMap())
+	}
+}
+
+func TestResyncParameters_RemovesStale(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("greet", "echo {{name|who}}", "greets someone")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err = s.dbtx.ExecContext(context.Background(),
+		`UPDATE commands SET parameters = CAST(? AS BLOB) WHERE id = ?`,
+		`[{"name":"name","description":"who"},{"name":"stale","description":"gone"}]`, cmd.ID)
+	if err != nil {
+		t.Fatalf("unexpected error drifting parameters: %v", err)
+	}
+
+	resynced, err := s.ResyncParameters("greet")
+	if err != nil {
+		t.Fatalf("unexpected error resyncing parameters: %v", err)
+	}
+
+	expected := map[string]string{"name": "who"}
+	if !maps.Equal(resynced.Parameters.ToMap(), expected) {
+		t.Fatalf("expected parameters %v, got %v", expected, resynced.Parameters.ToMap())
+	}
+
+	if resynced.Command != cmd.Command {
+		t.Fatalf("expected command %v, got %v", cmd.Command, resynced.Command)
+	}
+}
+
+func TestResyncParameters_EditedCommandColumn(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	cmd, err := s.AddCommand("greet", "echo {{name|who}}", "greets someone")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	// Edit the body behind shed's back, leaving the raw body stale
+	_, err = s.dbtx.ExecContext(context.Background(),
+		`UPDATE commands SET command = ? WHERE id = ?`,
+		"echo {{greeting|what to say}} {{name|who}}", cmd.ID)
+	if err != nil {
+		t.Fatalf("unexpected error editing command: %v", err)
+	}
+
+	resynced, err := s.ResyncParameters("greet")
+	if err != nil {
+		t.Fatalf("unexpected error resyncing parameters: %v", err)
+	}
+
+	expected := map[string]string{"greeting": "what to say", "name": "who"}
+	if !maps.Equal(resynced.Parameters.ToMap(), expected) {
+		t.Fatalf("expected parameters %v, got %v", expected, resynced.Parameters.ToMap())
+	}
+
+	raw, err := s.GetCommandRaw("greet")
+	if err != nil {
+		t.Fatalf("unexpected error getting raw command: %v", err)
+	}
+
+	if raw != "echo {{greeting|what to say}} {{name|who}}" {
+		t.Fatalf("expected the raw body to follow the edited command, got %q", raw)
+	}
+}
+
+func TestResyncParameters_ErrCommandNotFound(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.ResyncParameters("does_not_exist")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestListCommands_OK(t *testing.T) { // nolint:funlen,cyclop
+	t.Parallel()
+	s := prepNewStore(t)
+
+	// Create multiple commands
+	_, err := s.AddCommand("list_files", "ls -la {{path|directory path}}", "list files in directory")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err = s.AddCommand("show_date", "date +%Y-%m-%d", "display current date")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err = s.AddCommand("grep_logs", "grep {{pattern|search pattern}} {{file|log file}}", "search logs for pattern")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	// List all commands
+	commands, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(commands) != 3 {
+		t.Fatalf("expected 3 commands, got %v", len(commands))
+	}
+
+	// Verify command names exist
+	names := make(map[string]bool)
+	for _, cmd := range commands {
+		names[cmd.Name] = true
+	}
+
+	expectedNames := []string{"list_files", "show_date", "grep_logs"}
+	for _, name := range expectedNames {
+		if !names[name] {
+			t.Fatalf("expected command %v to be in list", name)
+		}
+	}
+
+	// Verify grep_logs has correct parameters
+	var grepCmd *Command
+
+	for i := range commands {
+		if commands[i].Name == "grep_logs" {
+			grepCmd = &commands[i]
+
+			break
+		}
+	}
+
+	if grepCmd == nil {
+		t.Fatalf("expected to find grep_logs command")
+	}
+
+	if len(grepCmd.Parameters) != 2 {
+		t.Fatalf("expected 2 parameters for grep_logs, got %v", len(grepCmd.Parameters))
+	}
+
+	// Parameters should be sorted by name
+	if grepCmd.Parameters[0].Name != "file" {
+		t.Fatalf("expected first parameter name %v, got %v", "file", grepCmd.Parameters[0].Name)
+	}
+
+	if grepCmd.Parameters[1].Name != "pattern" {
+		t.Fatalf("expected second parameter name %v, got %v", "pattern", grepCmd.Parameters[1].Name)
+	}
+}
+
+func TestListCommandsSince(t *testing.T) { // nolint:funlen
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"alpha", "beta", "gamma", "delta"} {
+		_, err := s.dbtx.ExecContext(context.Background(),
+			`INSERT INTO commands (name, command, description, parameters, created_at, updated_at)
+			VALUES (?, 'echo '||?, '', CAST('[]' AS BLOB), '2000-01-01 00:00:00', '2000-01-01 00:00:00')`, name, name)
+		if err != nil {
+			t.Fatalf("unexpected error seeding command: %v", err)
+		}
+	}
+
+	since := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cmds, err := s.ListCommandsSince(since)
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmds) != 0 {
+		t.Fatalf("expected no commands updated since %v, got %v", since, cmds)
+	}
+
+	if _, err := s.SetCommandEnv("beta", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	if _, err := s.ResyncParameters("delta"); err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	gamma, err := s.GetCommandByName("gamma")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if _, err := s.UpdateCommand(gamma.ID, "gamma", "echo gamma {{x}}", "", nil, "{}"); err != nil {
+		t.Fatalf("unexpected error updating command: %v", err)
+	}
+
+	cmds, err = s.ListCommandsSince(since)
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name)
+	}
+
+	slices.Sort(names)
+
+	if want := []string{"beta", "gamma"}; !slices.Equal(names, want) {
+		t.Fatalf("expected commands %v, got %v", want, names)
+	}
+
+	all, err := s.ListCommandsSince(time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(all) != 4 {
+		t.Fatalf("expected %v commands since the zero time, got %v", 4, len(all))
+	}
+}
+
+func TestListCommands_Empty(t *testing.T) { // nolint:funlen
+	t.Parallel()
+	s := prepNewStore(t)
+
+	// List commands when none exist
+	commands, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(commands) != 0 {
+		t.Fatalf("expected 0 commands, got %v", len(commands))
+	}
+}
+
+//nolint:paralleltest
+func TestNewStoreFromConfig_Unset(t *testing.T) {
+	tests := map[string]struct {
+		location string
+		password string
+		want     error
+	}{
+		"path unset":     {location: "", password: testPassword, want: ErrDBPathUnset},
+		"password unset": {location: prepDBFile(t), password: "", want: ErrDBPasswordUnset},
+		"both unset":     {location: "", password: "", want: ErrDBPathUnset},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			viper.Set("shed-db.location", tc.location)
+			viper.Set("shed-db.password", tc.password)
+
+			t.Cleanup(viper.Reset)
+
+			for _, open := range []func() (*Store, error){NewStoreFromConfig, NewReadOnlyStoreFromConfig} {
+				_, err := open()
+				if !errors.Is(err, tc.want) {
+					t.Fatalf("expected error %v, got %v", tc.want, err)
+				}
+
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("expected error to match %v, got %v", ErrNotFound, err)
+				}
+			}
+		})
+	}
+}
+
+//nolint:paralleltest
+func TestConfiguredDB_NamesConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "shed.toml")
+	if err := os.WriteFile(configFile, []byte("[shed-db]\nlocation = \"\"\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+
+	viper.SetConfigFile(configFile)
+	t.Cleanup(viper.Reset)
+
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatalf("unexpected error reading config: %v", err)
+	}
+
+	_, _, err := ConfiguredDB()
+	if !errors.Is(err, ErrDBPathUnset) {
+		t.Fatalf("expected error %v, got %v", ErrDBPathUnset, err)
+	}
+
+	if !strings.Contains(err.Error(), configFile) {
+		t.Fatalf("expected error to name %s, got %v", configFile, err)
+	}
+}
+
+func benchDBCommands(n int) []db.Command {
+	cc := make([]db.Command, n)
+
+	for i := range cc {
+		cc[i] = db.Command{
+			ID:          int64(i + 1),
+			Name:        "cmd_" + strconv.Itoa(i),
+			Command:     "deploy {{env|target}} {{region}}",
+			Description: "deploys things",
+			Parameters:  json.RawMessage(`[{"name":"env","description":"target"},{"name":"region"}]`),
+			Env:         json.RawMessage(`{"AWS_PROFILE":"prod"}`),
+			CreatedAt:   "2024-01-01 00:00:00",
+			UpdatedAt:   "2024-01-01 00:00:00",
+		}
+	}
+
+	return cc
+}
+
+func TestToCommands_MatchesToCommand(t *testing.T) {
+	t.Parallel()
+
+	cc := benchDBCommands(50)
+
+	got, err := ToCommands(cc)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := make([]Command, 0, len(cc))
+
+	for _, c := range cc {
+		cmd, err := ToCommand(c)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		want = append(want, *cmd)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestToCommands_InvalidParameters(t *testing.T) {
+	t.Parallel()
+
+	cc := benchDBCommands(3)
+	cc[1].Parameters = json.RawMessage(`{`)
+
+	if got, err := ToCommands(cc); err == nil {
+		t.Fatalf("expected an error, got %v", got)
+	}
+}
+
+func BenchmarkToCommands(b *testing.B) {
+	cc := benchDBCommands(1000)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := ToCommands(cc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCheckpoint_MainFileHoldsWrites(t *testing.T) {
+	t.Parallel()
+
+	dbPath := prepDBFile(t)
+	s := prepFileStore(t, dbPath)
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if err := s.Checkpoint(); err != nil {
+		t.Fatalf("unexpected error checkpointing: %v", err)
+	}
+
+	// A backup copying only the main file, without the WAL, sees the write
+	backup := filepath.Join(t.TempDir(), "backup.db")
+
+	bb, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading database: %v", err)
+	}
+
+	if err := os.WriteFile(backup, bb, 0o600); err != nil {
+		t.Fatalf("unexpected error writing backup: %v", err)
+	}
+
+	if _, err := prepFileStore(t, backup).GetCommandByName("greet"); err != nil {
+		t.Fatalf("expected the command in the backup, got %v", err)
+	}
+}
+
+func TestClose_Checkpoints(t *testing.T) {
+	t.Parallel()
+
+	dbPath := prepDBFile(t)
+
+	conn, err := sqlite3.DB(dbPath, testPassword)
+	if err != nil {
+		t.Fatalf("unexpected error opening database: %v", err)
+	}
+
+	s := NewStore(conn)
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error closing store: %v", err)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("unexpected error removing %v: %v", dbPath+suffix, err)
+		}
+	}
+
+	if _, err := prepFileStore(t, dbPath).GetCommandByName("greet"); err != nil {
+		t.Fatalf("expected the command after reopening, got %v", err)
+	}
+}
+
+func TestClose_Transaction(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	// The transaction belongs to the test, so Close leaves it usable
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error closing store: %v", err)
+	}
+
+	if _, err := s.ListCommands(); err != nil {
+		t.Fatalf("expected the store to stay usable, got %v", err)
 	}
 }
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/h3jfc/shed/db"
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+// FindDuplicateBodies groups commands whose bodies are the same once
+// normalized with brackets.ParseCommand. It maps each shared body to the
+// names of its commands, oldest first. Commands with a unique body are left
+// out.
+func (s *Store) FindDuplicateBodies() (map[string][]string, error) {
+	groups, err := s.duplicateGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	dups := make(map[string][]string, len(groups))
+
+	for body, cmds := range groups {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.Name)
+		}
+
+		dups[body] = names
+	}
+
+	return dups, nil
+}
+
+// DeduplicateCommands merges each group of duplicate commands into its oldest
+// command. The others are moved to the trash and their names, along with
+// their aliases, become aliases of the kept command, so they still run. It
+// maps each kept name to the names merged into it. All groups are merged in
+// one transaction.
+func (s *Store) DeduplicateCommands() (map[string][]string, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	merged := map[string][]string{}
+
+	err := s.withTx(func(tx *Store) error {
+		groups, err := tx.duplicateGroups()
+		if err != nil {
+			return err
+		}
+
+		// Merge in a stable order, so errors are reproducible
+		for _, body := range slices.Sorted(maps.Keys(groups)) {
+			keep, dups := groups[body][0], groups[body][1:]
+
+			for _, dup := range dups {
+				if err := tx.mergeInto(keep, dup); err != nil {
+					return err
+				}
+
+				merged[keep.Name] = append(merged[keep.Name], dup.Name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// duplicateGroups maps each normalized body shared by more than one command to
+// those commands, ordered by ID.
+func (s *Store) duplicateGroups() (map[string][]Command, error) {
+	cmds, err := s.ListCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]Command{}
+
+	for _, cmd := range cmds {
+		body, err := brackets.ParseCommand(cmd.Command)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize command %q: %w", cmd.Name, err)
+		}
+
+		groups[body] = append(groups[body], cmd)
+	}
+
+	maps.DeleteFunc(groups, func(_ string, cc []Command) bool { return len(cc) < 2 })
+
+	for _, cc := range groups {
+		slices.SortFunc(cc, func(a, b Command) int { return cmp.Compare(a.ID, b.ID) })
+	}
+
+	return groups, nil
+}
+
+// mergeInto moves dup to the trash and points its name and aliases at keep.
+func (s *Store) mergeInto(keep, dup Command) error {
+	err := s.queries.MoveAliases(context.Background(), db.MoveAliasesParams{
+		CommandID:   keep.ID,
+		CommandID_2: dup.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move aliases of %q: %w", dup.Name, err)
+	}
+
+	if _, err := s.SoftDeleteCommand(dup.Name); err != nil {
+		return fmt.Errorf("failed to remove duplicate %q: %w", dup.Name, err)
+	}
+
+	if _, err := s.AddAlias(dup.Name, keep.Name); err != nil {
+		return fmt.Errorf("failed to alias %q to %q: %w", dup.Name, keep.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateBodies(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, c := range [][2]string{
+		{"greet", "echo  {{ name | who }}"},
+		{"hello", "echo {{name|who}}"},
+		{"list", "ls -la"},
+	} {
+		if _, err := s.AddCommand(c[0], c[1], ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	got, err := s.FindDuplicateBodies()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{"echo {{name|who}}": {"greet", "hello"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDeduplicateCommands(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"greet", "hello", "hi"} {
+		if _, err := s.AddCommand(name, "echo {{name}}", ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	if _, err := s.AddCommand("list", "ls -la", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.AddAlias("hey", "hi"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	merged, err := s.DeduplicateCommands()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{"greet": {"hello", "hi"}}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("expected %v, got %v", want, merged)
+	}
+
+	cmds, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmds) != 2 {
+		t.Fatalf("expected %d commands left, got %d", 2, len(cmds))
+	}
+
+	// Merged names and their aliases now resolve to the kept command
+	for _, name := range []string{"hello", "hi", "hey"} {
+		resolved, err := s.ResolveName(name)
+		if err != nil {
+			t.Fatalf("unexpected error resolving %q: %v", name, err)
+		}
+
+		if resolved != "greet" {
+			t.Fatalf("expected %q to resolve to %v, got %v", name, "greet", resolved)
+		}
+	}
+
+	dups, err := s.FindDuplicateBodies()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(dups) != 0 {
+		t.Fatalf("expected no duplicates left, got %v", dups)
+	}
+}
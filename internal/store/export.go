@@ -0,0 +1,108 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// ExportFileExt is the extension of the per-command files written by
+	// ExportToDir.
+	ExportFileExt = ".json"
+
+	exportDirPerms = 0o755
+	// Env values can hold credentials, so exported files are private.
+	exportFilePerms = 0o600
+)
+
+// ExportToDir writes every command to its own <name>.json file in dir, in the
+// format shed describe --format json prints, so each command can be kept and
+// diffed on its own, as in a git repository. Each file holds the command body
+// as it was entered, so importing it gives the same command. dir is created if
+// needed. Existing files of exported commands are replaced; other files are
+// left alone. It returns the paths written, in command name order.
+func (s *Store) ExportToDir(dir string) ([]string, error) {
+	cmds, err := s.ListCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, exportDirPerms); err != nil {
+		return nil, fmt.Errorf("failed to create export directory %s: %w", dir, err)
+	}
+
+	paths := make([]string, 0, len(cmds))
+
+	for _, cmd := range cmds {
+		bb, err := json.MarshalIndent(ExportedCommand{
+			Version:     ExportVersion,
+			Name:        cmd.Name,
+			Command:     cmd.rawBody(),
+			Description: cmd.Description,
+			Parameters:  cmd.Parameters,
+			Env:         cmd.Env,
+		}, "", "  ")
+		if err != nil {
+			return paths, fmt.Errorf("failed to marshal command %q: %w", cmd.Name, err)
+		}
+
+		p := filepath.Join(dir, cmd.Name+ExportFileExt)
+
+		if err := os.WriteFile(p, append(bb, '\n'), exportFilePerms); err != nil {
+			return paths, fmt.Errorf("failed to write command %q: %w", cmd.Name, err)
+		}
+
+		paths = append(paths, p)
+	}
+
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// ReadExportDir reads the commands of every .json file in dir, in file name
+// order. Each file is read with ParseExport, so it may hold one command or an
+// array of them, from this or an older version of shed. Subdirectories and
+// other files are ignored.
+func ReadExportDir(dir string) ([]Command, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export directory: %w", err)
+	}
+
+	var cmds []Command
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ExportFileExt {
+			continue
+		}
+
+		bb, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export file: %w", err)
+		}
+
+		cc, err := ParseExport(bb)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		cmds = append(cmds, cc...)
+	}
+
+	return cmds, nil
+}
+
+// ImportFromDir imports the commands of a directory written by ExportToDir.
+// See ReadExportDir and ImportCommands.
+func (s *Store) ImportFromDir(dir string, opts ImportOptions) (*ImportResult, error) {
+	cmds, err := ReadExportDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ImportCommands(cmds, opts)
+}
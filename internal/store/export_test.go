@@ -0,0 +1,173 @@
+package store
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportToDir_RoundTrip(t *testing.T) {
+	t.Parallel()
+	src := prepNewStore(t)
+
+	bodies := map[string]string{
+		"deploy": "deploy {{env|target environment}} --token {{!token}}",
+		"greet":  "echo hello {{name}}",
+		"uptime": "uptime",
+	}
+
+	for name, body := range bodies {
+		if _, err := src.AddCommand(name, body, name+" description"); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	if _, err := src.SetCommandEnv("deploy", map[string]string{"REGION": "eu"}); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "commands")
+
+	paths, err := src.ExportToDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error exporting commands: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "deploy.json"),
+		filepath.Join(dir, "greet.json"),
+		filepath.Join(dir, "uptime.json"),
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("expected files %v, got %v", want, paths)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading export directory: %v", err)
+	}
+
+	if len(entries) != len(bodies) {
+		t.Fatalf("expected %d files, got %d", len(bodies), len(entries))
+	}
+
+	dst := prepFileStore(t, prepDBFile(t))
+
+	result, err := dst.ImportFromDir(dir, ImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error importing commands: %v", err)
+	}
+
+	if !slices.Equal(result.Added, []string{"deploy", "greet", "uptime"}) {
+		t.Fatalf("expected every command to be added, got %+v", result)
+	}
+
+	for name, body := range bodies {
+		cmd, err := dst.GetCommandByName(name)
+		if err != nil {
+			t.Fatalf("unexpected error getting command %q: %v", name, err)
+		}
+
+		if cmd.Command != body || cmd.Description != name+" description" {
+			t.Fatalf("expected %q to round trip, got %q, %q", name, cmd.Command, cmd.Description)
+		}
+	}
+
+	cmd, err := dst.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if desc, _ := cmd.Parameters.Description("env"); desc != "target environment" {
+		t.Fatalf("expected parameter description to round trip, got %q", desc)
+	}
+
+	if !maps.Equal(cmd.Env, map[string]string{"REGION": "eu"}) {
+		t.Fatalf("expected env to round trip, got %v", cmd.Env)
+	}
+}
+
+func TestExportToDir_RawBody(t *testing.T) {
+	t.Parallel()
+	src := prepNewStore(t)
+
+	raw := "  ls   -la    {{ path | directory path }}  "
+
+	if _, err := src.AddCommand("list_files", raw, ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	if _, err := src.ExportToDir(dir); err != nil {
+		t.Fatalf("unexpected error exporting commands: %v", err)
+	}
+
+	dst := prepFileStore(t, prepDBFile(t))
+
+	if _, err := dst.ImportFromDir(dir, ImportOptions{}); err != nil {
+		t.Fatalf("unexpected error importing commands: %v", err)
+	}
+
+	got, err := dst.GetCommandRaw("list_files")
+	if err != nil {
+		t.Fatalf("unexpected error getting raw command: %v", err)
+	}
+
+	if got != raw {
+		t.Fatalf("expected raw body %q, got %q", raw, got)
+	}
+}
+
+func TestExportToDir_KeepsOtherFiles(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	dir := t.TempDir()
+	readme := filepath.Join(dir, "README.md")
+
+	if err := os.WriteFile(readme, []byte("commands"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := s.ExportToDir(dir); err != nil {
+		t.Fatalf("unexpected error exporting commands: %v", err)
+	}
+
+	if _, err := os.Stat(readme); err != nil {
+		t.Fatalf("expected other files to be kept, got %v", err)
+	}
+
+	cmds, err := ReadExportDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading export directory: %v", err)
+	}
+
+	if len(cmds) != 1 || cmds[0].Name != "greet" {
+		t.Fatalf("expected only greet to be read, got %v", cmds)
+	}
+}
+
+func TestReadExportDir_Err(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := ReadExportDir(dir); err == nil {
+		t.Fatal("expected an error for a malformed file")
+	}
+
+	if _, err := ReadExportDir(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+// ExportVersion is the version of the export format, the JSON printed by shed
+// describe --format json. Version 1 exports carry no version field.
+const ExportVersion = 2
+
+var ErrUnsupportedExportVersion = errors.New("unsupported export version")
+
+// ExportedCommand is one command in an export. Other fields are ignored.
+type ExportedCommand struct {
+	Version     int                 `json:"version"`
+	Name        string              `json:"name"`
+	Command     string              `json:"command"`
+	Description string              `json:"description"`
+	Parameters  brackets.Parameters `json:"parameters"`
+	Env         map[string]string   `json:"env"`
+}
+
+// ImportOptions controls how ImportCommands treats a command whose name is
+// already taken.
+type ImportOptions struct {
+	// Overwrite replaces the existing command. Without it the imported one is
+	// skipped.
+	Overwrite bool
+	// MergeDescriptions replaces the existing command like Overwrite, but keeps
+	// the longer of the existing and imported description of each parameter.
+	MergeDescriptions bool
+}
+
+// ImportResult lists the names of the commands ImportCommands added, replaced,
+// and skipped, in import order.
+type ImportResult struct {
+	Added   []string
+	Updated []string
+	Skipped []string
+}
+
+// ImportCommands adds cmds to the store. Parameters are parsed from each
+// command body; descriptions listed in Parameters win when they are longer than
+// the ones in the body. A replaced command takes the env of the imported one,
+// which clears it when the import has none. Commands are imported in one
+// transaction: when one fails, none are imported.
+func (s *Store) ImportCommands(cmds []Command, opts ImportOptions) (*ImportResult, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+
+	err := s.withTx(func(tx *Store) error {
+		for _, cmd := range cmds {
+			done, err := tx.importCommand(cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			switch done {
+			case AuditOpAddCommand:
+				result.Added = append(result.Added, cmd.Name)
+			case AuditOpUpdateCommand:
+				result.Updated = append(result.Updated, cmd.Name)
+			default:
+				result.Skipped = append(result.Skipped, cmd.Name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importCommand writes one imported command with its env and an audit entry.
+// It returns the audit op of the write, or "" when the command was skipped.
+func (s *Store) importCommand(cmd Command, opts ImportOptions) (string, error) {
+	if err := ValidateName(cmd.Name); err != nil {
+		return "", fmt.Errorf("command %q: %w", cmd.Name, err)
+	}
+
+	if err := ValidateEnv(cmd.Env); err != nil {
+		return "", fmt.Errorf("env of command %q: %w", cmd.Name, err)
+	}
+
+	b, err := brackets.Parse(cmd.Command)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse command %q for parameters: %w", cmd.Name, err)
+	}
+
+	params := *b.Parameters
+	params.ThreeWayMerge(nil, &cmd.Parameters)
+
+	exists, err := s.CommandExists(cmd.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var written *Command
+
+	op := AuditOpUpdateCommand
+
+	switch {
+	case !exists:
+		op = AuditOpAddCommand
+		written, err = s.createCommand(cmd.Name, b.Command, cmd.Command, cmd.Description, params)
+	case opts.MergeDescriptions || opts.Overwrite:
+		existing, getErr := s.GetCommandByName(cmd.Name)
+		if getErr != nil {
+			return "", getErr
+		}
+
+		if opts.MergeDescriptions {
+			// No common ancestor is kept, so every parameter counts as changed
+			// on both sides and the longer description wins
+			params.ThreeWayMerge(nil, &existing.Parameters)
+		}
+
+		written, err = s.updateCommand(existing.ID, cmd.Name, b.Command, cmd.Command, cmd.Description, params)
+	default:
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to import command %q: %w", cmd.Name, err)
+	}
+
+	if _, err := s.updateEnv(written.ID, cmd.Env); err != nil {
+		return "", fmt.Errorf("failed to import env of command %q: %w", cmd.Name, err)
+	}
+
+	return op, s.audit(op, cmd.Name, "imported")
+}
+
+// ImportFromLegacyFormat imports the commands of an export written by this or
+// an older version of shed, upgrading them to the current format first. See
+// ParseExport.
+func (s *Store) ImportFromLegacyFormat(bb []byte, opts ImportOptions) (*ImportResult, error) {
+	cmds, err := ParseExport(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ImportCommands(cmds, opts)
+}
+
+// ParseExport decodes an export holding one command object or an array of
+// them. Each entry is upgraded to ExportVersion; an entry from a newer or
+// unknown version is ErrUnsupportedExportVersion.
+func ParseExport(bb []byte) ([]Command, error) {
+	var entries []ExportedCommand
+
+	if trimmed := bytes.TrimSpace(bb); len(trimmed) > 0 && trimmed[0] == '{' {
+		var entry ExportedCommand
+		if err := json.Unmarshal(trimmed, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse export: %w", err)
+		}
+
+		entries = append(entries, entry)
+	} else if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+
+	cmds := make([]Command, 0, len(entries))
+
+	for _, e := range entries {
+		e, err := upgradeExportedCommand(e)
+		if err != nil {
+			return nil, err
+		}
+
+		cmds = append(cmds, Command{
+			Name:        e.Name,
+			Command:     e.Command,
+			Description: e.Description,
+			Parameters:  e.Parameters,
+			Env:         e.Env,
+		})
+	}
+
+	return cmds, nil
+}
+
+// upgradeExportedCommand brings e up to ExportVersion one version at a time.
+func upgradeExportedCommand(e ExportedCommand) (ExportedCommand, error) {
+	switch e.Version {
+	case 0, 1:
+		// Version 1 has no version field, and leaves env and parameters out
+		// or null when a command has none.
+		if e.Env == nil {
+			e.Env = map[string]string{}
+		}
+
+		if e.Parameters == nil {
+			e.Parameters = brackets.Parameters{}
+		}
+
+		e.Version = 2
+
+		fallthrough
+	case ExportVersion:
+		return e, nil
+	default:
+		return e, fmt.Errorf("%w %d for command %q, this shed reads up to version %d",
+			ErrUnsupportedExportVersion, e.Version, e.Name, ExportVersion)
+	}
+}
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/h3jfc/shed/lib/brackets"
+)
+
+func TestImportCommands_MergeDescriptions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		existing string
+		imported string
+		want     string
+	}{
+		"imported longer kept": {
+			existing: "deploy {{env|target}}",
+			imported: "deploy {{env|target environment}}",
+			want:     "target environment",
+		},
+		"existing longer kept": {
+			existing: "deploy {{env|target environment}}",
+			imported: "deploy {{env|target}}",
+			want:     "target environment",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := prepNewStore(t)
+
+			if _, err := s.AddCommand("deploy", tc.existing, "deploy"); err != nil {
+				t.Fatalf("unexpected error adding command: %v", err)
+			}
+
+			cmds := []Command{{Name: "deploy", Command: tc.imported, Description: "imported"}}
+
+			result, err := s.ImportCommands(cmds, ImportOptions{MergeDescriptions: true})
+			if err != nil {
+				t.Fatalf("unexpected error importing commands: %v", err)
+			}
+
+			if !slices.Equal(result.Updated, []string{"deploy"}) {
+				t.Fatalf("expected deploy to be updated, got %+v", result)
+			}
+
+			cmd, err := s.GetCommandByName("deploy")
+			if err != nil {
+				t.Fatalf("unexpected error getting command: %v", err)
+			}
+
+			if desc, _ := cmd.Parameters.Description("env"); desc != tc.want {
+				t.Fatalf("expected description %q, got %q", tc.want, desc)
+			}
+
+			if cmd.Description != "imported" {
+				t.Fatalf("expected description %q, got %q", "imported", cmd.Description)
+			}
+		})
+	}
+}
+
+func TestImportCommands_Overwrite(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "deploy {{env|target environment}}", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	cmds := []Command{{Name: "deploy", Command: "deploy {{env|target}}", Description: "imported"}}
+
+	if _, err := s.ImportCommands(cmds, ImportOptions{Overwrite: true}); err != nil {
+		t.Fatalf("unexpected error importing commands: %v", err)
+	}
+
+	cmd, err := s.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if desc, _ := cmd.Parameters.Description("env"); desc != "target" {
+		t.Fatalf("expected description %q, got %q", "target", desc)
+	}
+}
+
+func TestImportCommands_OverwriteReplacesEnv(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "deploy", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.SetCommandEnv("deploy", map[string]string{"REGION": "eu"}); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	cmds := []Command{{Name: "deploy", Command: "deploy --force"}}
+
+	if _, err := s.ImportCommands(cmds, ImportOptions{Overwrite: true}); err != nil {
+		t.Fatalf("unexpected error importing commands: %v", err)
+	}
+
+	cmd, err := s.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if len(cmd.Env) != 0 {
+		t.Fatalf("expected the old env to be cleared, got %v", cmd.Env)
+	}
+}
+
+func TestImportCommands_AllOrNothing(t *testing.T) {
+	t.Parallel()
+	s := prepFileStore(t, prepDBFile(t))
+
+	cmds := []Command{
+		{Name: "greet", Command: "echo {{name}}"},
+		{Name: "deploy", Command: "deploy", Env: map[string]string{"BAD NAME": "x"}},
+	}
+
+	if _, err := s.ImportCommands(cmds, ImportOptions{}); !errors.Is(err, ErrInvalidEnvName) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidEnvName, err)
+	}
+
+	cmdList, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmdList) != 0 {
+		t.Fatalf("expected a failed import to add nothing, got %v", cmdList)
+	}
+
+	entries, err := s.AuditLog(0)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("expected a failed import to record nothing, got %v", entries)
+	}
+}
+
+func TestImportCommands_AddAndSkip(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("deploy", "deploy {{env}}", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	cmds := []Command{
+		{Name: "deploy", Command: "deploy --force {{env}}"},
+		{
+			Name:       "greet",
+			Command:    "echo {{name}}",
+			Parameters: brackets.Parameters{{Name: "name", Description: "who to greet"}},
+			Env:        map[string]string{"LANG": "C"},
+		},
+	}
+
+	result, err := s.ImportCommands(cmds, ImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error importing commands: %v", err)
+	}
+
+	if !slices.Equal(result.Added, []string{"greet"}) || !slices.Equal(result.Skipped, []string{"deploy"}) {
+		t.Fatalf("expected greet added and deploy skipped, got %+v", result)
+	}
+
+	greet, err := s.GetCommandByName("greet")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if desc, _ := greet.Parameters.Description("name"); desc != "who to greet" {
+		t.Fatalf("expected description %q, got %q", "who to greet", desc)
+	}
+
+	if greet.Env["LANG"] != "C" {
+		t.Fatalf("expected env LANG=C, got %v", greet.Env)
+	}
+
+	entries, err := s.AuditLog(1)
+	if err != nil {
+		t.Fatalf("unexpected error reading audit log: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Op != AuditOpAddCommand || entries[0].Target != "greet" ||
+		entries[0].Detail != "imported" {
+		t.Fatalf("expected the import of greet to be recorded, got %v", entries)
+	}
+
+	deploy, err := s.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if deploy.Command != "deploy {{env}}" {
+		t.Fatalf("expected skipped command to be unchanged, got %q", deploy.Command)
+	}
+}
+
+func TestImportCommands_ErrInvalidCommandName(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.ImportCommands([]Command{{Name: "bad name", Command: "ls"}}, ImportOptions{})
+	if !errors.Is(err, ErrInvalidCommandName) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidCommandName, err)
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  []string
+	}{
+		"single object": {
+			input: `{"id":3,"name":"deploy","command":"deploy {{env}}","parameters":[{"name":"env","description":"target"}]}`,
+			want:  []string{"deploy"},
+		},
+		"array": {
+			input: ` [{"name":"deploy","command":"deploy"},{"name":"greet","command":"echo hi"}]`,
+			want:  []string{"deploy", "greet"},
+		},
+		"empty array": {input: `[]`, want: []string{}},
+		"current version": {
+			input: `{"version":2,"name":"deploy","command":"deploy","parameters":[],"env":{}}`,
+			want:  []string{"deploy"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := ParseExport([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(cmds) != len(tc.want) {
+				t.Fatalf("expected %d commands, got %d", len(tc.want), len(cmds))
+			}
+
+			for i, cmd := range cmds {
+				if cmd.Name != tc.want[i] {
+					t.Fatalf("expected command %q, got %q", tc.want[i], cmd.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseExport_Err(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{`not json`, `{"name":`, `"deploy"`} {
+		if _, err := ParseExport([]byte(input)); err == nil {
+			t.Fatalf("expected an error for %q, got nil", input)
+		}
+	}
+}
+
+func TestImportFromLegacyFormat_V1(t *testing.T) {
+	t.Parallel()
+
+	s := prepNewStore(t)
+
+	// A version 1 export has no version field and null parameters and env.
+	v1 := `[{"id":1,"name":"deploy","command":"deploy {{env|target}}","description":"ship it","parameters":null,"env":null}]`
+
+	result, err := s.ImportFromLegacyFormat([]byte(v1), ImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(result.Added, []string{"deploy"}) {
+		t.Fatalf("expected %v, got %v", []string{"deploy"}, result.Added)
+	}
+
+	cmd, err := s.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if cmd.Description != "ship it" {
+		t.Fatalf("expected %v, got %v", "ship it", cmd.Description)
+	}
+
+	if len(cmd.Parameters) != 1 || cmd.Parameters[0].Description != "target" {
+		t.Fatalf("expected parameters parsed from the command, got %v", cmd.Parameters)
+	}
+}
+
+func TestImportFromLegacyFormat_UnknownVersion(t *testing.T) {
+	t.Parallel()
+
+	s := prepNewStore(t)
+
+	for _, version := range []string{"99", "-1"} {
+		export := `{"version":` + version + `,"name":"deploy","command":"deploy"}`
+
+		if _, err := s.ImportFromLegacyFormat([]byte(export), ImportOptions{}); !errors.Is(err, ErrUnsupportedExportVersion) {
+			t.Fatalf("expected %v, got %v", ErrUnsupportedExportVersion, err)
+		}
+	}
+
+	if _, err := s.GetCommandByName("deploy"); err == nil {
+		t.Fatal("expected no command to be imported")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/h3jfc/shed/lib/sqlite3"
@@ -12,6 +13,8 @@ import (
 
 var database *sql.DB
 
+const testPassword = "test_password"
+
 const dbname = "file::memory:?cache=shared&_journal_mode=WAL&_busy_timeout=10000"
 
 func TestMain(m *testing.M) {
@@ -67,3 +70,33 @@ func prepNewStore(t *testing.T) *Store {
 
 	return NewStore(tx)
 }
+
+// prepDBFile migrates a fresh encrypted database file for tests that need
+// their own connections, and returns its path.
+func prepDBFile(t *testing.T) string {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "shed.db")
+
+	if err := sqlite3.MigrateShedDB(dbPath, testPassword); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return dbPath
+}
+
+// prepFileStore opens a store on its own connection to dbPath.
+func prepFileStore(t *testing.T, dbPath string) *Store {
+	t.Helper()
+
+	conn, err := sqlite3.DB(dbPath, testPassword)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return NewStore(conn)
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+func TestMigrations_UniqueNameIndexes(t *testing.T) {
+	t.Parallel()
+
+	dbPath := prepDBFile(t)
+
+	conn, err := sqlite3.DB(dbPath, testPassword)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	tests := map[string]struct {
+		table  string
+		index  string
+		insert string
+	}{
+		"commands": {
+			table:  "commands",
+			index:  "idx_commands_name",
+			insert: "INSERT INTO commands (name, command, description, parameters) VALUES ('deploy', 'echo', '', CAST('[]' AS BLOB))",
+		},
+		"secrets": {
+			table:  "secrets",
+			index:  "idx_secrets_key",
+			insert: "INSERT INTO secrets (key, value, description) VALUES ('api_key', 'value', '')",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var unique bool
+
+			row := conn.QueryRow(`SELECT "unique" FROM pragma_index_list(?) WHERE name = ?`, tc.table, tc.index)
+			if err := row.Scan(&unique); err != nil {
+				t.Fatalf("expected index %s on %s, got %v", tc.index, tc.table, err)
+			}
+
+			if !unique {
+				t.Fatalf("expected index %s to be unique", tc.index)
+			}
+
+			if _, err := conn.Exec(tc.insert); err != nil {
+				t.Fatalf("expected first insert to succeed, got %v", err)
+			}
+
+			if _, err := conn.Exec(tc.insert); !sqlite3.IsUniqueViolation(err) {
+				t.Fatalf("expected a unique violation, got %v", err)
+			}
+		})
+	}
+}
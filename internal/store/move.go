@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+)
+
+// MoveCommand copies the named command, with its parameters and environment,
+// from src to dst and then removes it from src. An existing command of the same
+// name in dst is replaced only when overwrite is set. The command is written to
+// dst in one transaction, and the source is removed last, so a failure to write
+// dst never loses it. Both stores record the move in their audit log.
+func MoveCommand(src, dst *Store, name string, overwrite bool) error {
+	if err := src.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := dst.checkWritable(); err != nil {
+		return err
+	}
+
+	cmd, err := src.GetCommandByName(name)
+	if err != nil {
+		return fmt.Errorf("command %q does not exist: %w", name, ErrCommandNotFound)
+	}
+
+	// name may be an alias in src, so the command moves under its own name
+	name = cmd.Name
+
+	exists, err := dst.CommandExists(name)
+	if err != nil {
+		return err
+	}
+
+	if exists && !overwrite {
+		return fmt.Errorf("command with name %q already exists in destination: %w", name, ErrAlreadyExists)
+	}
+
+	err = dst.withTx(func(tx *Store) error {
+		op := AuditOpAddCommand
+
+		var written *Command
+
+		if exists {
+			existing, err := tx.GetCommandByName(name)
+			if err != nil {
+				return err
+			}
+
+			op = AuditOpUpdateCommand
+			written, err = tx.updateCommand(existing.ID, name, cmd.Command, cmd.RawCommand, cmd.Description, cmd.Parameters)
+			if err != nil {
+				return err
+			}
+		} else {
+			written, err = tx.createCommand(name, cmd.Command, cmd.RawCommand, cmd.Description, cmd.Parameters)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.updateEnv(written.ID, cmd.Env); err != nil {
+			return err
+		}
+
+		return tx.audit(op, name, auditDetailMovedIn)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write command to destination: %w", err)
+	}
+
+	if _, err := src.removeCommand(name, auditDetailMovedOut); err != nil {
+		return fmt.Errorf("failed to remove command from source: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func prepMoveStores(t *testing.T) (*Store, *Store) {
+	t.Helper()
+
+	src := prepFileStore(t, prepDBFile(t))
+	dst := prepFileStore(t, prepDBFile(t))
+
+	if _, err := src.AddCommand("deploy", "deploy {{env|target}}", "deploys the app"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := src.SetCommandEnv("deploy", map[string]string{"REGION": "eu"}); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	return src, dst
+}
+
+func TestMoveCommand_OK(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if err := MoveCommand(src, dst, "deploy", false); err != nil {
+		t.Fatalf("unexpected error moving command: %v", err)
+	}
+
+	if _, err := src.GetCommandByName("deploy"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+
+	cmd, err := dst.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting moved command: %v", err)
+	}
+
+	if cmd.Command != "deploy {{env|target}}" || cmd.Description != "deploys the app" {
+		t.Fatalf("expected moved command %v, got %v", "deploy {{env|target}}", cmd.Command)
+	}
+
+	if len(cmd.Parameters) != 1 || cmd.Parameters[0].Description != "target" {
+		t.Fatalf("expected parameters to be moved, got %v", cmd.Parameters)
+	}
+
+	if !maps.Equal(cmd.Env, map[string]string{"REGION": "eu"}) {
+		t.Fatalf("expected env to be moved, got %v", cmd.Env)
+	}
+}
+
+func TestMoveCommand_CollisionKeepsSource(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if _, err := dst.AddCommand("deploy", "echo other", "other"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	err := MoveCommand(src, dst, "deploy", false)
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected error %v, got %v", ErrAlreadyExists, err)
+	}
+
+	if _, err := src.GetCommandByName("deploy"); err != nil {
+		t.Fatalf("expected source command to be kept, got %v", err)
+	}
+
+	cmd, err := dst.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if cmd.Command != "echo other" {
+		t.Fatalf("expected destination command %v, got %v", "echo other", cmd.Command)
+	}
+}
+
+func TestMoveCommand_Overwrite(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if _, err := dst.AddCommand("deploy", "echo other", "other"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if err := MoveCommand(src, dst, "deploy", true); err != nil {
+		t.Fatalf("unexpected error moving command: %v", err)
+	}
+
+	if _, err := src.GetCommandByName("deploy"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+
+	cmd, err := dst.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting moved command: %v", err)
+	}
+
+	if cmd.Command != "deploy {{env|target}}" || cmd.Description != "deploys the app" {
+		t.Fatalf("expected moved command %v, got %v", "deploy {{env|target}}", cmd.Command)
+	}
+}
+
+func TestMoveCommand_ErrCommandNotFound(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if err := MoveCommand(src, dst, "missing", false); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestMoveCommand_ByAlias(t *testing.T) {
+	t.Parallel()
+	src, dst := prepMoveStores(t)
+
+	if _, err := src.AddAlias("d", "deploy"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if err := MoveCommand(src, dst, "d", false); err != nil {
+		t.Fatalf("unexpected error moving command: %v", err)
+	}
+
+	if exists, err := src.CommandExists("deploy"); err != nil || exists {
+		t.Fatalf("expected the command to be removed from source, got %v, %v", exists, err)
+	}
+
+	cmd, err := dst.GetCommandByName("deploy")
+	if err != nil {
+		t.Fatalf("unexpected error getting moved command: %v", err)
+	}
+
+	if !maps.Equal(cmd.Env, map[string]string{"REGION": "eu"}) {
+		t.Fatalf("expected env to be moved, got %v", cmd.Env)
+	}
+}
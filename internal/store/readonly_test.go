@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+func prepReadOnlyStore(t *testing.T) *Store {
+	t.Helper()
+
+	dbPath := prepDBFile(t)
+
+	rw, err := sqlite3.DB(dbPath, testPassword)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	if _, err := NewStore(rw).AddCommand("greet", "echo hello {{name}}", "says hello"); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if err := rw.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	ro, err := sqlite3.DBReadOnly(dbPath, testPassword)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		ro.Close()
+	})
+
+	return NewReadOnlyStore(ro)
+}
+
+func TestReadOnlyStore_Reads(t *testing.T) {
+	t.Parallel()
+	s := prepReadOnlyStore(t)
+
+	cmd, err := s.GetCommandByName("greet")
+	if err != nil {
+		t.Fatalf("unexpected error getting command: %v", err)
+	}
+
+	if cmd.Command != "echo hello {{name}}" {
+		t.Fatalf("expected command %v, got %v", "echo hello {{name}}", cmd.Command)
+	}
+
+	cmds, err := s.ListCommands()
+	if err != nil {
+		t.Fatalf("unexpected error listing commands: %v", err)
+	}
+
+	if len(cmds) != 1 {
+		t.Fatalf("expected %v commands, got %v", 1, len(cmds))
+	}
+}
+
+func TestReadOnlyStore_WritesFail(t *testing.T) {
+	t.Parallel()
+	s := prepReadOnlyStore(t)
+
+	if _, err := s.AddCommand("bye", "echo bye", ""); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected error %v, got %v", ErrReadOnly, err)
+	}
+
+	if _, err := s.AddSecret(apiKey, "value", ""); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected error %v, got %v", ErrReadOnly, err)
+	}
+
+	// The handle itself refuses writes too, not just the store.
+	_, err := s.dbtx.ExecContext(context.Background(),
+		"INSERT INTO secrets (key, value, description) VALUES ('k', 'v', '')")
+	if err == nil {
+		t.Fatalf("expected write through read-only handle to fail")
+	}
+}
@@ -2,33 +2,89 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
 
 	"github.com/h3jfc/shed/db"
+	"github.com/h3jfc/shed/lib/brackets"
+	"github.com/h3jfc/shed/lib/sqlite3"
 )
 
-var ErrSecretNotFound = errors.New("secret not found")
+var (
+	ErrSecretNotFound = errors.New("secret not found")
+	ErrInvalidSort    = errors.New("invalid sort field")
+	ErrEmptySecret    = errors.New("secret value cannot be empty")
+)
 
 type Secret = db.Secret
 
+// SecretInfo is a secret without its value.
+type SecretInfo = db.ListSecretsPageRow
+
+// SecretSort is a field secrets can be ordered by.
+type SecretSort string
+
+const (
+	SecretSortKey     SecretSort = "key"
+	SecretSortCreated SecretSort = "created"
+	SecretSortUpdated SecretSort = "updated"
+)
+
+// ListSecretsOptions controls the ordering and paging of ListSecretsPaged.
+// An empty Sort keeps the default newest-first order. A zero Limit returns
+// every secret after Offset.
+type ListSecretsOptions struct {
+	Sort    SecretSort
+	Reverse bool
+	Limit   int
+	Offset  int
+}
+
+// ParseSecretSort converts a user-supplied field name into a SecretSort.
+func ParseSecretSort(s string) (SecretSort, error) {
+	switch SecretSort(s) {
+	case "", SecretSortKey, SecretSortCreated, SecretSortUpdated:
+		return SecretSort(s), nil
+	default:
+		return "", fmt.Errorf("%w: %q, must be one of key, created, updated", ErrInvalidSort, s)
+	}
+}
+
 func (s *Store) AddSecret(key, value, description string) (*Secret, error) {
-	if err := validateName(key); err != nil {
+	if err := s.checkWritable(); err != nil {
 		return nil, err
 	}
 
-	_, err := s.GetSecretByKey(key)
-	if err == nil {
-		return nil, fmt.Errorf("secret with key %q already exists: %w", key, ErrAlreadyExists)
+	if err := validateName(key); err != nil {
+		return nil, err
 	}
 
-	secret, err := s.queries.CreateSecret(context.Background(), db.CreateSecretParams{
-		Key:         key,
-		Value:       value,
-		Description: description,
+	var secret Secret
+
+	err := s.withTx(func(tx *Store) error {
+		var err error
+
+		secret, err = tx.queries.CreateSecret(context.Background(), db.CreateSecretParams{
+			Key:         key,
+			Value:       value,
+			Description: description,
+		})
+		if sqlite3.IsUniqueViolation(err) {
+			return fmt.Errorf("secret with key %q already exists: %w", key, ErrAlreadyExists)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
+
+		return tx.audit(AuditOpAddSecret, key, "")
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create secret: %w", err)
+		return nil, err
 	}
 
 	return &secret, nil
@@ -43,45 +99,314 @@ func (s *Store) ListSecrets() ([]Secret, error) {
 	return secrets, nil
 }
 
+// ListSecretKeys returns the keys of all secrets in alphabetical order,
+// without loading their values.
+func (s *Store) ListSecretKeys() ([]string, error) {
+	keys, err := s.queries.ListSecretKeys(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secret keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// SecretsUsageReport maps each secret key to the sorted names of the commands
+// referencing it. Stored secrets no command uses map to an empty slice, and keys
+// referenced by commands but never stored are included too.
+func (s *Store) SecretsUsageReport() (map[string][]string, error) {
+	keys, err := s.ListSecretKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	report := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		report[key] = []string{}
+	}
+
+	cmds, err := s.ListCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		ss, err := brackets.ParseSecrets(cmd.Command)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse secrets of command %q: %w", cmd.Name, err)
+		}
+
+		for _, secret := range ss {
+			if !slices.Contains(report[secret.Key], cmd.Name) {
+				report[secret.Key] = append(report[secret.Key], cmd.Name)
+			}
+		}
+	}
+
+	for _, names := range report {
+		slices.Sort(names)
+	}
+
+	return report, nil
+}
+
+// GetSecretsForCommand returns the stored secrets cmd references and the keys
+// of those it references that are not stored, in the order they appear.
+func (s *Store) GetSecretsForCommand(cmd *Command) ([]Secret, []string, error) {
+	ss, err := brackets.ParseSecrets(cmd.Command)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse secrets of command %q: %w", cmd.Name, err)
+	}
+
+	if len(ss) == 0 {
+		return nil, nil, nil
+	}
+
+	keys := make([]string, 0, len(ss))
+	for _, secret := range ss {
+		keys = append(keys, secret.Key)
+	}
+
+	stored, err := s.GetSecretsByKeys(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var missing []string
+
+	for _, key := range keys {
+		if !slices.ContainsFunc(*stored, func(secret Secret) bool { return secret.Key == key }) {
+			missing = append(missing, key)
+		}
+	}
+
+	return *stored, missing, nil
+}
+
+// ListSecretsPaged lists secrets ordered by the given field, then by ID so
+// secrets created in the same second keep a stable order. Ordering and paging
+// happen in the database and secret values are never loaded.
+func (s *Store) ListSecretsPaged(opts ListSecretsOptions) ([]SecretInfo, error) {
+	if _, err := ParseSecretSort(string(opts.Sort)); err != nil {
+		return nil, err
+	}
+
+	params := db.ListSecretsPageParams{
+		Sort:       string(opts.Sort),
+		Descending: opts.Reverse,
+		Limit:      int64(opts.Limit),
+		Offset:     int64(max(opts.Offset, 0)),
+	}
+
+	// The default order is newest first
+	if opts.Sort == "" {
+		params.Descending = !opts.Reverse
+	}
+
+	// SQLite treats a negative limit as no limit
+	if opts.Limit <= 0 {
+		params.Limit = -1
+	}
+
+	secrets, err := s.queries.ListSecretsPage(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
 func (s *Store) UpdateSecret(key, value, description string) (*Secret, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	if err := validateName(key); err != nil {
 		return nil, err
 	}
 
 	prev, err := s.GetSecretByKey(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update secret: %w", ErrSecretNotFound)
+		return nil, fmt.Errorf("failed to update secret: %w", err)
 	}
 
-	secret, err := s.queries.UpdateSecret(context.Background(), db.UpdateSecretParams{
-		ID:          prev.ID,
-		Value:       value,
-		Description: description,
-		Key:         key,
+	var secret Secret
+
+	err = s.withTx(func(tx *Store) error {
+		secret, err = tx.queries.UpdateSecret(context.Background(), db.UpdateSecretParams{
+			ID:          prev.ID,
+			Value:       value,
+			Description: description,
+			Key:         key,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update secret: %w", err)
+		}
+
+		return tx.audit(AuditOpUpdateSecret, key, secretChanges(prev, value, description))
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update secret: %w", err)
+		return nil, err
 	}
 
 	return &secret, nil
 }
 
+// RotateSecret replaces the value of the existing secret key, keeping its
+// description, and returns the sorted names of the commands referencing it so
+// they can be checked with the new value. Both happen in one transaction.
+func (s *Store) RotateSecret(key, newValue string) ([]string, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if newValue == "" {
+		return nil, ErrEmptySecret
+	}
+
+	var dependents []string
+
+	err := s.withTx(func(tx *Store) error {
+		prev, err := tx.GetSecretByKey(key)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.queries.UpdateSecret(context.Background(), db.UpdateSecretParams{
+			ID:          prev.ID,
+			Value:       newValue,
+			Description: prev.Description,
+			Key:         key,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rotate secret: %w", err)
+		}
+
+		if err := tx.audit(AuditOpRotateSecret, key, ""); err != nil {
+			return err
+		}
+
+		report, err := tx.SecretsUsageReport()
+		if err != nil {
+			return err
+		}
+
+		dependents = report[key]
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dependents, nil
+}
+
+// AddSecretFromReader adds a secret whose value is read from r, so it never has
+// to appear on the command line. A single trailing newline is dropped.
+func (s *Store) AddSecretFromReader(key string, r io.Reader, description string) (*Secret, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	value, err := ReadSecretValue(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AddSecret(key, value, description)
+}
+
+// ReadSecretValue reads a secret value from r, dropping a single trailing
+// newline. An empty value is ErrEmptySecret.
+func ReadSecretValue(r io.Reader) (string, error) {
+	bb, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+
+	value := strings.TrimSuffix(strings.TrimSuffix(string(bb), "\n"), "\r")
+	if value == "" {
+		return "", ErrEmptySecret
+	}
+
+	return value, nil
+}
+
+// SetSecretValue stores value under key, creating the secret if it does not
+// exist and otherwise keeping its description.
+func (s *Store) SetSecretValue(key, value string) (*Secret, error) {
+	secret, _, err := s.SetSecret(key, value, "")
+
+	return secret, err
+}
+
+// SetSecret stores value under key, creating the secret if it does not exist
+// and updating it otherwise. An empty description keeps the existing one. It
+// reports whether the secret was created.
+func (s *Store) SetSecret(key, value, description string) (*Secret, bool, error) {
+	prev, err := s.GetSecretByKey(key)
+	if errors.Is(err, ErrSecretNotFound) {
+		secret, err := s.AddSecret(key, value, description)
+
+		return secret, err == nil, err
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if description == "" {
+		description = prev.Description
+	}
+
+	secret, err := s.UpdateSecret(key, value, description)
+
+	return secret, false, err
+}
+
 func (s *Store) RemoveSecret(key string) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
 	secret, err := s.GetSecretByKey(key)
 	if err != nil {
 		return fmt.Errorf("failed to get secret by key: %w", err)
 	}
 
-	err = s.queries.DeleteSecretByID(context.Background(), secret.ID)
-	if err != nil {
-		return fmt.Errorf("failed to delete secret: %w", err)
+	return s.withTx(func(tx *Store) error {
+		if err := tx.queries.DeleteSecretByID(context.Background(), secret.ID); err != nil {
+			return fmt.Errorf("failed to delete secret: %w", err)
+		}
+
+		return tx.audit(AuditOpRemoveSecret, key, "")
+	})
+}
+
+// secretChanges names the fields an update changes, for the audit log. It
+// says whether the value changed, never what it is.
+func secretChanges(prev *Secret, value, description string) string {
+	var changed []string
+
+	if prev.Value != value {
+		changed = append(changed, "value")
 	}
 
-	return nil
+	if prev.Description != description {
+		changed = append(changed, "description")
+	}
+
+	return strings.Join(changed, ", ")
 }
 
+// GetSecretByKey returns the secret stored under key, or an error wrapping
+// ErrSecretNotFound when there is none.
 func (s *Store) GetSecretByKey(key string) (*Secret, error) {
 	secret, err := s.queries.GetSecretByKey(context.Background(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %q", ErrSecretNotFound, key)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret by key: %w", err)
 	}
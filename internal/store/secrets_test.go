@@ -1,7 +1,11 @@
 package store
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"slices"
+	"sync"
 	"testing"
 )
 
@@ -31,6 +35,107 @@ func TestAddSecret_OK(t *testing.T) {
 	}
 }
 
+func TestAddSecretFromReader_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	secret, err := s.AddSecretFromReader(apiKey, bytes.NewBufferString("piped-value\n"), "piped")
+	if err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	got, err := s.GetSecretByKey(apiKey)
+	if err != nil {
+		t.Fatalf("unexpected error getting secret: %v", err)
+	}
+
+	if got.Value != "piped-value" || secret.Value != "piped-value" {
+		t.Fatalf("expected secret value %v, got %v", "piped-value", got.Value)
+	}
+
+	if got.Description != "piped" {
+		t.Fatalf("expected secret description %v, got %v", "piped", got.Description)
+	}
+}
+
+func TestAddSecretFromReader_Empty(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.AddSecretFromReader(apiKey, bytes.NewBufferString("\n"), "")
+	if !errors.Is(err, ErrEmptySecret) {
+		t.Fatalf("expected error %v, got %v", ErrEmptySecret, err)
+	}
+}
+
+func TestListSecretKeys(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, key := range []string{"zeta", "alpha", "mid"} {
+		if _, err := s.AddSecret(key, "value", ""); err != nil {
+			t.Fatalf("unexpected error adding secret: %v", err)
+		}
+	}
+
+	keys, err := s.ListSecretKeys()
+	if err != nil {
+		t.Fatalf("unexpected error listing secret keys: %v", err)
+	}
+
+	want := []string{"alpha", "mid", "zeta"}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("expected keys %v, got %v", want, keys)
+	}
+}
+
+func TestSecretsUsageReport(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, key := range []string{"used_key", "unused_key"} {
+		if _, err := s.AddSecret(key, "value", ""); err != nil {
+			t.Fatalf("unexpected error adding secret: %v", err)
+		}
+	}
+
+	cmds := map[string]string{
+		"deploy": "deploy --token {{!used_key}} {{!used_key}}",
+		"backup": "backup --token {{!used_key}} --db {{!missing_key}}",
+		"greet":  "echo hello {{name}}",
+	}
+	for name, command := range cmds {
+		if _, err := s.AddCommand(name, command, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	report, err := s.SecretsUsageReport()
+	if err != nil {
+		t.Fatalf("unexpected error building report: %v", err)
+	}
+
+	want := map[string][]string{
+		"used_key":    {"backup", "deploy"},
+		"unused_key":  {},
+		"missing_key": {"backup"},
+	}
+
+	if len(report) != len(want) {
+		t.Fatalf("expected report %v, got %v", want, report)
+	}
+
+	for key, names := range want {
+		if !slices.Equal(report[key], names) {
+			t.Fatalf("expected %v for %v, got %v", names, key, report[key])
+		}
+	}
+
+	if report["unused_key"] == nil {
+		t.Fatalf("expected unused secret to map to an empty slice, got nil")
+	}
+}
+
 func TestAddSecret_OKMaxLength(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
@@ -45,6 +150,46 @@ func TestAddSecret_OKMaxLength(t *testing.T) {
 	}
 }
 
+func TestAddSecret_ConcurrentSameKey(t *testing.T) {
+	t.Parallel()
+
+	dbPath := prepDBFile(t)
+	stores := []*Store{prepFileStore(t, dbPath), prepFileStore(t, dbPath)}
+
+	var wg sync.WaitGroup
+
+	start := make(chan struct{})
+	errs := make([]error, len(stores))
+
+	for i, s := range stores {
+		wg.Go(func() {
+			<-start
+
+			_, errs[i] = s.AddSecret(apiKey, "value", "")
+		})
+	}
+
+	close(start)
+	wg.Wait()
+
+	var ok, exists int
+
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrAlreadyExists):
+			exists++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if ok != 1 || exists != 1 {
+		t.Fatalf("expected 1 success and 1 %v, got %v and %v", ErrAlreadyExists, ok, exists)
+	}
+}
+
 func TestAddSecret_ErrAlreadyExists(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
@@ -192,6 +337,110 @@ func TestListSecrets_Empty(t *testing.T) {
 	}
 }
 
+func TestListSecretsPaged_Sort(t *testing.T) { // nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		opts ListSecretsOptions
+		want []string
+	}
+
+	tests := map[string]testcase{
+		"default": {
+			opts: ListSecretsOptions{},
+			want: []string{"alpha", "charlie", "bravo"},
+		},
+		"default-reverse": {
+			opts: ListSecretsOptions{Reverse: true},
+			want: []string{"bravo", "charlie", "alpha"},
+		},
+		"key": {
+			opts: ListSecretsOptions{Sort: SecretSortKey},
+			want: []string{"alpha", "bravo", "charlie"},
+		},
+		"key-reverse": {
+			opts: ListSecretsOptions{Sort: SecretSortKey, Reverse: true},
+			want: []string{"charlie", "bravo", "alpha"},
+		},
+		"created": {
+			opts: ListSecretsOptions{Sort: SecretSortCreated},
+			want: []string{"bravo", "charlie", "alpha"},
+		},
+		"created-reverse": {
+			opts: ListSecretsOptions{Sort: SecretSortCreated, Reverse: true},
+			want: []string{"alpha", "charlie", "bravo"},
+		},
+		"updated": {
+			opts: ListSecretsOptions{Sort: SecretSortUpdated},
+			want: []string{"charlie", "alpha", "bravo"},
+		},
+		"updated-reverse": {
+			opts: ListSecretsOptions{Sort: SecretSortUpdated, Reverse: true},
+			want: []string{"bravo", "alpha", "charlie"},
+		},
+		"paged": {
+			opts: ListSecretsOptions{Sort: SecretSortKey, Limit: 1, Offset: 1},
+			want: []string{"bravo"},
+		},
+		"paged-reverse": {
+			opts: ListSecretsOptions{Sort: SecretSortUpdated, Reverse: true, Limit: 2},
+			want: []string{"bravo", "alpha"},
+		},
+		"offset-past-end": {
+			opts: ListSecretsOptions{Sort: SecretSortKey, Offset: 5},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := prepNewStore(t)
+
+			seed := []struct{ key, created, updated string }{
+				{"alpha", "2025-01-03 00:00:00", "2025-01-05 00:00:00"},
+				{"bravo", "2025-01-01 00:00:00", "2025-01-06 00:00:00"},
+				{"charlie", "2025-01-02 00:00:00", "2025-01-04 00:00:00"},
+			}
+
+			// Insert directly so the update trigger does not overwrite updated_at
+			for _, sd := range seed {
+				_, err := s.dbtx.ExecContext(context.Background(),
+					"INSERT INTO secrets (key, value, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+					sd.key, "value", "", sd.created, sd.updated,
+				)
+				if err != nil {
+					t.Fatalf("unexpected error seeding secret: %v", err)
+				}
+			}
+
+			secrets, err := s.ListSecretsPaged(tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error listing secrets: %v", err)
+			}
+
+			got := make([]string, 0, len(secrets))
+			for _, secret := range secrets {
+				got = append(got, secret.Key)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestListSecretsPaged_ErrInvalidSort(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.ListSecretsPaged(ListSecretsOptions{Sort: "value"})
+	if !errors.Is(err, ErrInvalidSort) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidSort, err)
+	}
+}
+
 func TestUpdateSecret_OK(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
@@ -317,6 +566,69 @@ func TestUpdateSecret_Err(t *testing.T) {
 	}
 }
 
+func TestSetSecretValue_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	created, err := s.SetSecretValue("api_token", "first")
+	if err != nil {
+		t.Fatalf("unexpected error setting secret: %v", err)
+	}
+
+	if created.Value != "first" {
+		t.Fatalf("expected secret value %v, got %v", "first", created.Value)
+	}
+
+	if _, err := s.UpdateSecret("api_token", "first", "token for the api"); err != nil {
+		t.Fatalf("unexpected error updating secret: %v", err)
+	}
+
+	updated, err := s.SetSecretValue("api_token", "second")
+	if err != nil {
+		t.Fatalf("unexpected error setting secret: %v", err)
+	}
+
+	if updated.Value != "second" {
+		t.Fatalf("expected secret value %v, got %v", "second", updated.Value)
+	}
+
+	if updated.Description != "token for the api" {
+		t.Fatalf("expected description %v, got %v", "token for the api", updated.Description)
+	}
+}
+
+func TestSetSecret_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	secret, created, err := s.SetSecret(apiKey, "first", "api key")
+	if err != nil {
+		t.Fatalf("unexpected error setting secret: %v", err)
+	}
+
+	if !created || secret.Value != "first" || secret.Description != "api key" {
+		t.Fatalf("expected a created secret with value %v, got created=%v %+v", "first", created, secret)
+	}
+
+	secret, created, err = s.SetSecret(apiKey, "second", "")
+	if err != nil {
+		t.Fatalf("unexpected error setting secret: %v", err)
+	}
+
+	if created || secret.Value != "second" || secret.Description != "api key" {
+		t.Fatalf("expected an updated secret keeping its description, got created=%v %+v", created, secret)
+	}
+
+	secret, _, err = s.SetSecret(apiKey, "third", "new description")
+	if err != nil {
+		t.Fatalf("unexpected error setting secret: %v", err)
+	}
+
+	if secret.Description != "new description" {
+		t.Fatalf("expected description %v, got %v", "new description", secret.Description)
+	}
+}
+
 func TestRemoveSecret_OK(t *testing.T) {
 	t.Parallel()
 	s := prepNewStore(t)
@@ -342,8 +654,8 @@ func TestRemoveSecret_ErrSecretNotFound(t *testing.T) {
 	s := prepNewStore(t)
 
 	err := s.RemoveSecret("does_not_exist")
-	if err == nil {
-		t.Fatalf("expected error, got nil")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrSecretNotFound, err)
 	}
 }
 
@@ -393,8 +705,8 @@ func TestGetSecretByKey_ErrSecretNotFound(t *testing.T) {
 	s := prepNewStore(t)
 
 	_, err := s.GetSecretByKey("does_not_exist")
-	if err == nil {
-		t.Fatalf("expected error, got nil")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrSecretNotFound, err)
 	}
 }
 
@@ -457,3 +769,82 @@ func TestSecretOperations_EmptyValues(t *testing.T) {
 		t.Fatalf("expected empty description, got %v", secret2.Description)
 	}
 }
+
+func TestRotateSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]func(t *testing.T) *Store{
+		"in transaction": prepNewStore,
+		"own transaction": func(t *testing.T) *Store {
+			t.Helper()
+
+			return prepFileStore(t, prepDBFile(t))
+		},
+	}
+
+	for name, prep := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := prep(t)
+
+			if _, err := s.AddSecret(apiKey, "old", "the api key"); err != nil {
+				t.Fatalf("unexpected error adding secret: %v", err)
+			}
+
+			seed := map[string]string{
+				"fetch":  "curl -H {{!api_key}} {{url}}",
+				"deploy": "deploy --key {{!api_key}} --token {{!token}}",
+				"list":   "ls {{path}}",
+			}
+			for cmdName, command := range seed {
+				if _, err := s.AddCommand(cmdName, command, ""); err != nil {
+					t.Fatalf("unexpected error adding command: %v", err)
+				}
+			}
+
+			dependents, err := s.RotateSecret(apiKey, "new")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if want := []string{"deploy", "fetch"}; !slices.Equal(dependents, want) {
+				t.Fatalf("expected dependents %v, got %v", want, dependents)
+			}
+
+			got, err := s.GetSecretByKey(apiKey)
+			if err != nil {
+				t.Fatalf("unexpected error getting secret: %v", err)
+			}
+
+			if got.Value != "new" || got.Description != "the api key" {
+				t.Fatalf("expected value %q and description kept, got %+v", "new", got)
+			}
+		})
+	}
+}
+
+func TestRotateSecret_Errors(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.RotateSecret("missing", "new"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected %v, got %v", ErrSecretNotFound, err)
+	}
+
+	if _, err := s.AddSecret(apiKey, "old", ""); err != nil {
+		t.Fatalf("unexpected error adding secret: %v", err)
+	}
+
+	if _, err := s.RotateSecret(apiKey, ""); !errors.Is(err, ErrEmptySecret) {
+		t.Fatalf("expected %v, got %v", ErrEmptySecret, err)
+	}
+
+	got, err := s.GetSecretByKey(apiKey)
+	if err != nil {
+		t.Fatalf("unexpected error getting secret: %v", err)
+	}
+
+	if got.Value != "old" {
+		t.Fatalf("expected value to be unchanged, got %q", got.Value)
+	}
+}
@@ -0,0 +1,52 @@
+// Package storetest builds migrated stores for tests outside the store
+// package, so cmd-level tests can seed data against a real database.
+package storetest
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/h3jfc/shed/internal/store"
+	"github.com/h3jfc/shed/lib/sqlite3"
+)
+
+// memoryDB names an in-memory database. Each name is its own database, shared
+// only by connections that use the same name.
+const memoryDB = "file:storetest%d?mode=memory&cache=shared&_busy_timeout=10000"
+
+var dbCount atomic.Int64
+
+// New returns a store on a fresh, migrated in-memory database. The database is
+// closed, and so discarded, when the test finishes. Stores from separate calls
+// never see each other's data.
+func New(tb testing.TB) *store.Store {
+	tb.Helper()
+
+	return store.NewStore(NewDB(tb))
+}
+
+// NewDB returns a fresh, migrated in-memory database, closed when the test
+// finishes.
+func NewDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf(memoryDB, dbCount.Add(1)))
+	if err != nil {
+		tb.Fatalf("failed to open database: %v", err)
+	}
+
+	// A shared in-memory database lives as long as one connection is open
+	db.SetMaxOpenConns(1)
+
+	tb.Cleanup(func() {
+		db.Close()
+	})
+
+	if err := sqlite3.MigrateDB(db); err != nil {
+		tb.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
@@ -0,0 +1,59 @@
+package storetest
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	s := New(t)
+
+	if _, err := s.AddSecret("api_key", "value", "desc"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	secret, err := s.GetSecretByKey("api_key")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if secret.Value != "value" {
+		t.Fatalf("expected value %q, got %q", "value", secret.Value)
+	}
+}
+
+func TestNew_Isolated(t *testing.T) {
+	t.Parallel()
+
+	a := New(t)
+	b := New(t)
+
+	if _, err := a.AddSecret("api_key", "value", "desc"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	secrets, err := b.ListSecrets()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(secrets) != 0 {
+		t.Fatalf("expected no secrets in a separate store, got %v", secrets)
+	}
+}
+
+func TestNewDB_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	var db *sql.DB
+
+	t.Run("inner", func(t *testing.T) {
+		db = NewDB(t)
+	})
+
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected the database to be closed after the test finished")
+	}
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/h3jfc/shed/db"
+	"github.com/h3jfc/shed/internal/logger"
+)
+
+// DefaultTrashRetention is how long removed commands stay restorable.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// sqliteTimeLayout matches the format of datetime('now').
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+var ErrTrashEmpty = errors.New("nothing to restore, trash is empty")
+
+// SoftDeleteCommand removes a command by name or alias, keeping a copy in the
+// trash, aliases included, so it can be brought back with RestoreLastDeleted.
+func (s *Store) SoftDeleteCommand(name string) (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	c, err := s.queries.GetCommandByName(context.Background(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		c, err = s.getCommandByAlias(name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("command %q does not exist: %w", name, ErrCommandNotFound)
+	}
+
+	var cmd *Command
+
+	err = s.withTx(func(tx *Store) error {
+		aliases, err := tx.trashAliases(c.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.queries.CreateTrash(context.Background(), db.CreateTrashParams{
+			Name:        c.Name,
+			Command:     c.Command,
+			RawCommand:  c.RawCommand,
+			Description: c.Description,
+			Parameters:  c.Parameters,
+			Env:         c.Env,
+			CreatedAt:   c.CreatedAt,
+			Aliases:     aliases,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move command to trash: %w", err)
+		}
+
+		cmd, err = tx.removeCommand(c.Name, "moved to trash")
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// RestoreLastDeleted restores the most recently soft deleted command, with its
+// aliases, and removes it from the trash. An alias whose name has since been
+// taken by a command or another alias is not restored.
+func (s *Store) RestoreLastDeleted() (*Command, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	t, err := s.queries.GetLastTrash(context.Background())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrashEmpty
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last deleted command: %w", err)
+	}
+
+	if _, err := s.GetCommandByName(t.Name); err == nil {
+		return nil, fmt.Errorf("command with name %q already exists: %w", t.Name, ErrAlreadyExists)
+	}
+
+	params, err := ToParameters(t.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore command: %w", err)
+	}
+
+	var aliases []string
+	if err := json.Unmarshal(t.Aliases, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to restore command aliases: %w", err)
+	}
+
+	var c db.Command
+
+	err = s.withTx(func(tx *Store) error {
+		cmd, err := tx.createCommand(t.Name, t.Command, t.RawCommand, t.Description, params)
+		if err != nil {
+			return fmt.Errorf("failed to restore command: %w", err)
+		}
+
+		c, err = tx.queries.UpdateCommandEnv(context.Background(), db.UpdateCommandEnvParams{
+			Env: t.Env,
+			ID:  cmd.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore command env: %w", err)
+		}
+
+		if err := tx.restoreAliases(cmd.ID, aliases); err != nil {
+			return err
+		}
+
+		if err := tx.queries.DeleteTrashByID(context.Background(), t.ID); err != nil {
+			return fmt.Errorf("failed to remove command from trash: %w", err)
+		}
+
+		return tx.audit(AuditOpRestoreCommand, t.Name, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ToCommand(c)
+}
+
+// trashAliases returns the aliases of the command with id as JSON, for keeping
+// in the trash.
+func (s *Store) trashAliases(id int64) (json.RawMessage, error) {
+	aliases, err := s.queries.ListAliasesByCommandID(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list command aliases: %w", err)
+	}
+
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	bb, err := json.Marshal(aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal command aliases: %w", err)
+	}
+
+	return bb, nil
+}
+
+// restoreAliases points each alias that is still free back at the command with
+// id. Aliases taken in the meantime are skipped.
+func (s *Store) restoreAliases(id int64, aliases []string) error {
+	for _, alias := range aliases {
+		exists, err := s.CommandExists(alias)
+		if err != nil {
+			return err
+		}
+
+		err = s.checkNotAlias(alias)
+		if err != nil && !errors.Is(err, ErrAlreadyExists) {
+			return err
+		}
+
+		if exists || err != nil {
+			logger.Warn("Alias not restored, its name is taken", "alias", alias)
+
+			continue
+		}
+
+		_, err = s.queries.CreateAlias(context.Background(), db.CreateAliasParams{Alias: alias, CommandID: id})
+		if err != nil {
+			return fmt.Errorf("failed to restore alias %q: %w", alias, err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes trashed commands removed more than olderThan
+// ago and returns how many were deleted.
+func (s *Store) PurgeTrash(olderThan time.Duration) (int64, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().UTC().Add(-olderThan).Format(sqliteTimeLayout)
+
+	var n int64
+
+	err := s.withTx(func(tx *Store) error {
+		var err error
+
+		n, err = tx.queries.PurgeTrash(context.Background(), cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+
+		if n == 0 {
+			return nil
+		}
+
+		return tx.audit(AuditOpPurgeTrash, "trash", fmt.Sprintf("%d command(s) older than %s", n, cutoff))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
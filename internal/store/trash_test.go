@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSoftDeleteCommand_Restore(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	added, err := s.AddCommand("greet", "echo {{greeting|what to say}} {{name|who}}", "greets someone")
+	if err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	env := map[string]string{"LANG": "C"}
+	if _, err := s.SetCommandEnv("greet", env); err != nil {
+		t.Fatalf("unexpected error setting env: %v", err)
+	}
+
+	if _, err := s.SoftDeleteCommand("greet"); err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	if _, err := s.GetCommandByName("greet"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+
+	restored, err := s.RestoreLastDeleted()
+	if err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	if restored.Name != added.Name || restored.Command != added.Command || restored.Description != added.Description {
+		t.Fatalf("expected restored command %v, got %v", added, restored)
+	}
+
+	if !reflect.DeepEqual(restored.Parameters, added.Parameters) {
+		t.Fatalf("expected parameters %v, got %v", added.Parameters, restored.Parameters)
+	}
+
+	if !maps.Equal(restored.Env, env) {
+		t.Fatalf("expected env %v, got %v", env, restored.Env)
+	}
+
+	// The trash entry is consumed by the restore
+	if _, err := s.RestoreLastDeleted(); !errors.Is(err, ErrTrashEmpty) {
+		t.Fatalf("expected error %v, got %v", ErrTrashEmpty, err)
+	}
+}
+
+func TestSoftDeleteCommand_RestoresMostRecent(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := s.AddCommand(name, "echo "+name, ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+
+		if _, err := s.SoftDeleteCommand(name); err != nil {
+			t.Fatalf("unexpected error soft deleting command: %v", err)
+		}
+	}
+
+	restored, err := s.RestoreLastDeleted()
+	if err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	if restored.Name != "second" {
+		t.Fatalf("expected command name %v, got %v", "second", restored.Name)
+	}
+}
+
+func TestSoftDeleteCommand_ErrCommandNotFound(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.SoftDeleteCommand("does_not_exist")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+}
+
+func TestRestoreLastDeleted_ErrTrashEmpty(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.RestoreLastDeleted()
+	if !errors.Is(err, ErrTrashEmpty) {
+		t.Fatalf("expected error %v, got %v", ErrTrashEmpty, err)
+	}
+}
+
+func TestSoftDeleteCommand_RestoresAliases(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("kubectl_get", "kubectl get {{resource}}", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	for _, alias := range []string{"k", "kg"} {
+		if _, err := s.AddAlias(alias, "kubectl_get"); err != nil {
+			t.Fatalf("unexpected error adding alias %q: %v", alias, err)
+		}
+	}
+
+	// Removing by alias removes the command the alias points to
+	removed, err := s.SoftDeleteCommand("k")
+	if err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	if removed.Name != "kubectl_get" {
+		t.Fatalf("expected %q to be removed, got %q", "kubectl_get", removed.Name)
+	}
+
+	if _, err := s.GetCommandByName("kg"); !errors.Is(err, ErrCommandNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrCommandNotFound, err)
+	}
+
+	if _, err := s.RestoreLastDeleted(); err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	for _, alias := range []string{"k", "kg"} {
+		cmd, err := s.GetCommandByName(alias)
+		if err != nil {
+			t.Fatalf("expected alias %q to resolve after restore, got %v", alias, err)
+		}
+
+		if cmd.Name != "kubectl_get" {
+			t.Fatalf("expected alias %q to resolve to %q, got %q", alias, "kubectl_get", cmd.Name)
+		}
+	}
+}
+
+func TestSoftDeleteCommand_RestoreSkipsTakenAlias(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	for _, name := range []string{"kubectl_get", "kubectl_describe"} {
+		if _, err := s.AddCommand(name, "kubectl", ""); err != nil {
+			t.Fatalf("unexpected error adding command: %v", err)
+		}
+	}
+
+	if _, err := s.AddAlias("k", "kubectl_get"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if _, err := s.SoftDeleteCommand("kubectl_get"); err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	if _, err := s.AddAlias("k", "kubectl_describe"); err != nil {
+		t.Fatalf("unexpected error adding alias: %v", err)
+	}
+
+	if _, err := s.RestoreLastDeleted(); err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	cmd, err := s.GetCommandByName("k")
+	if err != nil {
+		t.Fatalf("unexpected error resolving alias: %v", err)
+	}
+
+	if cmd.Name != "kubectl_describe" {
+		t.Fatalf("expected the alias to stay with %q, got %q", "kubectl_describe", cmd.Name)
+	}
+}
+
+func TestRestoreLastDeleted_ErrAlreadyExists(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	if _, err := s.AddCommand("greet", "echo hi", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.SoftDeleteCommand("greet"); err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	if _, err := s.AddCommand("greet", "echo hello", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	_, err := s.RestoreLastDeleted()
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("expected error %v, got %v", ErrAlreadyExists, err)
+	}
+}
+
+func TestPurgeTrash_OK(t *testing.T) {
+	t.Parallel()
+	s := prepNewStore(t)
+
+	_, err := s.dbtx.ExecContext(context.Background(),
+		`INSERT INTO trash (name, command, description, created_at, deleted_at)
+		VALUES ('old', 'echo old', '', '2000-01-01 00:00:00', '2000-01-02 00:00:00')`)
+	if err != nil {
+		t.Fatalf("unexpected error seeding trash: %v", err)
+	}
+
+	if _, err := s.AddCommand("recent", "echo recent", ""); err != nil {
+		t.Fatalf("unexpected error adding command: %v", err)
+	}
+
+	if _, err := s.SoftDeleteCommand("recent"); err != nil {
+		t.Fatalf("unexpected error soft deleting command: %v", err)
+	}
+
+	n, err := s.PurgeTrash(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error purging trash: %v", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("expected %v purged, got %v", 1, n)
+	}
+
+	restored, err := s.RestoreLastDeleted()
+	if err != nil {
+		t.Fatalf("unexpected error restoring command: %v", err)
+	}
+
+	if restored.Name != "recent" {
+		t.Fatalf("expected command name %v, got %v", "recent", restored.Name)
+	}
+}
@@ -1,13 +1,21 @@
 package brackets
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/h3jfc/shed/lib/itertools"
 )
@@ -21,18 +29,47 @@ var (
 	ErrContainsInvalidSymbols = errors.New("contains invalid symbols")
 	ErrParameterNotFound      = errors.New("parameter not found")
 	ErrParsingValueParams     = errors.New("failed to parse value parameters")
+	ErrEmptySecretPrefix      = errors.New("secret prefix cannot be empty")
+	ErrInvalidSecretPrefix    = errors.New("secret prefix cannot contain {, | or }")
+	ErrParameterExists        = errors.New("parameter already exists")
+	ErrDescriptionTooLong     = errors.New("description too long")
+	ErrUnsupportedValue       = errors.New("unsupported value, expected a string or a list of strings")
+	ErrUndeclaredParameter    = errors.New("is not declared by the command")
+	ErrInvalidPattern         = errors.New("has an invalid pattern")
+	ErrPatternMismatch        = errors.New("does not match its pattern")
+	ErrBakeSecret             = errors.New("secrets cannot be baked into a command")
+	ErrSecretCollision        = errors.New("is also used as a secret")
 )
 
 var spaceRegex = regexp.MustCompile(`\s+`)
 
 const (
-	maxParts       = 2
 	characterLimit = 40
 	symbols        = "!@#$%^&*()-+=[]{};:'\",.<>?/\\|`~"
-	bang           = '!'
+
+	// DescriptionLimit is the maximum length, in characters, of a parameter or
+	// secret description.
+	DescriptionLimit = 500
+
+	// DefaultListSeparator joins the items of a list value, as in
+	// {"files":["a","b"]} hydrating to "a b".
+	DefaultListSeparator = " "
+
+	// DefaultSecretPrefix marks a bracket name as a secret, as in {{!api_key}}.
+	DefaultSecretPrefix = "!"
 )
 
-var symbolSet map[rune]struct{}
+var (
+	symbolSet map[rune]struct{}
+
+	secretPrefix   = DefaultSecretPrefix
+	secretPrefixMu sync.RWMutex
+
+	trimTrailingSeparator atomic.Bool
+
+	// patternCache holds compiled parameter patterns, keyed by source.
+	patternCache sync.Map
+)
 
 func init() {
 	symbolSet = make(map[rune]struct{})
@@ -41,9 +78,56 @@ func init() {
 	}
 }
 
+// SetSecretPrefix changes the sentinel that marks a bracket name as a secret.
+// It applies to every subsequent parse and hydration in the process. The
+// prefix cannot hold the characters that delimit a block.
+func SetSecretPrefix(prefix string) error {
+	if prefix == "" {
+		return ErrEmptySecretPrefix
+	}
+
+	if strings.ContainsAny(prefix, "{|}") {
+		return fmt.Errorf("%w: %q", ErrInvalidSecretPrefix, prefix)
+	}
+
+	secretPrefixMu.Lock()
+	defer secretPrefixMu.Unlock()
+
+	secretPrefix = prefix
+
+	return nil
+}
+
+// SecretPrefix returns the sentinel that marks a bracket name as a secret.
+func SecretPrefix() string {
+	secretPrefixMu.RLock()
+	defer secretPrefixMu.RUnlock()
+
+	return secretPrefix
+}
+
+// SetTrimTrailingSeparator turns on or off trimming of a dangling trailing ";"
+// or "|" in ParseCommand. It is off by default and applies to every subsequent
+// parse in the process.
+func SetTrimTrailingSeparator(enabled bool) {
+	trimTrailingSeparator.Store(enabled)
+}
+
+// SecretName returns the bracket name used to reference the secret key.
+func SecretName(key string) string {
+	return SecretPrefix() + key
+}
+
+func isSecretName(name string) bool {
+	return strings.HasPrefix(name, SecretPrefix())
+}
+
 type Parameter struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
+	// Pattern is a regular expression every value must match, written as a
+	// trailing /.../ segment: {{port|the port|/^\d+$/}}.
+	Pattern string `json:"pattern,omitempty"`
 }
 
 type ValuedParameter struct {
@@ -68,6 +152,76 @@ type Brackets struct {
 	Secrets    *Secrets
 }
 
+// Kinds of names reported by ParameterError.
+const (
+	KindParameter = "parameter"
+	KindSecret    = "secret"
+)
+
+// ParameterError reports the parameter or secret name that failed validation.
+// It wraps one of the package sentinel errors so errors.Is keeps working.
+type ParameterError struct {
+	Name string
+	Kind string
+	Err  error
+}
+
+func (e *ParameterError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s %v", e.Kind, e.Err)
+	}
+
+	return fmt.Sprintf("%s %v: %s", e.Kind, e.Err, e.Name)
+}
+
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// String renders the parameter for display as "name (description)".
+func (p Parameter) String() string {
+	name := p.Name
+
+	if p.Pattern != "" {
+		name += " /" + p.Pattern + "/"
+	}
+
+	if p.Description == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, p.Description)
+}
+
+// String renders the secret for display as it appears in a command, prefix
+// included, followed by its description in parentheses when it has one.
+func (s Secret) String() string {
+	if s.Description == "" {
+		return SecretName(s.Key)
+	}
+
+	return fmt.Sprintf("%s (%s)", SecretName(s.Key), s.Description)
+}
+
+// String joins the rendered parameters with commas.
+func (p Parameters) String() string {
+	return joinStrings(p)
+}
+
+// String joins the rendered secrets with commas.
+func (s Secrets) String() string {
+	return joinStrings(s)
+}
+
+func joinStrings[T fmt.Stringer](items []T) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, item.String())
+	}
+
+	return strings.Join(parts, ", ")
+}
+
 // MarshalJSON ensures deterministic ordering by name.
 func (p Parameters) MarshalJSON() ([]byte, error) {
 	if p == nil {
@@ -105,6 +259,10 @@ func (p *Parameters) UnmarshalJSON(data []byte) error {
 }
 
 func (p *Parameters) ToMap() map[string]string {
+	if p == nil {
+		return map[string]string{}
+	}
+
 	m := make(map[string]string, len(*p))
 
 	for i := range *p {
@@ -115,6 +273,10 @@ func (p *Parameters) ToMap() map[string]string {
 }
 
 func (p *Parameters) Names() []string {
+	if p == nil {
+		return []string{}
+	}
+
 	names := make([]string, 0, len(*p))
 
 	for i := range *p {
@@ -125,7 +287,7 @@ func (p *Parameters) Names() []string {
 }
 
 func (p *Parameters) Description(name string) (string, error) {
-	m := p.ToMap()
+	m := p.ToMap() // nil-safe
 
 	if desc, exists := m[name]; exists {
 		return desc, nil
@@ -157,6 +319,40 @@ func (p *Parameters) Replace(name, description string) {
 	})
 }
 
+// Rename renames the parameter oldName to newName, keeping its description, and
+// re-sorts. If newName already exists the two are merged: the existing entry is
+// kept, taking oldName's description only when it has none. It reports whether
+// oldName was found and renamed.
+func (p *Parameters) Rename(oldName, newName string) bool {
+	if p == nil || oldName == newName {
+		return false
+	}
+
+	from := slices.IndexFunc(*p, func(param Parameter) bool { return param.Name == oldName })
+	if from < 0 {
+		return false
+	}
+
+	if to := slices.IndexFunc(*p, func(param Parameter) bool { return param.Name == newName }); to >= 0 {
+		if (*p)[to].Description == "" {
+			(*p)[to].Description = (*p)[from].Description
+		}
+
+		*p = slices.Delete(*p, from, from+1)
+
+		return true
+	}
+
+	(*p)[from].Name = newName
+
+	// Re-sort to maintain deterministic ordering
+	sort.Slice(*p, func(i, j int) bool {
+		return (*p)[i].Name < (*p)[j].Name
+	})
+
+	return true
+}
+
 func (p *Parameters) MergeName(other *Parameters, name string) {
 	if p == nil || other == nil {
 		return
@@ -217,7 +413,98 @@ func (p *Parameters) ThreeWayMerge(before, updated *Parameters) { // nolint:cycl
 	}
 }
 
-// MarshalJSON ensures deterministic ordering by name.
+// MarshalIndent is like MarshalJSON but indents the output for people to read.
+func (p Parameters) MarshalIndent() ([]byte, error) {
+	return marshalIndent(p)
+}
+
+// Signature returns a stable SHA-256 hex digest of the parameter names and
+// descriptions. It ignores order, so two sets differing only in order share a
+// signature.
+func (p Parameters) Signature() string {
+	// MarshalJSON sorts by name and cannot fail for plain strings.
+	bb, _ := p.MarshalJSON()
+	sum := sha256.Sum256(bb)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// FilterUsed returns the parameters of p whose names still appear in a bracket
+// of command, dropping the orphans left behind by an edited body.
+func (p Parameters) FilterUsed(command string) Parameters {
+	used := make(map[string]struct{})
+	for _, content := range parseBrackets(command) {
+		used[parseName(content)] = struct{}{}
+	}
+
+	return itertools.Filter(p, func(param Parameter) bool {
+		_, ok := used[param.Name]
+
+		return ok
+	})
+}
+
+// WithoutSecrets returns the entries whose names are not secret references.
+func (p Parameters) WithoutSecrets() Parameters {
+	return itertools.Filter(p, func(param Parameter) bool {
+		return !isSecretName(param.Name)
+	})
+}
+
+// OnlySecrets returns the secret references as Secrets, with the secret
+// prefix stripped from their keys.
+func (p Parameters) OnlySecrets() Secrets {
+	prefix := SecretPrefix()
+	pp := itertools.Filter(p, func(param Parameter) bool {
+		return isSecretName(param.Name)
+	})
+
+	ss := itertools.Map(slices.Values(pp), func(param Parameter) Secret {
+		return Secret{
+			Key:         strings.TrimPrefix(param.Name, prefix),
+			Description: param.Description,
+		}
+	})
+
+	return slices.Collect(ss)
+}
+
+// ToValued returns a ValuedParameters with one empty value per parameter,
+// ready to be filled in. Parameters carry no defaults, so every value is empty.
+func (p Parameters) ToValued() ValuedParameters {
+	return slices.Collect(itertools.Map(slices.Values(p), func(param Parameter) ValuedParameter {
+		return ValuedParameter{Name: param.Name}
+	}))
+}
+
+// ToValuedFromJSONPartial reads values for p from a {"name":"value"} object
+// that need not hold every parameter, for flows that prompt for the rest. It
+// returns the values given, in the order of p, and the parameters still
+// without a value. Keys that are not parameters of p are ignored, and an empty
+// string reads as an empty object.
+func (p Parameters) ToValuedFromJSONPartial(jsonStr string) (ValuedParameters, Parameters, error) {
+	m := map[string]string{}
+
+	if strings.TrimSpace(jsonStr) != "" {
+		if err := json.Unmarshal([]byte(jsonStr), &m); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrParsingValueParams, err)
+		}
+	}
+
+	vp := ValuedParameters{}
+
+	for _, param := range p {
+		if v, ok := m[param.Name]; ok {
+			vp = append(vp, ValuedParameter{Name: param.Name, Value: v})
+		}
+	}
+
+	return vp, vp.MissingSubset(p), nil
+}
+
+// MarshalJSON ensures deterministic ordering by name. It produces the list
+// form, [{"name":"env","value":"prod"}]; see MarshalValuedParametersObject for
+// the {"env":"prod"} form that shed run and the default params files take.
 func (vp ValuedParameters) MarshalJSON() ([]byte, error) {
 	if vp == nil {
 		return []byte("[]"), nil
@@ -236,6 +523,33 @@ func (vp ValuedParameters) MarshalJSON() ([]byte, error) {
 	return json.Marshal(sorted)
 }
 
+// MarshalIndent is like MarshalJSON but indents the output for people to read.
+func (vp ValuedParameters) MarshalIndent() ([]byte, error) {
+	return marshalIndent(vp)
+}
+
+// ToJSONObject renders the values as an indented {"name":"value"} object, the
+// form accepted by HydrateStringFromJSON and shed run.
+func (vp ValuedParameters) ToJSONObject() ([]byte, error) {
+	return json.MarshalIndent(vp.toMap(), "", "  ")
+}
+
+// MarshalValuedParametersObject renders vp as a compact {"name":"value"}
+// object with keys sorted, the form ValuedParametersFromJSON and
+// HydrateStringFromJSON read. When a name repeats, its last value wins.
+func MarshalValuedParametersObject(vp ValuedParameters) ([]byte, error) {
+	return json.Marshal(vp.toMap())
+}
+
+func (vp ValuedParameters) toMap() map[string]string {
+	m := make(map[string]string, len(vp))
+	for _, v := range vp {
+		m[v.Name] = v.Value
+	}
+
+	return m
+}
+
 // UnmarshalJSON ensures the slice is sorted after unmarshaling.
 func (vp *ValuedParameters) UnmarshalJSON(data []byte) error {
 	var params []ValuedParameter
@@ -244,7 +558,7 @@ func (vp *ValuedParameters) UnmarshalJSON(data []byte) error {
 	}
 
 	params = itertools.Filter(params, func(p ValuedParameter) bool {
-		return p.Name[0] != bang
+		return !isSecretName(p.Name)
 	})
 
 	// Sort by name
@@ -280,6 +594,42 @@ func (vp ValuedParameters) MissingSubset(p Parameters) Parameters {
 	return missing
 }
 
+// Validate checks the supplied values against the declared parameters p: each
+// must be declared and match the parameter's pattern, if it has one. Every
+// violation is reported as a *ParameterError, joined into one error.
+func (vp ValuedParameters) Validate(p Parameters) error {
+	var errs []error
+
+	for _, v := range vp {
+		i := slices.IndexFunc(p, func(param Parameter) bool { return param.Name == v.Name })
+		if i < 0 {
+			errs = append(errs, &ParameterError{Name: v.Name, Kind: KindParameter, Err: ErrUndeclaredParameter})
+
+			continue
+		}
+
+		if err := matchPattern(p[i].Pattern, v.Value); err != nil {
+			errs = append(errs, &ParameterError{Name: v.Name, Kind: KindParameter, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// MissingSubset returns the secrets without a value in resolved. Secret values
+// are looked up by their prefixed name, as they appear in the command.
+func (s Secrets) MissingSubset(resolved ValuedParameters) Secrets {
+	var missing Secrets
+
+	for i := range s {
+		if _, exists := resolved.Value(SecretName(s[i].Key)); !exists {
+			missing = append(missing, s[i])
+		}
+	}
+
+	return missing
+}
+
 func ValuedParametersFromMap(m map[string]string) ValuedParameters {
 	vp := make(ValuedParameters, 0, len(m))
 
@@ -290,6 +640,59 @@ func ValuedParametersFromMap(m map[string]string) ValuedParameters {
 	return vp
 }
 
+// ValuedParametersFromAny builds values from decoded JSON, where each value is
+// a string or a list of strings. A list is joined with sep, and an empty list
+// hydrates to an empty string.
+func ValuedParametersFromAny(m map[string]any, sep string) (ValuedParameters, error) {
+	vp := make(ValuedParameters, 0, len(m))
+
+	for k, v := range m {
+		value, err := joinValue(v, sep)
+		if err != nil {
+			return nil, &ParameterError{Name: k, Kind: KindParameter, Err: err}
+		}
+
+		vp = append(vp, ValuedParameter{Name: k, Value: value})
+	}
+
+	return vp, nil
+}
+
+// ValuedParametersFromJSONList is ValuedParametersFromJSON with list values
+// joined by sep.
+func ValuedParametersFromJSONList(jsonStr, sep string) (ValuedParameters, error) {
+	var m map[string]any
+
+	err := json.Unmarshal([]byte(jsonStr), &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return ValuedParametersFromAny(m, sep)
+}
+
+func joinValue(v any, sep string) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []any:
+		items := make([]string, 0, len(val))
+
+		for _, item := range val {
+			str, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("%w: list item %v", ErrUnsupportedValue, item)
+			}
+
+			items = append(items, str)
+		}
+
+		return strings.Join(items, sep), nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedValue, v)
+	}
+}
+
 func ValuedParametersFromJSON(jsonStr string) (ValuedParameters, error) {
 	var m map[string]string
 
@@ -324,7 +727,7 @@ func ParametersFromJSON(jsonStr string) (Parameters, error) {
 
 func ParseParameters(input string) (Parameters, error) {
 	predicate := func(p Parameter) bool {
-		return rune(p.Name[0]) != bang // filter out secrets
+		return !isSecretName(p.Name) // filter out secrets
 	}
 
 	pp := parseParamOrSecret(input, predicate)
@@ -341,15 +744,17 @@ func ParseParameters(input string) (Parameters, error) {
 
 func ParseSecrets(input string) (Secrets, error) {
 	predicate := func(p Parameter) bool {
-		return rune(p.Name[0]) == bang // filter out non-secrets
+		return isSecretName(p.Name) // filter out non-secrets
 	}
 
 	pp := parseParamOrSecret(input, predicate)
 
+	prefix := SecretPrefix()
+
 	pp = slices.Collect(itertools.Map(slices.Values(pp), func(p Parameter) Parameter {
-		// Remove leading '!' from secret names
+		// Remove the secret prefix from secret names
 		return Parameter{
-			Name:        p.Name[1:],
+			Name:        strings.TrimPrefix(p.Name, prefix),
 			Description: p.Description,
 		}
 	}))
@@ -371,11 +776,51 @@ func ParseSecrets(input string) (Secrets, error) {
 	return slices.Collect(ss), nil
 }
 
+// CountParameterUsages returns how many {{...}} blocks reference each parameter
+// name in input, counting every occurrence, where ParseParameters keeps each
+// name once. Secrets are excluded, as they are from ParseParameters; count
+// them with CountSecretUsages. Names are not validated.
+func CountParameterUsages(input string) map[string]int {
+	return countUsages(input, func(name string) (string, bool) {
+		return name, !isSecretName(name)
+	})
+}
+
+// CountSecretUsages is CountParameterUsages for secrets, keyed by secret key
+// without the prefix.
+func CountSecretUsages(input string) map[string]int {
+	return countUsages(input, func(name string) (string, bool) {
+		return strings.TrimPrefix(name, SecretPrefix()), isSecretName(name)
+	})
+}
+
+// countUsages counts the blocks of input by the key keep returns for their
+// name, skipping blocks keep rejects and blocks without a name.
+func countUsages(input string, keep func(name string) (string, bool)) map[string]int {
+	counts := make(map[string]int)
+
+	for content := range bracketBlocks(input) {
+		name := parseName(content)
+		if name == "" {
+			continue
+		}
+
+		if key, ok := keep(name); ok {
+			counts[key]++
+		}
+	}
+
+	return counts
+}
+
 // ParseCommand normalizes a command string by:
 // - Trimming leading/trailing whitespace
 // - Normalizing spacing inside {{...}} blocks
 // - Normalizing spacing around | separators in parameter descriptions
-// - Collapsing multiple spaces outside {{...}} blocks to single spaces.
+// - Collapsing multiple spaces outside {{...}} blocks to single spaces
+// - Trimming dangling trailing ; or | separators, when SetTrimTrailingSeparator is on.
+//
+// ParseCommand is idempotent: normalizing its result again changes nothing.
 func ParseCommand(input string) (string, error) {
 	s := strings.TrimSpace(input)
 
@@ -386,11 +831,11 @@ func ParseCommand(input string) (string, error) {
 
 	i := 0
 	for i < len(s) {
-		// Look for opening {{
-		if i < len(s)-1 && s[i] == '{' && s[i+1] == '{' {
+		// Look for opening {{ with a matching }}; an unterminated {{ is literal text
+		if bracketEnd(s, i) >= 0 {
 			// Flush any accumulated outside content
 			if outsideBrackets.Len() > 0 {
-				normalized := spaceRegex.ReplaceAllString(outsideBrackets.String(), " ")
+				normalized := collapseSpaces(outsideBrackets.String())
 				result.WriteString(normalized)
 				outsideBrackets.Reset()
 			}
@@ -405,6 +850,12 @@ func ParseCommand(input string) (string, error) {
 				if s[i] == '}' && s[i+1] == '}' {
 					content := s[start:i]
 					normalized := cleanString(content)
+					// Keep a space before the closing braces if trimming would
+					// turn "} }}" into "}}}", which closes one brace earlier
+					if strings.HasSuffix(normalized, "}") {
+						normalized += " "
+					}
+
 					result.WriteString(normalized)
 					result.WriteString("}}")
 
@@ -423,28 +874,93 @@ func ParseCommand(input string) (string, error) {
 
 	// Flush any remaining outside content
 	if outsideBrackets.Len() > 0 {
-		normalized := spaceRegex.ReplaceAllString(outsideBrackets.String(), " ")
+		normalized := collapseSpaces(outsideBrackets.String())
 		result.WriteString(normalized)
 	}
 
+	if trimTrailingSeparator.Load() {
+		return trimTrailingSeparators(result.String()), nil
+	}
+
 	return result.String(), nil
 }
 
-func Parse(input string) (*Brackets, error) {
-	var err error
+// trimTrailingSeparators applies TrimTrailingSeparator until nothing changes,
+// so "echo a ; |" loses both separators at once rather than one per call.
+func trimTrailingSeparators(command string) string {
+	for {
+		trimmed := TrimTrailingSeparator(command)
+		if trimmed == command {
+			return command
+		}
+
+		command = trimmed
+	}
+}
 
-	input, err = ParseCommand(input)
+// ParseCommandStrict normalizes a command like ParseCommand but also fails
+// when a {{...}} block is not a valid parameter or secret, such as one whose
+// name contains a space or starts with a digit. Errors for parameters and
+// secrets are joined.
+func ParseCommandStrict(input string) (string, error) {
+	command, err := ParseCommand(input)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	p, err := ParseParameters(input)
+	_, pErr := ParseParameters(command)
+	_, sErr := ParseSecrets(command)
+
+	if err := errors.Join(pErr, sErr); err != nil {
+		return "", err
+	}
+
+	return command, nil
+}
+
+// TrimTrailingSeparator removes a single dangling ";" or "|" from the end of a
+// command. It is left alone when quoted, escaped as in "find -exec rm {} \;",
+// or part of a longer operator such as ";;", "||" or "&|".
+func TrimTrailingSeparator(command string) string {
+	s := strings.TrimRightFunc(command, unicode.IsSpace)
+	if s == "" {
+		return command
+	}
+
+	last := len(s) - 1
+	if s[last] != ';' && s[last] != '|' {
+		return command
+	}
+
+	if last > 0 && strings.ContainsRune(";|&", rune(s[last-1])) {
+		return command
+	}
+
+	if quotedOrEscaped(s, last) {
+		return command
+	}
+
+	return strings.TrimRightFunc(s[:last], unicode.IsSpace)
+}
+
+// Parse normalizes a command and extracts its parameters and secrets. Errors
+// from every stage are joined, so a template with both a bad parameter and a
+// bad secret reports both at once. A name used both as a parameter and as a
+// secret, as in {{token}} and {{!token}}, is an error.
+func Parse(input string) (*Brackets, error) {
+	input, err := ParseCommand(input)
 	if err != nil {
 		return nil, err
 	}
 
-	s, err := ParseSecrets(input)
-	if err != nil {
+	p, pErr := ParseParameters(input)
+	s, sErr := ParseSecrets(input)
+
+	if err := errors.Join(pErr, sErr); err != nil {
+		return nil, err
+	}
+
+	if err := checkSecretCollisions(p, s); err != nil {
 		return nil, err
 	}
 
@@ -452,7 +968,116 @@ func Parse(input string) (*Brackets, error) {
 		Command:    input,
 		Parameters: &p,
 		Secrets:    &s,
-	}, err
+	}, nil
+}
+
+// AddParameter appends a {{name|description}} token to the end of a command
+// string. The name must be a valid parameter name not already in the command.
+func AddParameter(command, name, description string) (string, error) {
+	if _, err := checkForInvalidParameters(Parameters{{Name: name}}, true); err != nil {
+		return "", err
+	}
+
+	pp, err := ParseParameters(command)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := pp.Description(name); err == nil {
+		return "", fmt.Errorf("%w: %s", ErrParameterExists, name)
+	}
+
+	token := "{{" + name + "}}"
+	if description != "" {
+		token = "{{" + name + "|" + description + "}}"
+	}
+
+	out := strings.TrimSpace(command)
+	if out == "" {
+		return token, nil
+	}
+
+	return out + " " + token, nil
+}
+
+// RemoveParameter removes every {{name...}} token for the parameter from a
+// command string, along with the whitespace separating it from the previous
+// word. Secrets are never removed.
+func RemoveParameter(command, name string) (string, error) {
+	var result strings.Builder
+
+	removed := false
+	i := 0
+
+	for i < len(command) {
+		end := bracketEnd(command, i)
+		if end < 0 || parseName(command[i+2:end-2]) != name {
+			result.WriteByte(command[i])
+			i++
+
+			continue
+		}
+
+		// Drop the whitespace in front of the token so no double spaces remain
+		trimmed := strings.TrimRight(result.String(), " \t")
+		result.Reset()
+		result.WriteString(trimmed)
+
+		removed = true
+		i = end
+	}
+
+	if !removed {
+		return "", fmt.Errorf("%w: %s", ErrParameterNotFound, name)
+	}
+
+	return strings.TrimSpace(result.String()), nil
+}
+
+// BakeParameter permanently replaces every {{name...}} block in a command
+// string with value, so the parameter is no longer part of the command, and
+// returns the new command string with its remaining parameters. Unlike
+// hydrating for a run, the result is meant to be stored. A parameter the
+// command does not use is ErrParameterNotFound, and secrets cannot be baked.
+func BakeParameter(input, name, value string) (string, Parameters, error) {
+	if isSecretName(name) {
+		return "", nil, fmt.Errorf("%w: %s", ErrBakeSecret, name)
+	}
+
+	var result strings.Builder
+
+	result.Grow(len(input))
+
+	baked := false
+	i := 0
+
+	for i < len(input) {
+		end := bracketEnd(input, i)
+		if end < 0 || parseName(input[i+2:end-2]) != name {
+			result.WriteByte(input[i])
+			i++
+
+			continue
+		}
+
+		result.WriteString(value)
+
+		baked = true
+		i = end
+	}
+
+	if !baked {
+		return "", nil, fmt.Errorf("%w: %s", ErrParameterNotFound, name)
+	}
+
+	out := result.String()
+
+	params, err := ParseParameters(out)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return out, params, nil
 }
 
 func HydrateString(input string, vp ValuedParameters) (string, error) {
@@ -475,60 +1100,133 @@ func HydrateString(input string, vp ValuedParameters) (string, error) {
 	return out, nil
 }
 
-func HydrateStringSafe(s string, vp ValuedParameters) string {
-	var out string
+// HydrateStringFromMap hydrates input with the values in m. When parameters
+// have no value it returns ErrMissingParameters naming them, together with the
+// partly hydrated string, their placeholders left in place.
+func HydrateStringFromMap(input string, m map[string]string) (string, error) {
+	vp := ValuedParametersFromMap(m)
+	out := HydrateStringSafe(input, vp)
 
-	var args []any
+	p, err := ParseParameters(input)
+	if err != nil {
+		return "", err
+	}
 
-	i := 0
+	if missing := vp.MissingSubset(p); len(missing) > 0 {
+		return out, fmt.Errorf("%w: %v", ErrMissingParameters, missing.Names())
+	}
 
-	j := 0
+	return out, nil
+}
 
-	var outSb268 strings.Builder
+// HydrateAll hydrates each template with the same set of values. Errors from
+// every template are collected, each naming the template index, and returned
+// together so a caller sees all missing parameters at once.
+func HydrateAll(templates []string, vp ValuedParameters) ([]string, error) {
+	out := make([]string, 0, len(templates))
 
-	for j < len(s)-1 {
-		// Look for opening {{
-		if s[j] == '{' && s[j+1] == '{' { //nolint:nestif
-			outSb268.WriteString(s[i:j] + "%s")
-			j += 2
-			start := j
+	var errs []error
 
-			// Find closing }}
-			for j < len(s)-1 {
-				if s[j] == '}' && s[j+1] == '}' {
-					content := cleanString(s[start:j])
-					name := parseName(content)
-
-					if len(name) == 0 {
-						args = append(args, "")
-					} else {
-						if val, exists := vp.Value(name); exists {
-							args = append(args, val)
-						} else {
-							args = append(args, "{{"+content+"}}")
-						}
-					}
+	for i, tmpl := range templates {
+		hydrated, err := HydrateString(tmpl, vp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %d: %w", i, err))
 
-					j += 2
-					i = j
+			continue
+		}
 
-					break
+		out = append(out, hydrated)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+func HydrateStringSafe(s string, vp ValuedParameters) string {
+	out, _ := HydrateStringSpans(s, vp)
+
+	return out
+}
+
+// SecretSpan is the byte range [Start, End) of a hydrated string that holds
+// the value substituted for the secret Key.
+type SecretSpan struct {
+	Key   string
+	Start int
+	End   int
+}
+
+// HydrateStringSpans hydrates s like HydrateStringSafe and also returns where
+// each secret value was substituted, in order. Masking by these spans hides
+// exactly the secret values, even when the same text also appears in the
+// command or in a parameter value.
+func HydrateStringSpans(s string, vp ValuedParameters) (string, []SecretSpan) {
+	var (
+		out   strings.Builder
+		spans []SecretSpan
+	)
+
+	out.Grow(len(s))
+
+	i := 0
+	for i < len(s) {
+		// A {{ without a closing }} is literal text
+		end := bracketEnd(s, i)
+		if end < 0 {
+			out.WriteByte(s[i])
+			i++
+
+			continue
+		}
+
+		content := cleanString(s[i+2 : end-2])
+		name := parseName(content)
+
+		if len(name) > 0 {
+			if val, exists := vp.Value(name); exists {
+				if isSecretName(name) {
+					start := out.Len()
+					spans = append(spans, SecretSpan{
+						Key:   strings.TrimPrefix(name, SecretPrefix()),
+						Start: start,
+						End:   start + len(val),
+					})
 				}
 
-				j++
+				out.WriteString(val)
+			} else {
+				out.WriteString("{{" + content + "}}")
 			}
-		} else {
-			j++
 		}
+
+		i = end
 	}
 
-	out += outSb268.String()
+	return out.String(), spans
+}
+
+// MaskSpans replaces each span of s with mask. The spans must be in order and
+// not overlap, as HydrateStringSpans returns them for s.
+func MaskSpans(s string, spans []SecretSpan, mask string) string {
+	var out strings.Builder
+
+	out.Grow(len(s))
 
-	if i < len(s) {
-		out += s[i:]
+	last := 0
+
+	for _, span := range spans {
+		out.WriteString(s[last:span.Start])
+		out.WriteString(mask)
+
+		last = span.End
 	}
 
-	return fmt.Sprintf(out, args...)
+	out.WriteString(s[last:])
+
+	return out.String()
 }
 
 func HydrateStringFromJSON(cmd, jsonValueParams string) (string, error) {
@@ -544,35 +1242,69 @@ func HydrateStringFromJSON(cmd, jsonValueParams string) (string, error) {
 	return HydrateStringSafe(cmd, vp), nil
 }
 
-func parseBrackets(s string) []string { //nolint:gocognit
+// marshalIndent marshals v, keeping its deterministic ordering, and indents
+// the result with two spaces.
+func marshalIndent(v json.Marshaler) ([]byte, error) {
+	bb, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, bb, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to indent json: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+func parseBrackets(s string) []string {
 	var results []string
 
 	seen := make(map[string]int) // maps key to index in results
 
-	i := 0
-	for i < len(s)-1 {
-		// Look for opening {{
-		if s[i] == '{' && s[i+1] == '{' { //nolint:nestif
+	for content := range bracketBlocks(s) {
+		name := parseName(content)
+		if len(name) == 0 {
+			continue
+		}
+
+		if idx, exists := seen[name]; exists {
+			// Replace if current content is longer
+			if len(content) > len(results[idx]) {
+				results[idx] = content
+			}
+		} else {
+			// Add new entry
+			seen[name] = len(results)
+			results = append(results, content)
+		}
+	}
+
+	return results
+}
+
+// bracketBlocks yields the cleaned content of every closed {{...}} block in s,
+// in order and including repeats.
+func bracketBlocks(s string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		i := 0
+		for i < len(s)-1 {
+			// Look for opening {{
+			if s[i] != '{' || s[i+1] != '{' {
+				i++
+
+				continue
+			}
+
 			i += 2
 			start := i
 
 			// Find closing }}
 			for i < len(s)-1 {
 				if s[i] == '}' && s[i+1] == '}' {
-					content := cleanString(s[start:i])
-					name := parseName(content)
-
-					if len(name) > 0 {
-						if idx, exists := seen[name]; exists {
-							// Replace if current content is longer
-							if len(content) > len(results[idx]) {
-								results[idx] = content
-							}
-						} else {
-							// Add new entry
-							seen[name] = len(results)
-							results = append(results, content)
-						}
+					if !yield(cleanString(s[start:i])) {
+						return
 					}
 
 					i += 2
@@ -582,25 +1314,33 @@ func parseBrackets(s string) []string { //nolint:gocognit
 
 				i++
 			}
-		} else {
-			i++
 		}
 	}
+}
 
-	return results
+// bracketEnd returns the index just past the closing }} of a bracket block
+// opening at i, or -1 when no block opens there.
+func bracketEnd(s string, i int) int {
+	if !strings.HasPrefix(s[i:], "{{") {
+		return -1
+	}
+
+	closing := strings.Index(s[i+2:], "}}")
+	if closing < 0 {
+		return -1
+	}
+
+	return i + 2 + closing + 2
 }
 
 func parseParamOrSecret(input string, predicate func(Parameter) bool) Parameters {
 	ss := parseBrackets(input)
 
 	params := itertools.Map(slices.Values(ss), func(s string) Parameter {
-		parts := strings.SplitN(s, "|", maxParts)
-
-		if len(parts) == 1 {
-			return Parameter{Name: parts[0], Description: ""}
-		}
+		name, desc, _ := splitNameDescription(s)
+		desc, pattern := splitPattern(desc)
 
-		return Parameter{Name: parts[0], Description: parts[1]}
+		return Parameter{Name: name, Description: desc, Pattern: pattern}
 	})
 
 	pp := Parameters(slices.Collect(params))
@@ -609,51 +1349,255 @@ func parseParamOrSecret(input string, predicate func(Parameter) bool) Parameters
 }
 
 func checkForInvalidParameters(pp Parameters, parameter bool) (Parameters, error) {
-	errString := "parameter"
+	kind := KindParameter
 	if !parameter {
-		errString = "secret"
+		kind = KindSecret
 	}
 
 	for i := range pp {
-		if len(pp[i].Name) == 0 {
-			return nil, fmt.Errorf("%s %w", errString, ErrNameEmpty)
+		name := pp[i].Name
+
+		if len(name) == 0 {
+			return nil, &ParameterError{Kind: kind, Err: ErrNameEmpty}
 		}
 
-		firstChar := rune(pp[i].Name[0])
+		firstChar := rune(name[0])
 		if firstChar >= '0' && firstChar <= '9' {
-			return nil, fmt.Errorf("%s %w: %s", errString, ErrStartsWithInvalidChar, pp[i].Name)
+			return nil, &ParameterError{Name: name, Kind: kind, Err: ErrStartsWithInvalidChar}
 		}
 
-		for _, r := range pp[i].Name {
+		for _, r := range name {
 			if _, exists := symbolSet[r]; exists {
-				return nil, fmt.Errorf("%s %w: %s", errString, ErrContainsInvalidSymbols, pp[i].Name)
+				return nil, &ParameterError{Name: name, Kind: kind, Err: ErrContainsInvalidSymbols}
 			}
 		}
 
-		if len(pp[i].Name) > characterLimit {
-			return nil, fmt.Errorf("%s %w: %s", errString, ErrTooLong, pp[i].Name)
+		if len(name) > characterLimit {
+			return nil, &ParameterError{Name: name, Kind: kind, Err: ErrTooLong}
+		}
+
+		if strings.Contains(name, " ") {
+			return nil, &ParameterError{Name: name, Kind: kind, Err: ErrContainsSpaces}
 		}
 
-		if strings.Contains(pp[i].Name, " ") {
-			return nil, fmt.Errorf("%s %w: %s", errString, ErrContainsSpaces, pp[i].Name)
+		if utf8.RuneCountInString(pp[i].Description) > DescriptionLimit {
+			return nil, &ParameterError{Name: name, Kind: kind, Err: ErrDescriptionTooLong}
+		}
+
+		if pp[i].Pattern != "" {
+			if _, err := compilePattern(pp[i].Pattern); err != nil {
+				return nil, &ParameterError{Name: name, Kind: kind, Err: err}
+			}
 		}
 	}
 
 	return pp, nil
 }
 
+// checkSecretCollisions reports each parameter whose name is also the key of a
+// secret. Both would be looked up by the same name when the command runs.
+func checkSecretCollisions(pp Parameters, ss Secrets) error {
+	keys := make(map[string]struct{}, len(ss))
+	for _, s := range ss {
+		keys[s.Key] = struct{}{}
+	}
+
+	var errs []error
+
+	for _, p := range pp {
+		if _, ok := keys[p.Name]; ok {
+			errs = append(errs, &ParameterError{Name: p.Name, Kind: KindParameter, Err: ErrSecretCollision})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// collapseSpaces collapses each run of whitespace in s to a single space,
+// except for backslash-newline line continuations, which are kept along with
+// the indentation of the line that follows.
+func collapseSpaces(s string) string {
+	var out strings.Builder
+
+	out.Grow(len(s))
+
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		end := continuationEnd(s, i)
+		if end < 0 {
+			continue
+		}
+
+		out.WriteString(spaceRegex.ReplaceAllString(s[start:i], " "))
+		out.WriteString(s[i:end])
+
+		start = end
+		i = end - 1
+	}
+
+	out.WriteString(spaceRegex.ReplaceAllString(s[start:], " "))
+
+	return out.String()
+}
+
+// continuationEnd returns the index just past a line continuation starting at
+// i: an unescaped backslash, a newline (optionally \r\n), and the spaces or tabs
+// that indent the next line. It returns -1 when there is none at i.
+func continuationEnd(s string, i int) int {
+	if s[i] != '\\' {
+		return -1
+	}
+
+	// A backslash preceded by an odd number of backslashes is itself escaped
+	backslashes := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		backslashes++
+	}
+
+	if backslashes%2 == 1 {
+		return -1
+	}
+
+	j := i + 1
+	if j < len(s) && s[j] == '\r' {
+		j++
+	}
+
+	if j >= len(s) || s[j] != '\n' {
+		return -1
+	}
+
+	j++
+	for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+		j++
+	}
+
+	return j
+}
+
+// quotedOrEscaped reports whether the byte at i in s sits inside shell quotes
+// or directly follows an unquoted backslash.
+func quotedOrEscaped(s string, i int) bool {
+	var quote byte
+
+	escaped := false
+
+	for j := range i {
+		c := s[j]
+
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			escaped = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+
+	return escaped || quote != 0
+}
+
+// splitNameDescription splits bracket content on the first "|". Everything after
+// it is the description verbatim, so descriptions may contain further pipes.
+func splitNameDescription(s string) (string, string, bool) {
+	name, desc, found := strings.Cut(s, "|")
+
+	return strings.TrimSpace(name), strings.TrimSpace(desc), found
+}
+
 func parseName(s string) string {
-	parts := strings.SplitN(s, "|", maxParts)
+	name, _, _ := splitNameDescription(s)
 
-	return strings.TrimSpace(parts[0])
+	return name
 }
 
 func cleanString(s string) string {
-	parts := strings.SplitN(s, "|", maxParts)
+	name, desc, found := splitNameDescription(s)
+	if !found {
+		return name
+	}
+
+	desc, pattern := splitPattern(desc)
+	if pattern != "" {
+		return name + "|" + desc + "|/" + pattern + "/"
+	}
+
+	return name + "|" + desc
+}
+
+// splitPattern splits a "/pattern/" segment off a description. Only the third
+// field of a block, as in {{port|the port|/^\d+$/}}, is a pattern, so slashes
+// in descriptions with further pipes keep their meaning. The pattern itself
+// may contain pipes.
+func splitPattern(desc string) (string, string) {
+	i := patternSeparator(desc)
+	if i < 0 {
+		return desc, ""
+	}
+
+	pattern := strings.TrimSpace(desc[i+1:])
+
+	return strings.TrimSpace(desc[:i]), pattern[1 : len(pattern)-1]
+}
+
+// patternSeparator returns the index in desc of the "|" that begins a pattern
+// segment, or -1 when desc has none: the first "|" must be followed by a
+// segment that starts and ends with "/".
+func patternSeparator(desc string) int {
+	i := strings.IndexByte(desc, '|')
+	if i < 0 {
+		return -1
+	}
 
-	if len(parts) == 1 {
-		return strings.TrimSpace(parts[0])
+	rest := strings.TrimSpace(desc[i+1:])
+	if len(rest) < 2 || rest[0] != '/' || rest[len(rest)-1] != '/' {
+		return -1
 	}
 
-	return strings.TrimSpace(parts[0]) + "|" + strings.TrimSpace(parts[1])
+	return i
+}
+
+// compilePattern compiles a parameter pattern, caching the result so each is
+// only compiled once.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w /%s/: %w", ErrInvalidPattern, pattern, err)
+	}
+
+	patternCache.Store(pattern, re)
+
+	return re, nil
+}
+
+// matchPattern checks value against pattern. An empty pattern matches anything.
+func matchPattern(pattern, value string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	if !re.MatchString(value) {
+		return fmt.Errorf("%w /%s/", ErrPatternMismatch, pattern)
+	}
+
+	return nil
 }
@@ -3,7 +3,11 @@ package brackets
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -107,6 +111,63 @@ func Test_parseBrackets(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestParseParameters_DescriptionWithPipes(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		input       string
+		wantCommand string
+		wantDesc    string
+	}
+
+	inputs := map[string]testcase{
+		"one-pipe": {
+			input:       "{{cmd|run a pipeline}}",
+			wantCommand: "{{cmd|run a pipeline}}",
+			wantDesc:    "run a pipeline",
+		},
+		"two-pipes": {
+			input:       "{{cmd|run a | b pipeline}}",
+			wantCommand: "{{cmd|run a | b pipeline}}",
+			wantDesc:    "run a | b pipeline",
+		},
+		"three-pipes": {
+			input:       "{{ cmd | run a | b | c }}",
+			wantCommand: "{{cmd|run a | b | c}}",
+			wantDesc:    "run a | b | c",
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseCommand(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.wantCommand {
+				t.Fatalf("expected command %q, got %q", tc.wantCommand, got)
+			}
+
+			pp, err := ParseParameters(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			desc, err := pp.Description("cmd")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if desc != tc.wantDesc {
+				t.Fatalf("expected description %q, got %q", tc.wantDesc, desc)
+			}
+		})
+	}
+}
+
 func TestParseCommand(t *testing.T) { //nolint:funlen
 	t.Parallel()
 
@@ -165,43 +226,43 @@ func TestParseParameters_OK(t *testing.T) { //nolint:funlen
 	inputs := map[string]testcase{
 		"standard-1": {
 			input: "Hello, {{name}}! Welcome to {{place}}.",
-			want:  []Parameter{{"name", ""}, {"place", ""}},
+			want:  []Parameter{{Name: "name", Description: ""}, {Name: "place", Description: ""}},
 		},
 		"standard-2": {
 			input: "{{one}} some text {{two}} more text {{three}}",
-			want:  []Parameter{{"one", ""}, {"two", ""}, {"three", ""}},
+			want:  []Parameter{{Name: "one", Description: ""}, {Name: "two", Description: ""}, {Name: "three", Description: ""}},
 		},
 		"character-limit-with-spaces": {
 			input: "{{ " + fortyCharVar + " }} some text {{two}} more text {{three}}",
-			want:  []Parameter{{fortyCharVar, ""}, {"two", ""}, {"three", ""}},
+			want:  []Parameter{{Name: fortyCharVar, Description: ""}, {Name: "two", Description: ""}, {Name: "three", Description: ""}},
 		},
 		"extra-spacing": {
 			input: "{{ one }} some text {{two}} more text {{three}}",
-			want:  []Parameter{{"one", ""}, {"two", ""}, {"three", ""}},
+			want:  []Parameter{{Name: "one", Description: ""}, {Name: "two", Description: ""}, {Name: "three", Description: ""}},
 		},
 		"extra-spacing-with-desc": {
 			input: "{{ one }} some text {{two | | description   }} more text {{three}}",
-			want:  []Parameter{{"one", ""}, {"two", "| description"}, {"three", ""}},
+			want:  []Parameter{{Name: "one", Description: ""}, {Name: "two", Description: "| description"}, {Name: "three", Description: ""}},
 		},
 		"duplicates-1": {
 			input: "{{one}} some text {{two}} more than {{one}} text {{three}}{{two}}",
-			want:  []Parameter{{"one", ""}, {"two", ""}, {"three", ""}},
+			want:  []Parameter{{Name: "one", Description: ""}, {Name: "two", Description: ""}, {Name: "three", Description: ""}},
 		},
 		"duplicates-2-fuller-description": {
 			input: "{{one|foobar}} some text {{two|base}} more than {{one|foobarbaz}} text {{three|}}{{two}}",
-			want:  []Parameter{{"one", "foobarbaz"}, {"two", "base"}, {"three", ""}},
+			want:  []Parameter{{Name: "one", Description: "foobarbaz"}, {Name: "two", Description: "base"}, {Name: "three", Description: ""}},
 		},
 		"with-pipes": {
 			input: "Hello {{world|earth}} and {{universe|}}, {{universe2||}}!",
-			want:  []Parameter{{"world", "earth"}, {"universe", ""}, {"universe2", "|"}},
+			want:  []Parameter{{Name: "world", Description: "earth"}, {Name: "universe", Description: ""}, {Name: "universe2", Description: "|"}},
 		},
 		"just-brackets": {
 			input: "{{first}}{{second}}{{third}}",
-			want:  []Parameter{{"first", ""}, {"second", ""}, {"third", ""}},
+			want:  []Parameter{{Name: "first", Description: ""}, {Name: "second", Description: ""}, {Name: "third", Description: ""}},
 		},
 		"ignores-secrets": {
 			input: "{{first}}{{second}}{{third}}{{! secret}}",
-			want:  []Parameter{{"first", ""}, {"second", ""}, {"third", ""}},
+			want:  []Parameter{{Name: "first", Description: ""}, {Name: "second", Description: ""}, {Name: "third", Description: ""}},
 		},
 		"no-blocks": {
 			input: "No blocks here",
@@ -209,11 +270,11 @@ func TestParseParameters_OK(t *testing.T) { //nolint:funlen
 		},
 		"single-block": {
 			input: "{{single_block}}",
-			want:  []Parameter{{"single_block", ""}},
+			want:  []Parameter{{Name: "single_block", Description: ""}},
 		},
 		"in-middle": {
 			input: "Start {{middle}} end",
-			want:  []Parameter{{"middle", ""}},
+			want:  []Parameter{{Name: "middle", Description: ""}},
 		},
 		"empty": {
 			input: "{{}}",
@@ -256,6 +317,122 @@ func TestParseParameters_OK(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestCountParameterUsages(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  map[string]int
+	}{
+		"used several times": {
+			input: "cp {{src}} {{dst}} && ls {{ dst | where to }} {{dst}}",
+			want:  map[string]int{"src": 1, "dst": 3},
+		},
+		"single use": {
+			input: "echo {{name}}",
+			want:  map[string]int{"name": 1},
+		},
+		"secrets excluded": {
+			input: "curl -H {{!token}} {{url}} {{!token}}",
+			want:  map[string]int{"url": 1},
+		},
+		"unterminated block not counted": {
+			input: "echo {{name}} {{name",
+			want:  map[string]int{"name": 1},
+		},
+		"no parameters": {
+			input: "ls -la",
+			want:  map[string]int{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := CountParameterUsages(tc.input); !maps.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCountSecretUsages(t *testing.T) {
+	t.Parallel()
+
+	got := CountSecretUsages("curl -H {{!token}} {{url}} {{ !token | api token }} {{!user}}")
+
+	want := map[string]int{"token": 2, "user": 1}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBakeParameter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input      string
+		name       string
+		value      string
+		want       string
+		wantParams []string
+	}{
+		"used once": {
+			input:      "ls -la {{path|directory}} {{flags}}",
+			name:       "path",
+			value:      "/home/user",
+			want:       "ls -la /home/user {{flags}}",
+			wantParams: []string{"flags"},
+		},
+		"used several times": {
+			input:      "cp {{src}} {{dst}} && ls {{ dst | where to }}",
+			name:       "dst",
+			value:      "/tmp/out",
+			want:       "cp {{src}} /tmp/out && ls /tmp/out",
+			wantParams: []string{"src"},
+		},
+		"last parameter": {
+			input:      "echo {{greeting}} {{!token}}",
+			name:       "greeting",
+			value:      "hello world",
+			want:       "echo hello world {{!token}}",
+			wantParams: []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, params, err := BakeParameter(tc.input, tc.name, tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+
+			if names := params.Names(); !slices.Equal(names, tc.wantParams) {
+				t.Fatalf("expected parameters %v, got %v", tc.wantParams, names)
+			}
+		})
+	}
+}
+
+func TestBakeParameter_Err(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := BakeParameter("ls {{path}}", "missing", "x"); !errors.Is(err, ErrParameterNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrParameterNotFound, err)
+	}
+
+	if _, _, err := BakeParameter("curl -H {{!token}}", "!token", "x"); !errors.Is(err, ErrBakeSecret) {
+		t.Fatalf("expected error %v, got %v", ErrBakeSecret, err)
+	}
+}
+
 func TestParseParameters_Err(t *testing.T) { //nolint:funlen
 	t.Parallel()
 
@@ -342,78 +519,27 @@ func TestParseParameters_ErrSymbol(t *testing.T) { //nolint:funlen
 	}
 }
 
-func TestHydrateStringSafe(t *testing.T) { //nolint:funlen
+func TestParseParameters_DescriptionLimit(t *testing.T) {
 	t.Parallel()
 
 	type testcase struct {
-		input  string
-		params ValuedParameters
-		want   string
+		description string
+		wantErr     error
 	}
 
 	inputs := map[string]testcase{
-		"standard-1": {
-			input: "Hello, {{name}}! Welcome to {{place}}.",
-			params: ValuedParameters{
-				{"name", "Alice"},
-				{"place", "Wonderland"},
-			},
-			want: "Hello, Alice! Welcome to Wonderland.",
-		},
-		"standard-2": {
-			input: "{{one}} some text {{two}} more text {{three}}",
-			params: ValuedParameters{
-				{"one", "1"},
-				{"three", "three"},
-			},
-			want: "1 some text {{two}} more text three",
-		},
-		"with-pipes": {
-			input: "Hello {{world|earth}}, {{universe|}} and {{universe2||}}!",
-			params: ValuedParameters{
-				{"world", "globe"},
-				{"universe", "cosmos"},
-				{"universe2", "reality"},
-			},
-			want: "Hello globe, cosmos and reality!",
-		},
-		"just-brackets": {
-			input: "{{first}}{{second}}{{third}}",
-			params: ValuedParameters{
-				{"first", "first"},
-				{"second", "second"},
-				{"third", "third"},
-			},
-			want: "firstsecondthird",
-		},
-		"no-blocks": {
-			input: "No blocks here",
-			params: ValuedParameters{
-				{"unused", "value"},
-			},
-			want: "No blocks here",
+		"empty": {
+			description: "",
 		},
-		"single-block": {
-			input: "{{single_block}}",
-			params: ValuedParameters{
-				{"single_block", "just one"},
-				{"unused", "value"},
-			},
-			want: "just one",
+		"within-limit": {
+			description: strings.Repeat("a", DescriptionLimit),
 		},
-		"in-middle": {
-			input: "Start {{middle}} end",
-			params: ValuedParameters{
-				{"middle", "center"},
-			},
-			want: "Start center end",
+		"within-limit-multibyte": {
+			description: strings.Repeat("é", DescriptionLimit),
 		},
-		"empty": {
-			input: "{{}}",
-			params: ValuedParameters{
-				{"unused", "value"},
-			},
-			want: "",
+		"over-limit": {
+			description: strings.Repeat("a", DescriptionLimit+1),
+			wantErr:     ErrDescriptionTooLong,
 		},
 	}
 
@@ -421,78 +547,56 @@ func TestHydrateStringSafe(t *testing.T) { //nolint:funlen
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got := HydrateStringSafe(tc.input, tc.params)
-			if got != tc.want {
-				t.Errorf("expected string: %q got string: %q", tc.want, got)
+			_, err := ParseParameters("echo {{path|" + tc.description + "}}")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected parameter error %v, got %v", tc.wantErr, err)
+			}
+
+			_, err = ParseSecrets("echo {{!token|" + tc.description + "}}")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected secret error %v, got %v", tc.wantErr, err)
 			}
 		})
 	}
 }
 
-func TestHydrateString_NoErr(t *testing.T) { //nolint:funlen
+func TestParameterError(t *testing.T) { //nolint:funlen
 	t.Parallel()
 
 	type testcase struct {
-		input  string
-		params ValuedParameters
-		want   string
+		input    string
+		secrets  bool
+		wantName string
+		wantKind string
+		want     error
 	}
 
 	inputs := map[string]testcase{
-		"standard-1": {
-			input: "Hello, {{name}}! Welcome to {{place}}.",
-			params: ValuedParameters{
-				{"name", "Alice"},
-				{"place", "Wonderland"},
-			},
-			want: "Hello, Alice! Welcome to Wonderland.",
-		},
-		"with-pipes": {
-			input: "Hello {{world|earth}}, {{universe|}} and {{universe2||}}!",
-			params: ValuedParameters{
-				{"world", "globe"},
-				{"universe", "cosmos"},
-				{"universe2", "reality"},
-			},
-			want: "Hello globe, cosmos and reality!",
-		},
-		"just-brackets": {
-			input: "{{first}}{{second}}{{third}}",
-			params: ValuedParameters{
-				{"first", "first"},
-				{"second", "second"},
-				{"third", "third"},
-			},
-			want: "firstsecondthird",
-		},
-		"no-blocks": {
-			input: "No blocks here",
-			params: ValuedParameters{
-				{"unused", "value"},
-			},
-			want: "No blocks here",
-		},
-		"single-block": {
-			input: "{{single_block}}",
-			params: ValuedParameters{
-				{"single_block", "just one"},
-				{"unused", "value"},
-			},
-			want: "just one",
-		},
-		"in-middle": {
-			input: "Start {{middle}} end",
-			params: ValuedParameters{
-				{"middle", "center"},
-			},
-			want: "Start center end",
-		},
-		"empty": {
-			input: "{{}}",
-			params: ValuedParameters{
-				{"unused", "value"},
-			},
-			want: "",
+		"parameter-invalid-symbol": {
+			input:    "echo {{good}} {{bad@name}}",
+			wantName: "bad@name",
+			wantKind: KindParameter,
+			want:     ErrContainsInvalidSymbols,
+		},
+		"parameter-too-long": {
+			input:    "echo {{" + fortyOneCharVar + "}}",
+			wantName: fortyOneCharVar,
+			wantKind: KindParameter,
+			want:     ErrTooLong,
+		},
+		"secret-starts-with-number": {
+			input:    "echo {{!1token}}",
+			secrets:  true,
+			wantName: "1token",
+			wantKind: KindSecret,
+			want:     ErrStartsWithInvalidChar,
+		},
+		"secret-empty": {
+			input:    "echo {{!}}",
+			secrets:  true,
+			wantName: "",
+			wantKind: KindSecret,
+			want:     ErrNameEmpty,
 		},
 	}
 
@@ -500,113 +604,212 @@ func TestHydrateString_NoErr(t *testing.T) { //nolint:funlen
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := HydrateString(tc.input, tc.params)
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
+			var err error
+			if tc.secrets {
+				_, err = ParseSecrets(tc.input)
+			} else {
+				_, err = ParseParameters(tc.input)
 			}
 
-			if got != tc.want {
-				t.Errorf("expected string: %q got string: %q", tc.want, got)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected error: %v, got error: %v", tc.want, err)
+			}
+
+			var pe *ParameterError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected *ParameterError, got %T", err)
+			}
+
+			if pe.Name != tc.wantName {
+				t.Errorf("expected name %q, got %q", tc.wantName, pe.Name)
+			}
+
+			if pe.Kind != tc.wantKind {
+				t.Errorf("expected kind %q, got %q", tc.wantKind, pe.Kind)
 			}
 		})
 	}
 }
 
-func TestHydrateString_Err(t *testing.T) {
+func TestParameterError_Wrapped(t *testing.T) {
 	t.Parallel()
 
-	type testcase struct {
-		input  string
-		params ValuedParameters
-		want   error
+	_, err := Parse("echo {{ok}} {{not-ok}}")
+
+	wrapped := fmt.Errorf("failed to parse command: %w", err)
+
+	var pe *ParameterError
+	if !errors.As(wrapped, &pe) {
+		t.Fatalf("expected *ParameterError, got %T", wrapped)
 	}
 
-	inputs := map[string]testcase{
-		"standard-2": {
-			input: "{{one}} some text {{two}} more text {{three}}",
-			params: ValuedParameters{
-				{"one", "1"},
-				{"three", "three"},
-			},
-			want: ErrMissingParameters,
-		},
+	if pe.Name != "not-ok" {
+		t.Errorf("expected name %q, got %q", "not-ok", pe.Name)
 	}
 
-	for name, tc := range inputs {
-		t.Run(name, func(t *testing.T) {
+	if pe.Error() != "parameter contains invalid symbols: not-ok" {
+		t.Errorf("unexpected error message: %q", pe.Error())
+	}
+}
+
+func TestHydrateStringSafe_Unterminated(t *testing.T) {
+	t.Parallel()
+
+	vp := ValuedParameters{{Name: "name", Value: "world"}}
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"ends in open":             {input: "echo {{", want: "echo {{"},
+		"ends in name":             {input: "echo {{name", want: "echo {{name"},
+		"ends in name and desc":    {input: "echo {{name|desc", want: "echo {{name|desc"},
+		"closed block before":      {input: "echo {{name}} {{name", want: "echo world {{name"},
+		"percent verbs kept":       {input: "date +%s {{name}} 100%", want: "date +%s world 100%"},
+		"single closing brace":     {input: "echo {{name}", want: "echo {{name}"},
+		"open and close separated": {input: "a {{ b } c", want: "a {{ b } c"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := HydrateString(tc.input, tc.params)
-			if got != "" {
-				t.Fatalf("expected empty string, got: %q", got)
+			got := HydrateStringSafe(tc.input, vp)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
 			}
 
-			if err == nil {
-				t.Fatalf("expected an error, got: %v", err)
+			if strings.Contains(got, "%!") {
+				t.Fatalf("unexpected format artifact in %q", got)
 			}
+		})
+	}
+}
 
-			if !errors.Is(err, tc.want) {
-				t.Errorf("expected error: %v, got error: %v", tc.want, err)
+func TestValuedParametersFromJSONList(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		json string
+		sep  string
+		want string
+	}{
+		"scalar":       {json: `{"files":"a"}`, sep: DefaultListSeparator, want: "kubectl apply -f a"},
+		"list joined":  {json: `{"files":["a","b"]}`, sep: DefaultListSeparator, want: "kubectl apply -f a b"},
+		"custom sep":   {json: `{"files":["a","b"]}`, sep: " -f ", want: "kubectl apply -f a -f b"},
+		"empty list":   {json: `{"files":[]}`, sep: DefaultListSeparator, want: "kubectl apply -f "},
+		"single item":  {json: `{"files":["a"]}`, sep: ",", want: "kubectl apply -f a"},
+		"missing list": {json: `{}`, sep: DefaultListSeparator, want: "kubectl apply -f {{files}}"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vp, err := ValuedParametersFromJSONList(tc.json, tc.sep)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got := HydrateStringSafe("kubectl apply -f {{files}}", vp); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestHydrateStringFromJSON_NoErr(t *testing.T) { //nolint:funlen
+func TestValuedParametersFromJSONList_ErrUnsupportedValue(t *testing.T) {
 	t.Parallel()
 
-	type testcase struct {
-		input           string
-		jsonValueParams string
-		want            string
+	tests := map[string]string{
+		"number":       `{"files":1}`,
+		"object":       `{"files":{"a":"b"}}`,
+		"number item":  `{"files":["a",2]}`,
+		"nested lists": `{"files":[["a"]]}`,
 	}
 
-	tests := map[string]testcase{
-		"standard": {
-			input:           "Hello, {{name}}! Welcome to {{place}}.",
-			jsonValueParams: `{"name":"Alice","place":"Wonderland"}`,
-			want:            "Hello, Alice! Welcome to Wonderland.",
-		},
-		"with-description-pipes": {
-			input:           "Hello {{world|earth}}, {{universe|cosmos}} and {{universe2|reality}}!",
-			jsonValueParams: `{"world":"globe","universe":"multiverse","universe2":"dimension"}`,
-			want:            "Hello globe, multiverse and dimension!",
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ValuedParametersFromJSONList(input, DefaultListSeparator)
+			if !errors.Is(err, ErrUnsupportedValue) {
+				t.Fatalf("expected %v, got %v", ErrUnsupportedValue, err)
+			}
+
+			var perr *ParameterError
+			if !errors.As(err, &perr) || perr.Name != "files" {
+				t.Fatalf("expected parameter error naming files, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseCommand_LineContinuation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"continuation kept": {
+			input: "kubectl apply \\\n  -f {{file}} \\\n  --dry-run",
+			want:  "kubectl apply \\\n  -f {{file}} \\\n  --dry-run",
 		},
-		"single-parameter": {
-			input:           "curl -XGET {{url}}",
-			jsonValueParams: `{"url":"https://example.com"}`,
-			want:            "curl -XGET https://example.com",
+		"crlf continuation kept": {
+			input: "make \\\r\n\tbuild",
+			want:  "make \\\r\n\tbuild",
 		},
-		"multiple-parameters": {
-			input: "curl -XPOST --data '{{data}}' -H {{header}} {{url}}",
-			jsonValueParams: `{"data":"{\"foo\":\"bar\"}","header":"Authorization:Bearer token",` +
-				`"url":"https://api.example.com"}`,
-			want: "curl -XPOST --data '{\"foo\":\"bar\"}' -H Authorization:Bearer token https://api.example.com",
+		"spaces before backslash collapsed": {
+			input: "echo   a    \\\n    b",
+			want:  "echo a \\\n    b",
 		},
-		"empty-json": {
-			input:           "No parameters here",
-			jsonValueParams: `{}`,
-			want:            "No parameters here",
+		"escaped backslash is not a continuation": {
+			input: "echo a\\\\\n   b",
+			want:  "echo a\\\\ b",
 		},
-		"unused-parameters": {
-			input:           "Only {{used}} parameter",
-			jsonValueParams: `{"used":"this one","unused":"ignored"}`,
-			want:            "Only this one parameter",
+		"plain newlines collapsed": {
+			input: "echo   a\n\n   {{b}}\t c",
+			want:  "echo a {{b}} c",
 		},
-		"special-characters": {
-			input:           "Path: {{path}}",
-			jsonValueParams: `{"path":"/home/user/my documents/file.txt"}`,
-			want:            "Path: /home/user/my documents/file.txt",
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseCommand(tc.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// There are no template transforms; text after "|" is a description, so only
+// its surrounding whitespace is normalized and its casing is kept.
+func TestParseCommand_DescriptionCasing(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"spacing around pipe normalized": {
+			input: "echo {{ x | upper }}",
+			want:  "echo {{x|upper}}",
 		},
-		"empty-value": {
-			input:           "Value: {{empty}}",
-			jsonValueParams: `{"empty":""}`,
-			want:            "Value: ",
+		"description casing kept": {
+			input: "echo {{ x | UPPER }}",
+			want:  "echo {{x|UPPER}}",
 		},
-		"whitespace-in-json": {
-			input:           "{{first}} {{second}}",
-			jsonValueParams: `{ "first" : "one" , "second" : "two" }`,
-			want:            "one two",
+		"mixed case description kept": {
+			input: "curl {{ url | The Target URL }}",
+			want:  "curl {{url|The Target URL}}",
 		},
 	}
 
@@ -614,36 +817,1169 @@ func TestHydrateStringFromJSON_NoErr(t *testing.T) { //nolint:funlen
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := HydrateStringFromJSON(tc.input, tc.jsonValueParams)
+			got, err := ParseCommand(tc.input)
 			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
+				t.Fatalf("expected no error, got %v", err)
 			}
 
 			if got != tc.want {
-				t.Errorf("expected: %q, got: %q", tc.want, got)
+				t.Fatalf("expected %q, got %q", tc.want, got)
 			}
 		})
 	}
 }
 
-func TestHydrateStringFromJSON_WithEmptyString(t *testing.T) {
+func TestParseCommandStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  error
+	}{
+		"space in name":           {input: "echo {{ name with space }}", want: ErrContainsSpaces},
+		"leading digit":           {input: "echo {{1name|first}}", want: ErrStartsWithInvalidChar},
+		"secret leading digit":    {input: "echo {{!1token}}", want: ErrStartsWithInvalidChar},
+		"valid blocks normalized": {input: "echo  {{ name | who }} {{!token}}"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseCommandStrict(tc.input)
+			if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+
+			if tc.want != nil {
+				return
+			}
+
+			want, err := ParseCommand(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Unterminated(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
 		input string
 		want  string
 	}{
-		"with-parameters": {
-			input: "curl -XGET {{url}} -H {{header}}",
-			want:  "curl -XGET {{url}} -H {{header}}",
+		"ends in open":          {input: "echo  {{", want: "echo {{"},
+		"ends in name":          {input: "echo  {{ name", want: "echo {{ name"},
+		"ends in name and desc": {input: "echo {{name|desc", want: "echo {{name|desc"},
+		"closed block before":   {input: "echo {{ name }}  {{name", want: "echo {{name}} {{name"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseCommand(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+
+			pp, err := ParseParameters(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(pp) > 1 {
+				t.Fatalf("expected an unterminated block not to yield a parameter, got %v", pp)
+			}
+		})
+	}
+}
+
+func TestHydrateStringSafe(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		input  string
+		params ValuedParameters
+		want   string
+	}
+
+	inputs := map[string]testcase{
+		"standard-1": {
+			input: "Hello, {{name}}! Welcome to {{place}}.",
+			params: ValuedParameters{
+				{"name", "Alice"},
+				{"place", "Wonderland"},
+			},
+			want: "Hello, Alice! Welcome to Wonderland.",
 		},
-		"no-parameters": {
-			input: "echo hello world",
-			want:  "echo hello world",
+		"standard-2": {
+			input: "{{one}} some text {{two}} more text {{three}}",
+			params: ValuedParameters{
+				{"one", "1"},
+				{"three", "three"},
+			},
+			want: "1 some text {{two}} more text three",
+		},
+		"with-pipes": {
+			input: "Hello {{world|earth}}, {{universe|}} and {{universe2||}}!",
+			params: ValuedParameters{
+				{"world", "globe"},
+				{"universe", "cosmos"},
+				{"universe2", "reality"},
+			},
+			want: "Hello globe, cosmos and reality!",
+		},
+		"just-brackets": {
+			input: "{{first}}{{second}}{{third}}",
+			params: ValuedParameters{
+				{"first", "first"},
+				{"second", "second"},
+				{"third", "third"},
+			},
+			want: "firstsecondthird",
+		},
+		"no-blocks": {
+			input: "No blocks here",
+			params: ValuedParameters{
+				{"unused", "value"},
+			},
+			want: "No blocks here",
+		},
+		"single-block": {
+			input: "{{single_block}}",
+			params: ValuedParameters{
+				{"single_block", "just one"},
+				{"unused", "value"},
+			},
+			want: "just one",
+		},
+		"in-middle": {
+			input: "Start {{middle}} end",
+			params: ValuedParameters{
+				{"middle", "center"},
+			},
+			want: "Start center end",
+		},
+		"empty": {
+			input: "{{}}",
+			params: ValuedParameters{
+				{"unused", "value"},
+			},
+			want: "",
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := HydrateStringSafe(tc.input, tc.params)
+			if got != tc.want {
+				t.Errorf("expected string: %q got string: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHydrateStringSpans(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input     string
+		vp        ValuedParameters
+		want      string
+		wantSpans []SecretSpan
+	}{
+		"secret": {
+			input:     "curl -H {{!token}} {{url}}",
+			vp:        ValuedParameters{{Name: "!token", Value: "s3cr3t"}, {Name: "url", Value: "https://x"}},
+			want:      "curl -H s3cr3t https://x",
+			wantSpans: []SecretSpan{{Key: "token", Start: 8, End: 14}},
+		},
+		"same text elsewhere": {
+			input: "echo abc {{!token}} {{word}}",
+			vp:    ValuedParameters{{Name: "!token", Value: "abc"}, {Name: "word", Value: "abc"}},
+			want:  "echo abc abc abc",
+			wantSpans: []SecretSpan{
+				{Key: "token", Start: 9, End: 12},
+			},
+		},
+		"used twice": {
+			input: "{{!a}}:{{!b}}@{{!a}}",
+			vp:    ValuedParameters{{Name: "!a", Value: "user"}, {Name: "!b", Value: "pw"}},
+			want:  "user:pw@user",
+			wantSpans: []SecretSpan{
+				{Key: "a", Start: 0, End: 4},
+				{Key: "b", Start: 5, End: 7},
+				{Key: "a", Start: 8, End: 12},
+			},
+		},
+		"missing secret left in place": {
+			input:     "echo {{!token}}",
+			vp:        ValuedParameters{},
+			want:      "echo {{!token}}",
+			wantSpans: nil,
+		},
+		"no secrets": {
+			input:     "echo {{name}}",
+			vp:        ValuedParameters{{Name: "name", Value: "bob"}},
+			want:      "echo bob",
+			wantSpans: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, spans := HydrateStringSpans(tc.input, tc.vp)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+
+			if !reflect.DeepEqual(spans, tc.wantSpans) {
+				t.Fatalf("expected spans %v, got %v", tc.wantSpans, spans)
+			}
+
+			if safe := HydrateStringSafe(tc.input, tc.vp); safe != got {
+				t.Fatalf("expected HydrateStringSafe to match, got %q", safe)
+			}
+
+			for _, span := range spans {
+				if v, _ := tc.vp.Value(SecretName(span.Key)); got[span.Start:span.End] != v {
+					t.Fatalf("expected span %v to hold %q, got %q", span, v, got[span.Start:span.End])
+				}
+			}
+		})
+	}
+}
+
+func TestMaskSpans(t *testing.T) {
+	t.Parallel()
+
+	vp := ValuedParameters{{Name: "!token", Value: "abc"}, {Name: "word", Value: "abc"}}
+
+	hydrated, spans := HydrateStringSpans("echo abc {{!token}} {{word}}", vp)
+
+	if got, want := MaskSpans(hydrated, spans, "***"), "echo abc *** abc"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if got := MaskSpans("plain", nil, "***"); got != "plain" {
+		t.Fatalf("expected %q, got %q", "plain", got)
+	}
+}
+
+func TestHydrateStringFromMap(t *testing.T) {
+	t.Parallel()
+
+	input := "deploy {{env|target}} {{version}} --token {{!token}}"
+
+	tests := map[string]struct {
+		values  map[string]string
+		want    string
+		missing []string
+	}{
+		"full map": {
+			values: map[string]string{"env": "prod", "version": "1.2.3", SecretName("token"): "s3cr3t"},
+			want:   "deploy prod 1.2.3 --token s3cr3t",
+		},
+		"missing key": {
+			values:  map[string]string{"env": "prod", SecretName("token"): "s3cr3t"},
+			want:    "deploy prod {{version}} --token s3cr3t",
+			missing: []string{"version"},
+		},
+		"empty map": {
+			values:  map[string]string{},
+			want:    input,
+			missing: []string{"env", "version"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := HydrateStringFromMap(input, tc.values)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+
+			if len(tc.missing) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrMissingParameters) {
+				t.Fatalf("expected %v, got %v", ErrMissingParameters, err)
+			}
+
+			for _, n := range tc.missing {
+				if !strings.Contains(err.Error(), n) {
+					t.Fatalf("expected error to name %q, got %v", n, err)
+				}
+			}
+		})
+	}
+}
+
+func TestHydrateString_NoErr(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		input  string
+		params ValuedParameters
+		want   string
+	}
+
+	inputs := map[string]testcase{
+		"standard-1": {
+			input: "Hello, {{name}}! Welcome to {{place}}.",
+			params: ValuedParameters{
+				{"name", "Alice"},
+				{"place", "Wonderland"},
+			},
+			want: "Hello, Alice! Welcome to Wonderland.",
+		},
+		"with-pipes": {
+			input: "Hello {{world|earth}}, {{universe|}} and {{universe2||}}!",
+			params: ValuedParameters{
+				{"world", "globe"},
+				{"universe", "cosmos"},
+				{"universe2", "reality"},
+			},
+			want: "Hello globe, cosmos and reality!",
+		},
+		"just-brackets": {
+			input: "{{first}}{{second}}{{third}}",
+			params: ValuedParameters{
+				{"first", "first"},
+				{"second", "second"},
+				{"third", "third"},
+			},
+			want: "firstsecondthird",
+		},
+		"no-blocks": {
+			input: "No blocks here",
+			params: ValuedParameters{
+				{"unused", "value"},
+			},
+			want: "No blocks here",
+		},
+		"single-block": {
+			input: "{{single_block}}",
+			params: ValuedParameters{
+				{"single_block", "just one"},
+				{"unused", "value"},
+			},
+			want: "just one",
+		},
+		"in-middle": {
+			input: "Start {{middle}} end",
+			params: ValuedParameters{
+				{"middle", "center"},
+			},
+			want: "Start center end",
+		},
+		"empty": {
+			input: "{{}}",
+			params: ValuedParameters{
+				{"unused", "value"},
+			},
+			want: "",
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := HydrateString(tc.input, tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected string: %q got string: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHydrateString_Err(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		input  string
+		params ValuedParameters
+		want   error
+	}
+
+	inputs := map[string]testcase{
+		"standard-2": {
+			input: "{{one}} some text {{two}} more text {{three}}",
+			params: ValuedParameters{
+				{"one", "1"},
+				{"three", "three"},
+			},
+			want: ErrMissingParameters,
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := HydrateString(tc.input, tc.params)
+			if got != "" {
+				t.Fatalf("expected empty string, got: %q", got)
+			}
+
+			if err == nil {
+				t.Fatalf("expected an error, got: %v", err)
+			}
+
+			if !errors.Is(err, tc.want) {
+				t.Errorf("expected error: %v, got error: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestHydrateAll_OK(t *testing.T) {
+	t.Parallel()
+
+	templates := []string{
+		"echo {{one}}",
+		"echo {{one}} {{two|second}}",
+		"echo no params",
+	}
+	params := ValuedParameters{{"one", "1"}, {"two", "2"}}
+
+	got, err := HydrateAll(templates, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"echo 1", "echo 1 2", "echo no params"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestHydrateAll_Empty(t *testing.T) {
+	t.Parallel()
+
+	got, err := HydrateAll([]string{}, ValuedParameters{{"one", "1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("expected no results, got %v", got)
+	}
+}
+
+func TestHydrateAll_Err(t *testing.T) {
+	t.Parallel()
+
+	templates := []string{
+		"echo {{one}}",
+		"echo {{one}} {{two}}",
+		"echo {{three}}",
+	}
+	params := ValuedParameters{{"one", "1"}}
+
+	got, err := HydrateAll(templates, params)
+	if got != nil {
+		t.Fatalf("expected nil result, got: %v", got)
+	}
+
+	if !errors.Is(err, ErrMissingParameters) {
+		t.Fatalf("expected error: %v, got error: %v", ErrMissingParameters, err)
+	}
+
+	want := "template 1: missing parameters: [two]\ntemplate 2: missing parameters: [three]"
+	if err.Error() != want {
+		t.Errorf("expected error message %q, got %q", want, err.Error())
+	}
+}
+
+func TestAddParameter(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		command     string
+		name        string
+		description string
+		want        string
+		wantErr     error
+	}
+
+	inputs := map[string]testcase{
+		"with-description": {
+			command:     "ls -la {{path}}",
+			name:        "flags",
+			description: "extra flags",
+			want:        "ls -la {{path}} {{flags|extra flags}}",
+		},
+		"without-description": {
+			command: "ls -la ",
+			name:    "path",
+			want:    "ls -la {{path}}",
+		},
+		"empty-command": {
+			command: "",
+			name:    "path",
+			want:    "{{path}}",
+		},
+		"already-exists": {
+			command: "ls -la {{path|dir}}",
+			name:    "path",
+			wantErr: ErrParameterExists,
+		},
+		"invalid-name": {
+			command: "ls -la",
+			name:    "bad name",
+			wantErr: ErrContainsSpaces,
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := AddParameter(tc.command, tc.name, tc.description)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveParameter(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		command string
+		name    string
+		want    string
+		wantErr error
+	}
+
+	inputs := map[string]testcase{
+		"middle": {
+			command: "ls -la {{path|dir}} | grep {{pattern}}",
+			name:    "path",
+			want:    "ls -la | grep {{pattern}}",
+		},
+		"start": {
+			command: "{{cmd}} --help",
+			name:    "cmd",
+			want:    "--help",
+		},
+		"every-occurrence": {
+			command: "echo {{name}} and {{ name | again }} {{other}}",
+			name:    "name",
+			want:    "echo and {{other}}",
+		},
+		"keeps-secret-with-same-name": {
+			command: "curl -H {{!token}} {{token}}",
+			name:    "token",
+			want:    "curl -H {{!token}}",
+		},
+		"nonexistent": {
+			command: "ls -la {{path}}",
+			name:    "missing",
+			wantErr: ErrParameterNotFound,
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := RemoveParameter(tc.command, tc.name)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHydrateStringFromJSON_NoErr(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		input           string
+		jsonValueParams string
+		want            string
+	}
+
+	tests := map[string]testcase{
+		"standard": {
+			input:           "Hello, {{name}}! Welcome to {{place}}.",
+			jsonValueParams: `{"name":"Alice","place":"Wonderland"}`,
+			want:            "Hello, Alice! Welcome to Wonderland.",
+		},
+		"with-description-pipes": {
+			input:           "Hello {{world|earth}}, {{universe|cosmos}} and {{universe2|reality}}!",
+			jsonValueParams: `{"world":"globe","universe":"multiverse","universe2":"dimension"}`,
+			want:            "Hello globe, multiverse and dimension!",
+		},
+		"single-parameter": {
+			input:           "curl -XGET {{url}}",
+			jsonValueParams: `{"url":"https://example.com"}`,
+			want:            "curl -XGET https://example.com",
+		},
+		"multiple-parameters": {
+			input: "curl -XPOST --data '{{data}}' -H {{header}} {{url}}",
+			jsonValueParams: `{"data":"{\"foo\":\"bar\"}","header":"Authorization:Bearer token",` +
+				`"url":"https://api.example.com"}`,
+			want: "curl -XPOST --data '{\"foo\":\"bar\"}' -H Authorization:Bearer token https://api.example.com",
+		},
+		"empty-json": {
+			input:           "No parameters here",
+			jsonValueParams: `{}`,
+			want:            "No parameters here",
+		},
+		"unused-parameters": {
+			input:           "Only {{used}} parameter",
+			jsonValueParams: `{"used":"this one","unused":"ignored"}`,
+			want:            "Only this one parameter",
+		},
+		"special-characters": {
+			input:           "Path: {{path}}",
+			jsonValueParams: `{"path":"/home/user/my documents/file.txt"}`,
+			want:            "Path: /home/user/my documents/file.txt",
+		},
+		"empty-value": {
+			input:           "Value: {{empty}}",
+			jsonValueParams: `{"empty":""}`,
+			want:            "Value: ",
+		},
+		"whitespace-in-json": {
+			input:           "{{first}} {{second}}",
+			jsonValueParams: `{ "first" : "one" , "second" : "two" }`,
+			want:            "one two",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := HydrateStringFromJSON(tc.input, tc.jsonValueParams)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHydrateStringFromJSON_WithEmptyString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"with-parameters": {
+			input: "curl -XGET {{url}} -H {{header}}",
+			want:  "curl -XGET {{url}} -H {{header}}",
+		},
+		"no-parameters": {
+			input: "echo hello world",
+			want:  "echo hello world",
+		},
+		"with-description-pipes": {
+			input: "{{name|user name}} says {{message|greeting message}}",
+			want:  "{{name|user name}} says {{message|greeting message}}",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Empty string should work the same as "{}"
+			got, err := HydrateStringFromJSON(tc.input, "")
+			if err != nil {
+				t.Fatalf("unexpected error with empty string: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected: %q, got: %q", tc.want, got)
+			}
+
+			// Verify it's the same as using "{}"
+			gotWithEmptyJSON, err := HydrateStringFromJSON(tc.input, "{}")
+			if err != nil {
+				t.Fatalf("unexpected error with empty JSON: %v", err)
+			}
+
+			if got != gotWithEmptyJSON {
+				t.Errorf("empty string and empty JSON should produce same result: %q vs %q", got, gotWithEmptyJSON)
+			}
+		})
+	}
+}
+
+func TestHydrateStringFromJSON_Err(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		input           string
+		jsonValueParams string
+		wantErr         error
+	}
+
+	tests := map[string]testcase{
+		"invalid-json-syntax": {
+			input:           "{{param}}",
+			jsonValueParams: `{invalid}`,
+			wantErr:         ErrParsingValueParams,
+		},
+		"invalid-json-missing-quotes": {
+			input:           "{{param}}",
+			jsonValueParams: `{param:value}`,
+			wantErr:         ErrParsingValueParams,
+		},
+		"invalid-json-trailing-comma": {
+			input:           "{{param}}",
+			jsonValueParams: `{"param":"value",}`,
+			wantErr:         ErrParsingValueParams,
+		},
+		"invalid-json-single-quotes": {
+			input:           "{{param}}",
+			jsonValueParams: `{'param':'value'}`,
+			wantErr:         ErrParsingValueParams,
+		},
+		"invalid-json-array": {
+			input:           "{{param}}",
+			jsonValueParams: `["param","value"]`,
+			wantErr:         ErrParsingValueParams,
+		},
+		"plain-text": {
+			input:           "{{param}}",
+			jsonValueParams: "not json",
+			wantErr:         ErrParsingValueParams,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := HydrateStringFromJSON(tc.input, tc.jsonValueParams)
+			if got != "" {
+				t.Fatalf("expected empty string, got: %q", got)
+			}
+
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error: %v, got error: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParameters_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		params   Parameters
+		expected string
+	}{
+		{
+			name: "sorted output",
+			params: Parameters{
+				{Name: "zebra", Description: "last"},
+				{Name: "apple", Description: "first"},
+				{Name: "monkey", Description: "middle"},
+			},
+			expected: `[{"name":"apple","description":"first"},{"name":"monkey","description":"middle"},{"name":"zebra","description":"last"}]`, //nolint:lll
+		},
+		{
+			name:     "empty slice",
+			params:   Parameters{},
+			expected: `[]`,
+		},
+		{
+			name:     "nil slice",
+			params:   nil,
+			expected: `[]`,
+		},
+		{
+			name: "single element",
+			params: Parameters{
+				{Name: "single", Description: "only one"},
+			},
+			expected: `[{"name":"single","description":"only one"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.Marshal(tt.params)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			if string(got) != tt.expected {
+				t.Errorf("Marshal() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParameters_MarshalIndent(t *testing.T) {
+	t.Parallel()
+
+	p := Parameters{
+		{Name: "zebra", Description: "last"},
+		{Name: "alpha"},
+	}
+
+	want := `[
+  {
+    "name": "alpha"
+  },
+  {
+    "name": "zebra",
+    "description": "last"
+  }
+]`
+
+	for range 3 {
+		got, err := p.MarshalIndent()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(got) != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+
+	if p[0].Name != "zebra" {
+		t.Fatalf("expected original order to be preserved, got %v", p)
+	}
+}
+
+func TestParameters_Signature(t *testing.T) {
+	t.Parallel()
+
+	base := Parameters{
+		{Name: "env", Description: "target"},
+		{Name: "version"},
+	}
+
+	tests := map[string]struct {
+		other Parameters
+		same  bool
+	}{
+		"identical":           {other: Parameters{{Name: "env", Description: "target"}, {Name: "version"}}, same: true},
+		"reordered":           {other: Parameters{{Name: "version"}, {Name: "env", Description: "target"}}, same: true},
+		"description changed": {other: Parameters{{Name: "env", Description: "stage"}, {Name: "version"}}},
+		"description added":   {other: Parameters{{Name: "env", Description: "target"}, {Name: "version", Description: "v"}}},
+		"name changed":        {other: Parameters{{Name: "env", Description: "target"}, {Name: "tag"}}},
+		"parameter removed":   {other: Parameters{{Name: "env", Description: "target"}}},
+		"empty":               {other: Parameters{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := base.Signature() == tc.other.Signature()
+			if got != tc.same {
+				t.Fatalf("expected same signature %v, got %v", tc.same, got)
+			}
+		})
+	}
+}
+
+func TestParameters_SignatureNil(t *testing.T) {
+	t.Parallel()
+
+	var p Parameters
+
+	if p.Signature() != (Parameters{}).Signature() {
+		t.Fatalf("expected nil and empty parameters to share a signature")
+	}
+
+	if len(p.Signature()) != 64 {
+		t.Fatalf("expected a 64 character hex digest, got %q", p.Signature())
+	}
+}
+
+func TestParameters_MarshalIndentNil(t *testing.T) {
+	t.Parallel()
+
+	var p Parameters
+
+	got, err := p.MarshalIndent()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(got) != "[]" {
+		t.Fatalf("expected [], got %s", got)
+	}
+}
+
+func TestValuedParameters_Validate(t *testing.T) {
+	t.Parallel()
+
+	params := Parameters{{Name: "env", Description: "target"}, {Name: "region"}}
+
+	tests := map[string]struct {
+		values     ValuedParameters
+		undeclared []string
+	}{
+		"declared values pass": {
+			values: ValuedParameters{{Name: "env", Value: "prod"}, {Name: "region", Value: "eu"}},
+		},
+		"nothing supplied": {
+			values: nil,
+		},
+		"undeclared value": {
+			values:     ValuedParameters{{Name: "env", Value: "prod"}, {Name: "zone", Value: "a"}},
+			undeclared: []string{"zone"},
+		},
+		"every violation reported": {
+			values:     ValuedParameters{{Name: "tier", Value: "1"}, {Name: "zone", Value: "a"}},
+			undeclared: []string{"tier", "zone"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.values.Validate(params)
+			if len(tc.undeclared) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrUndeclaredParameter) {
+				t.Fatalf("expected %v, got %v", ErrUndeclaredParameter, err)
+			}
+
+			for _, n := range tc.undeclared {
+				if !strings.Contains(err.Error(), n) {
+					t.Fatalf("expected error to name %q, got %v", n, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValuedParameters_ValidatePattern(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := Parse(`serve --port {{ port | the port | /^\d+$/ }} {{version||/^v\d+\.\d+\.\d+$/}}`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := Parameters{
+		{Name: "port", Description: "the port", Pattern: `^\d+$`},
+		{Name: "version", Pattern: `^v\d+\.\d+\.\d+$`},
+	}
+	if !slices.Equal(*parsed.Parameters, want) {
+		t.Fatalf("expected %v, got %v", want, *parsed.Parameters)
+	}
+
+	wantCommand := `serve --port {{port|the port|/^\d+$/}} {{version||/^v\d+\.\d+\.\d+$/}}`
+	if parsed.Command != wantCommand {
+		t.Fatalf("expected %q, got %q", wantCommand, parsed.Command)
+	}
+
+	tests := map[string]struct {
+		values ValuedParameters
+		want   error
+	}{
+		"matching": {
+			values: ValuedParameters{{Name: "port", Value: "8080"}, {Name: "version", Value: "v1.2.3"}},
+		},
+		"non-matching": {
+			values: ValuedParameters{{Name: "port", Value: "http"}},
+			want:   ErrPatternMismatch,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.values.Validate(*parsed.Parameters)
+			if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestSplitPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		desc        string
+		wantDesc    string
+		wantPattern string
+	}{
+		"no pattern":               {desc: "the port", wantDesc: "the port"},
+		"pattern":                  {desc: `the port | /^\d+$/ `, wantDesc: "the port", wantPattern: `^\d+$`},
+		"pattern with pipes":       {desc: "env|/^(dev|prod)$/", wantDesc: "env", wantPattern: "^(dev|prod)$"},
+		"empty description":        {desc: "|/^v/", wantPattern: "^v"},
+		"slashes in description":   {desc: "path like /tmp/", wantDesc: "path like /tmp/"},
+		"slashes after later pipe": {desc: "a | b | /tmp/", wantDesc: "a | b | /tmp/"},
+		"not wrapped in slashes":   {desc: "a | /tmp", wantDesc: "a | /tmp"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			desc, pattern := splitPattern(tc.desc)
+			if desc != tc.wantDesc || pattern != tc.wantPattern {
+				t.Fatalf("expected %q, %q, got %q, %q", tc.wantDesc, tc.wantPattern, desc, pattern)
+			}
+		})
+	}
+}
+
+func TestParseParameters_MalformedPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseParameters(`serve --port {{port|the port|/^(\d+$/}}`)
+	if !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("expected %v, got %v", ErrInvalidPattern, err)
+	}
+
+	var pErr *ParameterError
+	if !errors.As(err, &pErr) || pErr.Name != "port" {
+		t.Fatalf("expected a *ParameterError for %q, got %v", "port", err)
+	}
+}
+
+// AllocsPerRun cannot be used in a parallel test.
+func TestCompilePattern_Cached(t *testing.T) { // nolint:paralleltest
+	first, err := compilePattern(`^cache-\d+$`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := compilePattern(`^cache-\d+$`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected the cached regexp %p, got %p", first, second)
+	}
+
+	other, err := compilePattern(`^cache-\w+$`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if other == first {
+		t.Fatal("expected a different regexp for a different pattern")
+	}
+
+	// A cache hit does not compile, so it does not allocate.
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = compilePattern(`^cache-\d+$`)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected %v allocations for a cached pattern, got %v", 0, allocs)
+	}
+}
+
+func TestParameters_ToValued(t *testing.T) {
+	t.Parallel()
+
+	p := Parameters{{Name: "env", Description: "target"}, {Name: "version"}}
+	want := ValuedParameters{{Name: "env"}, {Name: "version"}}
+
+	if got := p.ToValued(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParameters_ToValuedFromJSONPartial(t *testing.T) {
+	t.Parallel()
+
+	p := Parameters{{Name: "env", Description: "target"}, {Name: "version"}, {Name: "region"}}
+
+	tests := map[string]struct {
+		json        string
+		wantValues  ValuedParameters
+		wantMissing Parameters
+	}{
+		"partial": {
+			json:        `{"region":"eu","env":"prod"}`,
+			wantValues:  ValuedParameters{{Name: "env", Value: "prod"}, {Name: "region", Value: "eu"}},
+			wantMissing: Parameters{{Name: "version"}},
+		},
+		"complete": {
+			json:        `{"env":"prod","version":"1.2","region":"eu"}`,
+			wantValues:  ValuedParameters{{Name: "env", Value: "prod"}, {Name: "version", Value: "1.2"}, {Name: "region", Value: "eu"}},
+			wantMissing: nil,
+		},
+		"empty value counts as given": {
+			json:        `{"version":""}`,
+			wantValues:  ValuedParameters{{Name: "version"}},
+			wantMissing: Parameters{{Name: "env", Description: "target"}, {Name: "region"}},
 		},
-		"with-description-pipes": {
-			input: "{{name|user name}} says {{message|greeting message}}",
-			want:  "{{name|user name}} says {{message|greeting message}}",
+		"unknown key ignored": {
+			json:        `{"other":"x","env":"prod"}`,
+			wantValues:  ValuedParameters{{Name: "env", Value: "prod"}},
+			wantMissing: Parameters{{Name: "version"}, {Name: "region"}},
+		},
+		"empty string": {
+			json:        "",
+			wantValues:  ValuedParameters{},
+			wantMissing: p,
 		},
 	}
 
@@ -651,68 +1987,45 @@ func TestHydrateStringFromJSON_WithEmptyString(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			// Empty string should work the same as "{}"
-			got, err := HydrateStringFromJSON(tc.input, "")
+			values, missing, err := p.ToValuedFromJSONPartial(tc.json)
 			if err != nil {
-				t.Fatalf("unexpected error with empty string: %v", err)
-			}
-
-			if got != tc.want {
-				t.Errorf("expected: %q, got: %q", tc.want, got)
+				t.Fatalf("unexpected error: %v", err)
 			}
 
-			// Verify it's the same as using "{}"
-			gotWithEmptyJSON, err := HydrateStringFromJSON(tc.input, "{}")
-			if err != nil {
-				t.Fatalf("unexpected error with empty JSON: %v", err)
+			if !reflect.DeepEqual(values, tc.wantValues) {
+				t.Errorf("expected values %v, got %v", tc.wantValues, values)
 			}
 
-			if got != gotWithEmptyJSON {
-				t.Errorf("empty string and empty JSON should produce same result: %q vs %q", got, gotWithEmptyJSON)
+			if !reflect.DeepEqual(missing, tc.wantMissing) {
+				t.Errorf("expected missing %v, got %v", tc.wantMissing, missing)
 			}
 		})
 	}
 }
 
-func TestHydrateStringFromJSON_Err(t *testing.T) { //nolint:funlen
+func TestParameters_ToValuedFromJSONPartial_Err(t *testing.T) {
 	t.Parallel()
 
-	type testcase struct {
-		input           string
-		jsonValueParams string
-		wantErr         error
+	p := Parameters{{Name: "env"}}
+
+	for _, input := range []string{`{"env":`, `["env"]`, `{"env":1}`} {
+		if _, _, err := p.ToValuedFromJSONPartial(input); !errors.Is(err, ErrParsingValueParams) {
+			t.Errorf("expected error %v for %s, got %v", ErrParsingValueParams, input, err)
+		}
 	}
+}
 
-	tests := map[string]testcase{
-		"invalid-json-syntax": {
-			input:           "{{param}}",
-			jsonValueParams: `{invalid}`,
-			wantErr:         ErrParsingValueParams,
-		},
-		"invalid-json-missing-quotes": {
-			input:           "{{param}}",
-			jsonValueParams: `{param:value}`,
-			wantErr:         ErrParsingValueParams,
-		},
-		"invalid-json-trailing-comma": {
-			input:           "{{param}}",
-			jsonValueParams: `{"param":"value",}`,
-			wantErr:         ErrParsingValueParams,
-		},
-		"invalid-json-single-quotes": {
-			input:           "{{param}}",
-			jsonValueParams: `{'param':'value'}`,
-			wantErr:         ErrParsingValueParams,
-		},
-		"invalid-json-array": {
-			input:           "{{param}}",
-			jsonValueParams: `["param","value"]`,
-			wantErr:         ErrParsingValueParams,
-		},
-		"plain-text": {
-			input:           "{{param}}",
-			jsonValueParams: "not json",
-			wantErr:         ErrParsingValueParams,
+func TestValuedParameters_ToJSONObject(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		vp   ValuedParameters
+		want string
+	}{
+		"empty": {vp: nil, want: "{}"},
+		"sorted keys": {
+			vp:   ValuedParameters{{Name: "version", Value: "1.2"}, {Name: "env"}},
+			want: "{\n  \"env\": \"\",\n  \"version\": \"1.2\"\n}",
 		},
 	}
 
@@ -720,74 +2033,95 @@ func TestHydrateStringFromJSON_Err(t *testing.T) { //nolint:funlen
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := HydrateStringFromJSON(tc.input, tc.jsonValueParams)
-			if got != "" {
-				t.Fatalf("expected empty string, got: %q", got)
+			got, err := tc.vp.ToJSONObject()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if err == nil {
-				t.Fatalf("expected error, got nil")
+			if string(got) != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
 			}
 
-			if !errors.Is(err, tc.wantErr) {
-				t.Errorf("expected error: %v, got error: %v", tc.wantErr, err)
+			if _, err := ValuedParametersFromJSON(string(got)); err != nil {
+				t.Fatalf("expected output to parse as values, got %v", err)
 			}
 		})
 	}
 }
 
-func TestParameters_MarshalJSON(t *testing.T) {
+func TestMarshalValuedParametersObject(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name     string
-		params   Parameters
-		expected string
+	tests := map[string]struct {
+		vp   ValuedParameters
+		want string
 	}{
-		{
-			name: "sorted output",
-			params: Parameters{
-				{Name: "zebra", Description: "last"},
-				{Name: "apple", Description: "first"},
-				{Name: "monkey", Description: "middle"},
-			},
-			expected: `[{"name":"apple","description":"first"},{"name":"monkey","description":"middle"},{"name":"zebra","description":"last"}]`, //nolint:lll
-		},
-		{
-			name:     "empty slice",
-			params:   Parameters{},
-			expected: `[]`,
-		},
-		{
-			name:     "nil slice",
-			params:   nil,
-			expected: `[]`,
-		},
-		{
-			name: "single element",
-			params: Parameters{
-				{Name: "single", Description: "only one"},
-			},
-			expected: `[{"name":"single","description":"only one"}]`,
-		},
+		"empty":       {vp: nil, want: "{}"},
+		"sorted keys": {vp: ValuedParameters{{Name: "version", Value: "1.2"}, {Name: "env"}}, want: `{"env":"","version":"1.2"}`},
+		"escaped":     {vp: ValuedParameters{{Name: "msg", Value: `say "hi" & <go>`}}, want: `{"msg":"say \"hi\" \u0026 \u003cgo\u003e"}`},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := json.Marshal(tt.params)
+			got, err := MarshalValuedParametersObject(tc.vp)
 			if err != nil {
-				t.Fatalf("Marshal() error = %v", err)
+				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if string(got) != tt.expected {
-				t.Errorf("Marshal() = %s, want %s", got, tt.expected)
+			if string(got) != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+
+			back, err := ValuedParametersFromJSON(string(got))
+			if err != nil {
+				t.Fatalf("expected output to parse as values, got %v", err)
+			}
+
+			sortValued := func(vp ValuedParameters) ValuedParameters {
+				vp = slices.Clone(vp)
+				slices.SortFunc(vp, func(a, b ValuedParameter) int { return strings.Compare(a.Name, b.Name) })
+
+				return vp
+			}
+
+			if !reflect.DeepEqual(sortValued(back), sortValued(tc.vp)) && len(tc.vp) > 0 {
+				t.Fatalf("expected %v back, got %v", tc.vp, back)
 			}
 		})
 	}
 }
 
+func TestValuedParameters_MarshalIndent(t *testing.T) {
+	t.Parallel()
+
+	vp := ValuedParameters{
+		{Name: "version", Value: "1.2.3"},
+		{Name: "env", Value: "prod"},
+	}
+
+	want := `[
+  {
+    "name": "env",
+    "value": "prod"
+  },
+  {
+    "name": "version",
+    "value": "1.2.3"
+  }
+]`
+
+	got, err := vp.MarshalIndent()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
 func TestParameters_UnmarshalJSON(t *testing.T) {
 	t.Parallel()
 
@@ -1196,6 +2530,29 @@ func TestParameters_Description_NotFound(t *testing.T) {
 	}
 }
 
+func TestParameters_NilPointer(t *testing.T) {
+	t.Parallel()
+
+	var p *Parameters
+
+	if got := p.ToMap(); got == nil || len(got) != 0 {
+		t.Errorf("ToMap() on nil pointer = %v, want empty map", got)
+	}
+
+	if got := p.Names(); got == nil || len(got) != 0 {
+		t.Errorf("Names() on nil pointer = %v, want empty slice", got)
+	}
+
+	desc, err := p.Description("missing")
+	if desc != "" {
+		t.Errorf("Description() on nil pointer = %q, want empty string", desc)
+	}
+
+	if !errors.Is(err, ErrParameterNotFound) {
+		t.Errorf("Description() on nil pointer error = %v, want ErrParameterNotFound", err)
+	}
+}
+
 func TestParameters_Replace(t *testing.T) { // nolint:funlen,gocognit,cyclop
 	t.Parallel()
 
@@ -1864,57 +3221,281 @@ func TestThreeWayMerge_MultipleParameters(t *testing.T) {
 		t.Errorf("alpha: expected 'priority changed', got '%s'", desc)
 	}
 
-	// beta: priority didn't change, updated changed -> take updated
-	desc, _ = priority.Description("beta")
-	if desc != "updated changed" {
-		t.Errorf("beta: expected 'updated changed', got '%s'", desc)
+	// beta: priority didn't change, updated changed -> take updated
+	desc, _ = priority.Description("beta")
+	if desc != "updated changed" {
+		t.Errorf("beta: expected 'updated changed', got '%s'", desc)
+	}
+
+	// gamma: both changed, updated is longer -> take updated
+	desc, _ = priority.Description("gamma")
+	if desc != "updated changed longer" {
+		t.Errorf("gamma: expected 'updated changed longer', got '%s'", desc)
+	}
+
+	// delta: new parameter, updated is longer -> take updated
+	desc, _ = priority.Description("delta")
+	if desc != "new but longer description" {
+		t.Errorf("delta: expected 'new but longer description', got '%s'", desc)
+	}
+}
+
+func TestThreeWayMerge_UpdatedMissingParameter(t *testing.T) {
+	t.Parallel()
+
+	priority := &Parameters{
+		{Name: "alpha", Description: "changed"},
+	}
+	before := &Parameters{
+		{Name: "alpha", Description: "original"},
+	}
+	updated := &Parameters{}
+
+	priority.ThreeWayMerge(before, updated)
+
+	// Priority changed but updated doesn't have it -> keep priority
+	desc, _ := priority.Description("alpha")
+	if desc != "changed" {
+		t.Errorf("expected 'changed', got '%s'", desc)
+	}
+}
+
+func TestThreeWayMerge_EmptyPriority(t *testing.T) {
+	t.Parallel()
+
+	priority := &Parameters{}
+	before := &Parameters{{Name: "alpha", Description: "before"}}
+	updated := &Parameters{{Name: "alpha", Description: "updated"}}
+
+	priority.ThreeWayMerge(before, updated)
+
+	// Priority is empty, nothing to merge
+	if len(*priority) != 0 {
+		t.Errorf("expected empty priority, got %d items", len(*priority))
+	}
+}
+
+func TestParameters_WithoutSecrets(t *testing.T) {
+	t.Parallel()
+
+	mixed := Parameters{
+		{Name: "path", Description: "directory"},
+		{Name: "!token", Description: "api token"},
+		{Name: "verbose"},
+		{Name: "!password"},
+	}
+
+	want := Parameters{
+		{Name: "path", Description: "directory"},
+		{Name: "verbose"},
+	}
+
+	if got := mixed.WithoutSecrets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParameters_OnlySecrets(t *testing.T) {
+	t.Parallel()
+
+	mixed := Parameters{
+		{Name: "path", Description: "directory"},
+		{Name: "!token", Description: "api token"},
+		{Name: "verbose"},
+		{Name: "!password"},
+	}
+
+	want := Secrets{
+		{Key: "token", Description: "api token"},
+		{Key: "password"},
+	}
+
+	if got := mixed.OnlySecrets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParameters_FilterUsed(t *testing.T) {
+	t.Parallel()
+
+	stored := Parameters{
+		{Name: "env", Description: "target"},
+		{Name: "region"},
+		{Name: SecretName("token")},
+	}
+
+	tests := map[string]struct {
+		command string
+		want    []string
+	}{
+		"all used":          {command: "deploy {{env}} {{region|aws region}} {{!token}}", want: []string{"env", "region", "!token"}},
+		"parameter dropped": {command: "deploy {{env}} {{!token}}", want: []string{"env", "!token"}},
+		"secret dropped":    {command: "deploy {{env}} {{region}}", want: []string{"env", "region"}},
+		"none used":         {command: "deploy", want: nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, p := range stored.FilterUsed(tc.command) {
+				got = append(got, p.Name)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParameters_Rename(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		params  Parameters
+		oldName string
+		newName string
+		renamed bool
+		want    Parameters
+	}{
+		"existing re-sorted": {
+			params:  Parameters{{Name: "a", Description: "first"}, {Name: "m", Description: "middle"}},
+			oldName: "a",
+			newName: "z",
+			renamed: true,
+			want:    Parameters{{Name: "m", Description: "middle"}, {Name: "z", Description: "first"}},
+		},
+		"missing": {
+			params:  Parameters{{Name: "a"}},
+			oldName: "b",
+			newName: "c",
+			renamed: false,
+			want:    Parameters{{Name: "a"}},
+		},
+		"merged into existing": {
+			params:  Parameters{{Name: "env", Description: "old"}, {Name: "target", Description: "kept"}},
+			oldName: "env",
+			newName: "target",
+			renamed: true,
+			want:    Parameters{{Name: "target", Description: "kept"}},
+		},
+		"merged takes description": {
+			params:  Parameters{{Name: "env", Description: "old"}, {Name: "target"}},
+			oldName: "env",
+			newName: "target",
+			renamed: true,
+			want:    Parameters{{Name: "target", Description: "old"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := slices.Clone(tc.params)
+
+			if renamed := got.Rename(tc.oldName, tc.newName); renamed != tc.renamed {
+				t.Fatalf("expected renamed %v, got %v", tc.renamed, renamed)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParameters_SplitSecretsEmpty(t *testing.T) {
+	t.Parallel()
+
+	var pp Parameters
+
+	if got := pp.WithoutSecrets(); len(got) != 0 {
+		t.Fatalf("expected no parameters, got %v", got)
+	}
+
+	if got := pp.OnlySecrets(); len(got) != 0 {
+		t.Fatalf("expected no secrets, got %v", got)
 	}
+}
 
-	// gamma: both changed, updated is longer -> take updated
-	desc, _ = priority.Description("gamma")
-	if desc != "updated changed longer" {
-		t.Errorf("gamma: expected 'updated changed longer', got '%s'", desc)
+func TestParameter_String(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		param fmt.Stringer
+		want  string
+	}{
+		"parameter with description":    {param: Parameter{Name: "env", Description: "target env"}, want: "env (target env)"},
+		"parameter without description": {param: Parameter{Name: "env"}, want: "env"},
+		"secret with description":       {param: Secret{Key: "token", Description: "api token"}, want: "!token (api token)"},
+		"secret without description":    {param: Secret{Key: "token"}, want: "!token"},
 	}
 
-	// delta: new parameter, updated is longer -> take updated
-	desc, _ = priority.Description("delta")
-	if desc != "new but longer description" {
-		t.Errorf("delta: expected 'new but longer description', got '%s'", desc)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.param.String(); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
 	}
 }
 
-func TestThreeWayMerge_UpdatedMissingParameter(t *testing.T) {
+func TestParameters_String(t *testing.T) {
 	t.Parallel()
 
-	priority := &Parameters{
-		{Name: "alpha", Description: "changed"},
+	pp := Parameters{{Name: "env", Description: "target env"}, {Name: "version"}}
+	if got, want := pp.String(), "env (target env), version"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
 	}
-	before := &Parameters{
-		{Name: "alpha", Description: "original"},
-	}
-	updated := &Parameters{}
 
-	priority.ThreeWayMerge(before, updated)
+	ss := Secrets{{Key: "token"}, {Key: "db_pass", Description: "database"}}
+	if got, want := ss.String(), "!token, !db_pass (database)"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
 
-	// Priority changed but updated doesn't have it -> keep priority
-	desc, _ := priority.Description("alpha")
-	if desc != "changed" {
-		t.Errorf("expected 'changed', got '%s'", desc)
+	if got := (Parameters{}).String(); got != "" {
+		t.Fatalf("expected empty string, got %q", got)
 	}
 }
 
-func TestThreeWayMerge_EmptyPriority(t *testing.T) {
+func TestSecrets_MissingSubset(t *testing.T) {
 	t.Parallel()
 
-	priority := &Parameters{}
-	before := &Parameters{{Name: "alpha", Description: "before"}}
-	updated := &Parameters{{Name: "alpha", Description: "updated"}}
+	resolved := ValuedParameters{
+		{Name: "user", Value: "admin"},
+		{Name: SecretName("token"), Value: "s3cr3t"},
+		{Name: "db_pass", Value: "not a secret value"},
+	}
 
-	priority.ThreeWayMerge(before, updated)
+	tests := map[string]struct {
+		secrets Secrets
+		want    []string
+	}{
+		"all resolved": {secrets: Secrets{{Key: "token"}}, want: nil},
+		"some missing": {secrets: Secrets{{Key: "token"}, {Key: "db_pass"}}, want: []string{"db_pass"}},
+		"no secrets":   {secrets: nil, want: nil},
+	}
 
-	// Priority is empty, nothing to merge
-	if len(*priority) != 0 {
-		t.Errorf("expected empty priority, got %d items", len(*priority))
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			missing := tc.secrets.MissingSubset(resolved)
+
+			var got []string
+			for _, secret := range missing {
+				got = append(got, secret.Key)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
 	}
 }
 
@@ -2182,6 +3763,175 @@ func TestParseSecrets_IgnoresParameters(t *testing.T) {
 	}
 }
 
+func TestSecretPrefix_Default(t *testing.T) {
+	t.Parallel()
+
+	if SecretPrefix() != DefaultSecretPrefix {
+		t.Fatalf("expected default prefix %q, got %q", DefaultSecretPrefix, SecretPrefix())
+	}
+
+	b, err := Parse("curl {{url}} -H {{!token|auth token}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(*b.Secrets, Secrets{{"token", "auth token"}}) {
+		t.Errorf("expected secrets %v, got %v", Secrets{{"token", "auth token"}}, *b.Secrets)
+	}
+
+	if !reflect.DeepEqual(*b.Parameters, Parameters{{Name: "url", Description: ""}}) {
+		t.Errorf("expected parameters %v, got %v", Parameters{{Name: "url", Description: ""}}, *b.Parameters)
+	}
+
+	if SecretName("token") != "!token" {
+		t.Errorf("expected secret name %q, got %q", "!token", SecretName("token"))
+	}
+}
+
+// Changes package-level state, so it must not run in parallel with other tests.
+func TestSecretPrefix_Custom(t *testing.T) { //nolint:paralleltest
+	if err := SetSecretPrefix("$$"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := SetSecretPrefix(DefaultSecretPrefix); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	b, err := Parse("curl {{url}} -H {{$$token|auth token}} -d 'hi!'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(*b.Secrets, Secrets{{"token", "auth token"}}) {
+		t.Errorf("expected secrets %v, got %v", Secrets{{"token", "auth token"}}, *b.Secrets)
+	}
+
+	if !reflect.DeepEqual(*b.Parameters, Parameters{{Name: "url", Description: ""}}) {
+		t.Errorf("expected parameters %v, got %v", Parameters{{Name: "url", Description: ""}}, *b.Parameters)
+	}
+
+	// The default prefix is now an ordinary (invalid) parameter name
+	if _, err := ParseParameters("echo {{!token}}"); !errors.Is(err, ErrContainsInvalidSymbols) {
+		t.Errorf("expected error %v, got %v", ErrContainsInvalidSymbols, err)
+	}
+
+	vp := ValuedParameters{{"url", "https://example.com"}, {SecretName("token"), "s3cr3t"}}
+
+	got, err := HydrateString(b.Command, vp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "curl https://example.com -H s3cr3t -d 'hi!'" {
+		t.Errorf("unexpected hydrated command: %q", got)
+	}
+
+	var unmarshaled ValuedParameters
+	if err := json.Unmarshal([]byte(`[{"name":"$$token","value":"x"},{"name":"url","value":"y"}]`), &unmarshaled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(unmarshaled, ValuedParameters{{"url", "y"}}) {
+		t.Errorf("expected secrets to be filtered, got %v", unmarshaled)
+	}
+}
+
+func TestTrimTrailingSeparator(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"trailing semicolon":          {input: "ls -la ;", want: "ls -la"},
+		"trailing semicolon no space": {input: "ls -la;", want: "ls -la"},
+		"trailing pipe":               {input: "cat {{file}} |", want: "cat {{file}}"},
+		"trailing whitespace":         {input: "ls -la ;  \n", want: "ls -la"},
+		"no separator":                {input: "ls -la", want: "ls -la"},
+		"mid command separators":      {input: "cd /tmp; ls | wc -l", want: "cd /tmp; ls | wc -l"},
+		"single quoted":               {input: "echo 'a ;", want: "echo 'a ;"},
+		"double quoted":               {input: "echo \"a |", want: "echo \"a |"},
+		"closed quote before":         {input: "echo 'a;' ;", want: "echo 'a;'"},
+		"escaped semicolon":           {input: "find . -exec rm {} \\;", want: "find . -exec rm {} \\;"},
+		"case terminator":             {input: "case $x in a) echo a;;", want: "case $x in a) echo a;;"},
+		"or operator":                 {input: "make ||", want: "make ||"},
+		"pipe stderr":                 {input: "make &|", want: "make &|"},
+		"only separator":              {input: ";", want: ""},
+		"empty":                       {input: "", want: ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := TrimTrailingSeparator(tc.input); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// Changes package-level state, so it must not run in parallel with other tests.
+func TestParseCommand_TrimTrailingSeparator(t *testing.T) { //nolint:paralleltest
+	got, err := ParseCommand("ls   -la {{path}} ;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "ls -la {{path}} ;" {
+		t.Fatalf("expected separator kept by default, got %q", got)
+	}
+
+	SetTrimTrailingSeparator(true)
+	t.Cleanup(func() {
+		SetTrimTrailingSeparator(false)
+	})
+
+	for input, want := range map[string]string{
+		"ls   -la {{path}} ;":    "ls -la {{path}}",
+		"cat {{file}} |":         "cat {{file}}",
+		"echo 'done;'":           "echo 'done;'",
+		"echo 'keep ; quoted ;'": "echo 'keep ; quoted ;'",
+		"echo a ; ;":             "echo a",
+		"echo {{a}} ; |":         "echo {{a}}",
+		"esac ;; ;":              "esac ;;",
+	} {
+		got, err := ParseCommand(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestSetSecretPrefix_ErrEmpty(t *testing.T) {
+	t.Parallel()
+
+	if err := SetSecretPrefix(""); !errors.Is(err, ErrEmptySecretPrefix) {
+		t.Fatalf("expected error %v, got %v", ErrEmptySecretPrefix, err)
+	}
+}
+
+func TestSetSecretPrefix_ErrInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, prefix := range []string{"{", "|", "}", "!|"} {
+		if err := SetSecretPrefix(prefix); !errors.Is(err, ErrInvalidSecretPrefix) {
+			t.Fatalf("expected error %v for %q, got %v", ErrInvalidSecretPrefix, prefix, err)
+		}
+	}
+
+	if got := SecretPrefix(); strings.ContainsAny(got, "{|}") {
+		t.Fatalf("expected the prefix to be unchanged, got %q", got)
+	}
+}
+
 func TestParse_IgnoresSecrets(t *testing.T) {
 	t.Parallel()
 
@@ -2195,7 +3945,7 @@ func TestParse_IgnoresSecrets(t *testing.T) {
 		"mixed-params-and-secrets": {
 			input:       "curl {{url}} -H {{!token}}",
 			wantCommand: "curl {{url}} -H {{!token}}",
-			wantParams:  Parameters{{"url", ""}},
+			wantParams:  Parameters{{Name: "url", Description: ""}},
 		},
 		"only-secrets": {
 			input:       "echo {{!password}} {{!apikey}}",
@@ -2205,7 +3955,7 @@ func TestParse_IgnoresSecrets(t *testing.T) {
 		"params-with-desc-and-secrets": {
 			input:       "{{name|user name}} says {{message}} to {{!admin}}",
 			wantCommand: "{{name|user name}} says {{message}} to {{!admin}}",
-			wantParams:  Parameters{{"name", "user name"}, {"message", ""}},
+			wantParams:  Parameters{{Name: "name", Description: "user name"}, {Name: "message", Description: ""}},
 		},
 	}
 
@@ -2242,6 +3992,105 @@ func TestParse_IgnoresSecrets(t *testing.T) {
 	}
 }
 
+func TestParse_JoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  []error
+	}{
+		"parameter and secret": {
+			input: "deploy {{1version}} {{!tok$en}}",
+			want:  []error{ErrStartsWithInvalidChar, ErrContainsInvalidSymbols},
+		},
+		"parameter only": {
+			input: "deploy {{1version}} {{!token}}",
+			want:  []error{ErrStartsWithInvalidChar},
+		},
+		"secret only": {
+			input: "deploy {{version}} {{!tok$en}}",
+			want:  []error{ErrContainsInvalidSymbols},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := Parse(tc.input)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", b)
+			}
+
+			for _, want := range tc.want {
+				if !errors.Is(err, want) {
+					t.Fatalf("expected error %v in %v", want, err)
+				}
+			}
+
+			var pe *ParameterError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected a ParameterError in %v", err)
+			}
+		})
+	}
+}
+
+func TestParse_SecretCollision(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  []string
+	}{
+		"same name": {
+			input: "curl -u {{token}}:{{!token}} {{url}}",
+			want:  []string{"token"},
+		},
+		"parameter with description": {
+			input: "login {{user|the user}} {{!user}}",
+			want:  []string{"user"},
+		},
+		"several names": {
+			input: "{{a}} {{b}} {{!b}} {{!a}}",
+			want:  []string{"a", "b"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := Parse(tc.input)
+			if !errors.Is(err, ErrSecretCollision) {
+				t.Fatalf("expected error %v, got %v and %+v", ErrSecretCollision, err, b)
+			}
+
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q to be named in %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_NoSecretCollision(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"curl -u {{user}}:{{!token}} {{url}}",
+		"echo {{token_name}} {{!token}}",
+		"echo {{!token}} {{!token}}",
+	}
+
+	for _, input := range tests {
+		if _, err := Parse(input); err != nil {
+			t.Errorf("expected %q to parse, got %v", input, err)
+		}
+	}
+}
+
 func TestParse(t *testing.T) { //nolint:funlen,gocognit,cyclop
 	t.Parallel()
 
@@ -2256,27 +4105,27 @@ func TestParse(t *testing.T) { //nolint:funlen,gocognit,cyclop
 		"standard": {
 			input:       "Hello, {{name}}! Welcome to {{place}}.",
 			wantCommand: "Hello, {{name}}! Welcome to {{place}}.",
-			wantParams:  Parameters{{"name", ""}, {"place", ""}},
+			wantParams:  Parameters{{Name: "name", Description: ""}, {Name: "place", Description: ""}},
 		},
 		"with-descriptions": {
 			input:       "curl -XGET {{url|API endpoint}} -H {{header|auth header}}",
 			wantCommand: "curl -XGET {{url|API endpoint}} -H {{header|auth header}}",
-			wantParams:  Parameters{{"url", "API endpoint"}, {"header", "auth header"}},
+			wantParams:  Parameters{{Name: "url", Description: "API endpoint"}, {Name: "header", Description: "auth header"}},
 		},
 		"extra-spacing": {
 			input:       "  {{  one  }}  some     text  {{two}} more text {{three}}  ",
 			wantCommand: "{{one}} some text {{two}} more text {{three}}",
-			wantParams:  Parameters{{"one", ""}, {"two", ""}, {"three", ""}},
+			wantParams:  Parameters{{Name: "one", Description: ""}, {Name: "two", Description: ""}, {Name: "three", Description: ""}},
 		},
 		"extra-spacing-with-desc": {
 			input:       "{{ one | desc1 }} text {{two | | description   }} more {{three}}",
 			wantCommand: "{{one|desc1}} text {{two|| description}} more {{three}}",
-			wantParams:  Parameters{{"one", "desc1"}, {"two", "| description"}, {"three", ""}},
+			wantParams:  Parameters{{Name: "one", Description: "desc1"}, {Name: "two", Description: "| description"}, {Name: "three", Description: ""}},
 		},
 		"duplicates-longer-description": {
 			input:       "{{one|short}} text {{one|longer description}} end",
 			wantCommand: "{{one|short}} text {{one|longer description}} end",
-			wantParams:  Parameters{{"one", "longer description"}},
+			wantParams:  Parameters{{Name: "one", Description: "longer description"}},
 		},
 		"no-parameters": {
 			input:       "Just plain text",
@@ -2286,7 +4135,7 @@ func TestParse(t *testing.T) { //nolint:funlen,gocognit,cyclop
 		"single-parameter": {
 			input:       "{{single}}",
 			wantCommand: "{{single}}",
-			wantParams:  Parameters{{"single", ""}},
+			wantParams:  Parameters{{Name: "single", Description: ""}},
 		},
 		"empty-brackets": {
 			input:       "{{}}",
@@ -2296,12 +4145,12 @@ func TestParse(t *testing.T) { //nolint:funlen,gocognit,cyclop
 		"at-character-limit": {
 			input:       "{{" + fortyCharVar + "}}",
 			wantCommand: "{{" + fortyCharVar + "}}",
-			wantParams:  Parameters{{fortyCharVar, ""}},
+			wantParams:  Parameters{{Name: fortyCharVar, Description: ""}},
 		},
 		"multiple-with-pipes": {
 			input:       "{{first|desc1}} {{second||}} {{third|||}}",
 			wantCommand: "{{first|desc1}} {{second||}} {{third|||}}",
-			wantParams:  Parameters{{"first", "desc1"}, {"second", "|"}, {"third", "||"}},
+			wantParams:  Parameters{{Name: "first", Description: "desc1"}, {Name: "second", Description: "|"}, {Name: "third", Description: "||"}},
 		},
 		"exceeds-character-limit": {
 			input:   "{{" + fortyOneCharVar + "}}",
@@ -2386,3 +4235,158 @@ func TestParse(t *testing.T) { //nolint:funlen,gocognit,cyclop
 		})
 	}
 }
+
+// fuzzSeeds are inputs that have tripped up the brace scanner before.
+var fuzzSeeds = []string{
+	"", "{", "}", "{{", "}}", "{{}}", "{{|", "{{|}}", "{{a", "a}}", "{{{a}}}", "{{a}}}", "{{{{a}}",
+	"{{ a | b }}", "{{!a}}", "{{a}}{{b}}", "echo {{name|desc}} \\\n  --flag", "x {{ y } z", "%s {{a}} 100%", "{{a } }}",
+}
+
+// idempotenceCorpus holds the inputs of the ParseCommand tests above, plus
+// quoting, multi-pipe description and escaped brace cases, for checking that
+// normalizing twice gives the same result as normalizing once.
+var idempotenceCorpus = []string{
+	// TestParseCommand
+	"  Hello, {{name}}! Welcome to {{place}}.  ",
+	"{{one}} some     text {{two}} more text {{three}}",
+	"{{ one }} some text {{ two  }} more text {{three}}",
+	"{{ one | a normal description }} some text {{two}} more text {{three}}",
+	"{{ one }} some text {{two | | description   }} more text {{three}}",
+	// TestParseCommand_LineContinuation
+	"kubectl apply \\\n  -f {{file}} \\\n  --dry-run",
+	"make \\\r\n\tbuild",
+	"echo   a    \\\n    b",
+	"echo a\\\\\n   b",
+	"echo   a\n\n   {{b}}\t c",
+	// TestParseCommand_DescriptionCasing
+	"echo {{ x | upper }}",
+	"curl {{ url | The Target URL }}",
+	// TestParseCommand_Unterminated
+	"echo  {{",
+	"echo  {{ name",
+	"echo {{name|desc",
+	"echo {{ name }}  {{name",
+	// Quoted spaces
+	`echo "a   b" {{x}}`,
+	`echo 'hello    world'  {{ name }}`,
+	`printf "%s  %s\n" {{a}} {{b}}`,
+	// Descriptions with several pipes, with and without a pattern
+	"{{ x | one | two | three }}",
+	"{{ x | a | /^\\d+$/ }}",
+	"{{ x | a | b | /re/ }}",
+	"{{x|a|/b/|c}}",
+	"{{x| |/a/}}",
+	"{{ x | | }}",
+	// Escaped and stray braces
+	`echo \{\{x\}\}`,
+	`echo \{{x}}`,
+	`echo {{x\}}`,
+	`awk '{print $1}' {{file}}`,
+	`find . -name {{pattern}} -exec rm {} \;`,
+	"echo {{ a } }}",
+	"echo {{a|b}}}}",
+}
+
+// Changes package-level state, so it must not run in parallel with other tests.
+func TestParseCommand_Idempotent(t *testing.T) { //nolint:paralleltest
+	corpus := slices.Concat(fuzzSeeds, idempotenceCorpus, []string{"echo a ; ;", "cat {{file}} | ;"})
+
+	for _, trim := range []bool{false, true} {
+		SetTrimTrailingSeparator(trim)
+
+		for _, input := range corpus {
+			once, err := ParseCommand(input)
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", input, err)
+			}
+
+			twice, err := ParseCommand(once)
+			if err != nil {
+				t.Fatalf("ParseCommand(%q) = %q, which fails to parse again: %v", input, once, err)
+			}
+
+			if twice != once {
+				t.Fatalf("ParseCommand is not idempotent for %q (trim %v): %q then %q", input, trim, once, twice)
+			}
+		}
+	}
+
+	SetTrimTrailingSeparator(false)
+}
+
+func BenchmarkParseParameters(b *testing.B) {
+	input := `deploy --env {{env|target environment}} --region {{region}} --tag {{tag|release tag}}`
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := ParseParameters(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseParameters_Pattern(b *testing.B) {
+	input := `serve --port {{port|the port|/^\d+$/}} --host {{host|the host|/^[a-z.]+$/}}`
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := ParseParameters(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzParseCommand(f *testing.F) {
+	for _, seed := range slices.Concat(fuzzSeeds, idempotenceCorpus) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		once, err := ParseCommand(input)
+		if err != nil {
+			return
+		}
+
+		twice, err := ParseCommand(once)
+		if err != nil {
+			t.Fatalf("ParseCommand(%q) = %q, which fails to parse again: %v", input, once, err)
+		}
+
+		if twice != once {
+			t.Fatalf("ParseCommand is not idempotent for %q: %q then %q", input, once, twice)
+		}
+
+		// The parameters found are stable too
+		first, firstErr := ParseParameters(once)
+		second, secondErr := ParseParameters(twice)
+
+		if (firstErr == nil) != (secondErr == nil) || !slices.Equal(first, second) {
+			t.Fatalf("ParseParameters is not stable for %q: %v, %v then %v, %v", input, first, firstErr, second, secondErr)
+		}
+	})
+}
+
+func FuzzParseParameters(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(_ *testing.T, input string) {
+		_, _ = ParseParameters(input)
+		_, _ = ParseSecrets(input)
+	})
+}
+
+func FuzzHydrateStringSafe(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, "value")
+	}
+
+	f.Fuzz(func(_ *testing.T, input, value string) {
+		vp := ValuedParameters{{Name: "a", Value: value}, {Name: "name", Value: value}}
+
+		_ = HydrateStringSafe(input, vp)
+	})
+}
@@ -0,0 +1,12 @@
+package brackets
+
+import "strings"
+
+// ShellQuoteForExport quotes s as a single word for a POSIX shell, so a stored
+// command written into a script, as in shed add name '<command>', reads back
+// unchanged. The whole string is wrapped in single quotes, inside which the
+// shell expands nothing: $, backticks, and {{param}} blocks stay literal. A
+// single quote in s ends the quoting, is written escaped, and quoting resumes.
+func ShellQuoteForExport(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
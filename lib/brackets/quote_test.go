@@ -0,0 +1,73 @@
+package brackets
+
+import (
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestShellQuoteForExport(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"plain":         {input: "ls -la", want: `'ls -la'`},
+		"empty":         {input: "", want: `''`},
+		"single quotes": {input: `echo 'hi'`, want: `'echo '\''hi'\'''`},
+		"dollar":        {input: `echo $HOME`, want: `'echo $HOME'`},
+		"brace blocks":  {input: `deploy {{env|target}} {{!token}}`, want: `'deploy {{env|target}} {{!token}}'`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ShellQuoteForExport(tc.input); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestShellQuoteForExport_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+
+	inputs := []string{
+		`echo 'it''s' "$USER" $(whoami) ` + "`date`",
+		`deploy --env {{env|target environment}} --token {{!token}}`,
+		`grep -E '^{{pattern|what to match}}$' {{file}} | sed 's/a/b/' \` + "\n  && echo done",
+		`printf '%s\n' {a,b} * ~ ; exit`,
+	}
+
+	for _, input := range inputs {
+		out, err := exec.Command("sh", "-c", "printf %s "+ShellQuoteForExport(input)).Output()
+		if err != nil {
+			t.Fatalf("unexpected error running the shell for %q: %v", input, err)
+		}
+
+		if string(out) != input {
+			t.Fatalf("expected %q back from the shell, got %q", input, out)
+		}
+
+		want, err := Parse(input)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", input, err)
+		}
+
+		got, err := Parse(string(out))
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", out, err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package brackets
+
+import "strings"
+
+// TokenKind labels a segment of a command template. Templates have no
+// default-value syntax, so there is no kind for a default; one would be added
+// with that syntax.
+type TokenKind int
+
+const (
+	// TokenLiteral is text outside of any {{...}} block.
+	TokenLiteral TokenKind = iota
+	// TokenParamOpen is the opening {{ of a block.
+	TokenParamOpen
+	// TokenName is the parameter or secret name, including surrounding spaces.
+	TokenName
+	// TokenSeparator is the | between a name and its description, or between
+	// a description and its pattern.
+	TokenSeparator
+	// TokenDescription is everything after the first separator, including
+	// further pipes, up to a pattern.
+	TokenDescription
+	// TokenParamClose is the closing }} of a block.
+	TokenParamClose
+	// TokenPattern is the /.../ third field of a block, including surrounding
+	// spaces, as in {{port|the port|/^\d+$/}}.
+	TokenPattern
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenLiteral:
+		return "Literal"
+	case TokenParamOpen:
+		return "ParamOpen"
+	case TokenName:
+		return "Name"
+	case TokenSeparator:
+		return "Separator"
+	case TokenDescription:
+		return "Description"
+	case TokenParamClose:
+		return "ParamClose"
+	case TokenPattern:
+		return "Pattern"
+	default:
+		return "Unknown"
+	}
+}
+
+// Token is a labeled byte range [Start, End) of a template. Text is the raw
+// input in that range, so concatenating the Text of every token returns the
+// original input.
+type Token struct {
+	Kind  TokenKind
+	Start int
+	End   int
+	Text  string
+}
+
+// Tokenize splits a command template into a token stream using the same block
+// scanning as Parse. An unterminated {{ is returned as literal text.
+func Tokenize(input string) []Token {
+	var tokens []Token
+
+	add := func(kind TokenKind, start, end int) {
+		if start < end {
+			tokens = append(tokens, Token{Kind: kind, Start: start, End: end, Text: input[start:end]})
+		}
+	}
+
+	literalStart := 0
+	i := 0
+
+	for i < len(input) {
+		end := bracketEnd(input, i)
+		if end < 0 {
+			i++
+
+			continue
+		}
+
+		add(TokenLiteral, literalStart, i)
+		add(TokenParamOpen, i, i+2)
+
+		contentStart, contentEnd := i+2, end-2
+		if pipe := strings.IndexByte(input[contentStart:contentEnd], '|'); pipe >= 0 {
+			sep := contentStart + pipe
+			add(TokenName, contentStart, sep)
+			add(TokenSeparator, sep, sep+1)
+			addDescription(add, input, sep+1, contentEnd)
+		} else {
+			add(TokenName, contentStart, contentEnd)
+		}
+
+		add(TokenParamClose, contentEnd, end)
+
+		i = end
+		literalStart = end
+	}
+
+	add(TokenLiteral, literalStart, len(input))
+
+	return tokens
+}
+
+// addDescription adds the description in input[start:end], splitting off a
+// pattern field the way Parse does.
+func addDescription(add func(kind TokenKind, start, end int), input string, start, end int) {
+	i := patternSeparator(input[start:end])
+	if i < 0 {
+		add(TokenDescription, start, end)
+
+		return
+	}
+
+	sep := start + i
+	add(TokenDescription, start, sep)
+	add(TokenSeparator, sep, sep+1)
+	add(TokenPattern, sep+1, end)
+}
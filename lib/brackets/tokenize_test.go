@@ -0,0 +1,133 @@
+package brackets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	type testcase struct {
+		input string
+		want  []Token
+	}
+
+	inputs := map[string]testcase{
+		"empty": {
+			input: "",
+			want:  nil,
+		},
+		"literal-only": {
+			input: "ls -la",
+			want:  []Token{{Kind: TokenLiteral, Start: 0, End: 6, Text: "ls -la"}},
+		},
+		"name-only": {
+			input: "ls {{path}}",
+			want: []Token{
+				{Kind: TokenLiteral, Start: 0, End: 3, Text: "ls "},
+				{Kind: TokenParamOpen, Start: 3, End: 5, Text: "{{"},
+				{Kind: TokenName, Start: 5, End: 9, Text: "path"},
+				{Kind: TokenParamClose, Start: 9, End: 11, Text: "}}"},
+			},
+		},
+		"description-and-secret": {
+			input: "curl {{ url | a | b }} -H {{!token}}.",
+			want: []Token{
+				{Kind: TokenLiteral, Start: 0, End: 5, Text: "curl "},
+				{Kind: TokenParamOpen, Start: 5, End: 7, Text: "{{"},
+				{Kind: TokenName, Start: 7, End: 12, Text: " url "},
+				{Kind: TokenSeparator, Start: 12, End: 13, Text: "|"},
+				{Kind: TokenDescription, Start: 13, End: 20, Text: " a | b "},
+				{Kind: TokenParamClose, Start: 20, End: 22, Text: "}}"},
+				{Kind: TokenLiteral, Start: 22, End: 26, Text: " -H "},
+				{Kind: TokenParamOpen, Start: 26, End: 28, Text: "{{"},
+				{Kind: TokenName, Start: 28, End: 34, Text: "!token"},
+				{Kind: TokenParamClose, Start: 34, End: 36, Text: "}}"},
+				{Kind: TokenLiteral, Start: 36, End: 37, Text: "."},
+			},
+		},
+		"pattern": {
+			input: `{{port|the port| /^(80|443)$/ }}`,
+			want: []Token{
+				{Kind: TokenParamOpen, Start: 0, End: 2, Text: "{{"},
+				{Kind: TokenName, Start: 2, End: 6, Text: "port"},
+				{Kind: TokenSeparator, Start: 6, End: 7, Text: "|"},
+				{Kind: TokenDescription, Start: 7, End: 15, Text: "the port"},
+				{Kind: TokenSeparator, Start: 15, End: 16, Text: "|"},
+				{Kind: TokenPattern, Start: 16, End: 30, Text: " /^(80|443)$/ "},
+				{Kind: TokenParamClose, Start: 30, End: 32, Text: "}}"},
+			},
+		},
+		"slashes in a description": {
+			input: "{{dir|path like /tmp/}}",
+			want: []Token{
+				{Kind: TokenParamOpen, Start: 0, End: 2, Text: "{{"},
+				{Kind: TokenName, Start: 2, End: 5, Text: "dir"},
+				{Kind: TokenSeparator, Start: 5, End: 6, Text: "|"},
+				{Kind: TokenDescription, Start: 6, End: 21, Text: "path like /tmp/"},
+				{Kind: TokenParamClose, Start: 21, End: 23, Text: "}}"},
+			},
+		},
+		"unterminated": {
+			input: "echo {{name",
+			want:  []Token{{Kind: TokenLiteral, Start: 0, End: 11, Text: "echo {{name"}},
+		},
+	}
+
+	for name, tc := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Tokenize(tc.input)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected tokens %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTokenize_Reconstructs(t *testing.T) {
+	t.Parallel()
+
+	input := "deploy {{env|target | stage}} --tag {{ version }} {{!api_key|token}} {{}} done {{"
+
+	var b strings.Builder
+
+	prev := 0
+
+	for _, tok := range Tokenize(input) {
+		if tok.Start != prev {
+			t.Fatalf("expected token to start at %v, got %v", prev, tok.Start)
+		}
+
+		if tok.Text != input[tok.Start:tok.End] {
+			t.Fatalf("expected token text %q, got %q", input[tok.Start:tok.End], tok.Text)
+		}
+
+		b.WriteString(tok.Text)
+
+		prev = tok.End
+	}
+
+	if b.String() != input {
+		t.Fatalf("expected %q, got %q", input, b.String())
+	}
+}
+
+func TestTokenKind_String(t *testing.T) {
+	t.Parallel()
+
+	if got := TokenDescription.String(); got != "Description" {
+		t.Fatalf("expected %q, got %q", "Description", got)
+	}
+
+	if got := TokenPattern.String(); got != "Pattern" {
+		t.Fatalf("expected %q, got %q", "Pattern", got)
+	}
+
+	if got := TokenKind(99).String(); got != "Unknown" {
+		t.Fatalf("expected %q, got %q", "Unknown", got)
+	}
+}
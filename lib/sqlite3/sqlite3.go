@@ -14,9 +14,13 @@ import (
 )
 
 const (
-	defaultTargetVersion  = 1
+	defaultTargetVersion  = 8
 	defaultCipherPageSize = 4096
 	conn                  = "file:%s?_key=%s&_cipher_page_size=%d&cache=shared&_journal_mode=WAL&_busy_timeout=10000"
+
+	// connReadOnly skips the shared cache so a read-only handle never shares pages
+	// with a writable connection in the same process.
+	connReadOnly = "file:%s?_key=%s&_cipher_page_size=%d&mode=ro&_query_only=true&_busy_timeout=10000"
 )
 
 var ErrDirtyMigration = errors.New("migration is dirty, intervention is needed")
@@ -34,6 +38,21 @@ func DB(dbPath, encryptionKey string) (*sql.DB, error) {
 	return db, nil
 }
 
+// DBReadOnly opens the database at dbPath for reading only. Any write through
+// the returned handle fails with SQLITE_READONLY.
+func DBReadOnly(dbPath, encryptionKey string) (*sql.DB, error) {
+	dbname := fmt.Sprintf(connReadOnly, dbPath, encryptionKey, defaultCipherPageSize)
+
+	db, err := sql.Open("sqlite3", dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
 func MigrateShedDB(dbPath, encryptionKey string) error {
 	dbname := fmt.Sprintf(conn, dbPath, encryptionKey, defaultCipherPageSize)
 
@@ -53,6 +72,37 @@ func MigrateShedDB(dbPath, encryptionKey string) error {
 	return runMigrations(m)
 }
 
+// SchemaVersion returns the migration version this build migrates databases to.
+func SchemaVersion() uint {
+	return defaultTargetVersion
+}
+
+// ShedDBVersion returns the migration version of the database at dbPath and
+// whether its last migration failed part way. The database is opened read-only.
+func ShedDBVersion(dbPath, encryptionKey string) (uint, bool, error) {
+	db, err := DBReadOnly(dbPath, encryptionKey)
+	if err != nil {
+		return 0, false, err
+	}
+	defer closeDatabase(db)
+
+	var (
+		version uint
+		dirty   bool
+	)
+
+	err = db.QueryRow("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("could not get current migration version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
 func MigrateDB(db *sql.DB) error {
 	m, err := createMigrator(db)
 	if err != nil {
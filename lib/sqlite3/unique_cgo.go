@@ -0,0 +1,17 @@
+//go:build cgo
+
+package sqlite3
+
+import (
+	"errors"
+
+	dsqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// IsUniqueViolation reports whether err comes from a UNIQUE constraint failing,
+// such as inserting a name that is already taken.
+func IsUniqueViolation(err error) bool {
+	var sqliteErr dsqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == dsqlite3.ErrConstraintUnique
+}
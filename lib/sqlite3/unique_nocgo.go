@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package sqlite3
+
+import "strings"
+
+// IsUniqueViolation reports whether err comes from a UNIQUE constraint failing.
+// Without cgo the driver has no typed errors, so this matches the SQLite
+// message instead. Such builds cannot open a database, so it is rarely reached.
+func IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}